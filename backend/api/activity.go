@@ -0,0 +1,51 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// minimum time between two updates of the last action of a user
+const activityResolution = time.Minute
+
+// records the last action of a user. To not write on every request,
+// the time is only updated once per activityResolution
+func touchUser(uid int) {
+	key := strconv.Itoa(uid)
+
+	if _, found := cachedActivity.Get(key); found {
+		return
+	}
+
+	if _, err := store.Exec("UPDATE users SET lastaction = ? WHERE uid = ?", time.Now().Format(time.DateTime), uid); err != nil {
+		logger.Error().Msgf("can't store last action of user with uid = %q: %v", uid, err)
+	} else {
+		cachedActivity.Set(key, struct{}{})
+	}
+}
+
+// reactivates a deactivated account
+func reactivateUser(uid int) error {
+	return store.Update("users", struct {
+		Deactivated bool
+		Lastaction  string
+	}{Lastaction: time.Now().Format(time.DateTime)}, struct{ Uid int }{Uid: uid})
+}
+
+// deactivates the accounts without any activity since the configured time. Accounts
+// that were never used count from their creation, the admin-account is never deactivated
+func deactivateInactiveUsers() error {
+	threshold := time.Now().Add(-config.Users.DeactivateAfter).Format(time.DateTime)
+
+	if res, err := store.Exec("UPDATE users SET deactivated = TRUE WHERE NOT deactivated AND name != 'admin' AND COALESCE(lastaction, lastlogin, created) < ?", threshold); err != nil {
+		return err
+	} else if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count > 0 {
+		logger.Info().Msgf("deactivated %d inactive users", count)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum number of renumberings followed when resolving a mid
+const maxAliasDepth = 16
+
+// tables referencing an element by its mid, they are renumbered together with it. The
+// certificates, the archive and the merged contacts keep the mid they were recorded with
+var renumberedTables = []string{
+	"elements", "postaladdresses", "matchings", "consents", "mailqueue", "reservationclients",
+	"expiries", "audit", "notifications",
+}
+
+// resolves a mid through the renumberings to the current one. Mids that exist or
+// were never renumbered are returned unchanged
+func resolveMid(mid string) (string, error) {
+	for range maxAliasDepth {
+		if elements, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+			return "", err
+		} else if len(elements) == 1 {
+			return mid, nil
+		} else if aliases, err := store.Select[MidAlias]("midaliases", "old = ? ORDER BY aid DESC LIMIT 1", mid); err != nil {
+			return "", err
+		} else if len(aliases) == 0 {
+			return mid, nil
+		} else {
+			mid = aliases[0].New
+		}
+	}
+
+	return mid, nil
+}
+
+// returns the mid and all previous mids of an element
+func midHistory(mid string) ([]string, error) {
+	history := []string{mid}
+
+	for ii := 0; ii < len(history) && ii < maxAliasDepth; ii++ {
+		if aliases, err := store.Select[MidAlias]("midaliases", "new = ?", history[ii]); err != nil {
+			return nil, err
+		} else {
+			for _, alias := range aliases {
+				history = append(history, alias.Old)
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// handles get-requests resolving a (possibly renumbered) mid to the current one
+func getElementsResolve(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msg("query doesn't include valid mid")
+	} else if current, err := resolveMid(mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't resolve mid %q: %v", mid, err)
+	} else {
+		response.Data = ElementAlias{Mid: current}
+	}
+
+	return response
+}
+
+// handles get-requests for the history of the renumberings
+func getElementsAliases(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if aliases, err := store.Select[MidAlias]("midaliases", "aid > 0 ORDER BY aid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get mid-aliases from database: %v", err)
+	} else {
+		response.Data = aliases
+	}
+
+	return response
+}
+
+// handles post-requests renumbering an element and the data referencing it from "mid" to "to".
+// The old mid resolves to the new one, issued certificates keep their original mid
+func postElementsRenumber(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mid := c.Query("mid")
+	to := c.Query("to")
+
+	if ok, err := isValidMid(to); err != nil || !ok {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid target element-name"
+
+		logger.Info().Msgf("can't renumber element: invalid element-name: %q", to)
+	} else if elements, err := store.Select[ElementDB]("elements", "mid = ? OR mid = ?", mid, to); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else if len(elements) != 1 || elements[0].Mid != mid {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "element doesn't exist or target is already taken"
+
+		logger.Info().Msgf("can't renumber element %q to %q: element doesn't exist or target is already taken", mid, to)
+	} else if err := store.Transaction(func(tx *store.Tx) error {
+		// a partial renumbering would separate the element from its data
+		for _, table := range renumberedTables {
+			if _, err := tx.Exec("UPDATE "+table+" SET mid = ? WHERE mid = ?", to, mid); err != nil {
+				return fmt.Errorf("can't renumber %q: %v", table, err)
+			}
+		}
+
+		return tx.Insert("midaliases", struct {
+			Old     string
+			New     string
+			Renamed string
+			Uid     int
+		}{Old: mid, New: to, Renamed: time.Now().Format(time.DateTime), Uid: getUser(c).Uid})
+	}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't renumber element %q to %q: %v", mid, to, err)
+	} else {
+		cachedElements.Delete("status")
+
+		logger.Info().Msgf("renumbered element %q to %q", mid, to)
+
+		response = getElementsAliases(c)
+	}
+
+	return response
+}
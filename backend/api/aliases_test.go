@@ -0,0 +1,111 @@
+package api
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"gopkg.in/yaml.v3"
+)
+
+// config with the elements "pv-a1" to "pv-a10"
+func renumberConfig(t *testing.T) backendConfig.ConfigStruct {
+	t.Helper()
+
+	cfg := testConfig()
+
+	if mids, err := backendConfig.NewMidScheme(`^(pv-\w)(\d{1,2})$`); err != nil {
+		t.Fatalf("can't parse mid-scheme: %v", err)
+	} else {
+		cfg.Mids = mids
+	}
+
+	if err := yaml.Unmarshal([]byte(`
+valid_elements:
+  pv-a: {from: 1, to: 10}
+`), &cfg.ValidateElements); err != nil {
+		t.Fatalf("can't parse valid elements: %v", err)
+	}
+
+	return cfg
+}
+
+// answers the statements of a renumbering of "pv-a1", the update of the failing table returns an error.
+// The renumbered tables are recorded
+func renumberHandler(failing string, renumbered *[]string) func(string, []driver.Value) (fakeResult, error) {
+	return func(query string, args []driver.Value) (fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT ") && strings.HasSuffix(query, " FROM elements WHERE mid = ? OR mid = ?"):
+			return selectResult(query, map[string]driver.Value{"mid": "pv-a1", "name": "Sponsor", "pending": false, "fields": "{}"}), nil
+		case strings.HasPrefix(query, "SELECT ") && strings.Contains(query, " FROM midaliases "):
+			return selectResult(query), nil
+		case strings.HasPrefix(query, "INSERT INTO midaliases "):
+			return fakeResult{affected: 1}, nil
+		case strings.HasPrefix(query, "UPDATE ") && strings.HasSuffix(query, " SET mid = ? WHERE mid = ?"):
+			table := strings.Fields(query)[1]
+
+			if table == failing {
+				return fakeResult{}, errors.New("lock wait timeout exceeded")
+			}
+
+			*renumbered = append(*renumbered, table)
+
+			return fakeResult{affected: 1}, nil
+		default:
+			return fakeResult{}, fmt.Errorf("unexpected statement: %s", query)
+		}
+	}
+}
+
+// the element and all the tables referencing it are renumbered in a single transaction
+func TestPostElementsRenumber(t *testing.T) {
+	var renumbered []string
+
+	fake := useFakeDB(t, renumberConfig(t), renumberHandler("", &renumbered))
+
+	status := requestAs(t, fiber.MethodPost, UserDB{Uid: 1, Name: "admin", Admin: true}, postElementsRenumber, "/api/elements/renumber?mid=pv-a1&to=pv-a2", "")
+
+	if status != fiber.StatusOK {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusOK)
+	}
+
+	for _, table := range []string{"elements", "postaladdresses", "matchings", "consents", "mailqueue", "reservationclients", "expiries", "audit"} {
+		if !slices.Contains(renumbered, table) {
+			t.Errorf("%q wasn't renumbered", table)
+		}
+	}
+
+	if fake.count("BEGIN") != 1 || fake.count("COMMIT") != 1 || fake.count("ROLLBACK") != 0 {
+		t.Error("renumbering wasn't committed as a single transaction")
+	}
+
+	if count := fake.count("INSERT INTO midaliases "); count != 1 {
+		t.Errorf("alias was stored %d times, expected once", count)
+	}
+}
+
+// a failing table rolls back the renumbering of the others
+func TestPostElementsRenumberRollback(t *testing.T) {
+	var renumbered []string
+
+	fake := useFakeDB(t, renumberConfig(t), renumberHandler("mailqueue", &renumbered))
+
+	status := requestAs(t, fiber.MethodPost, UserDB{Uid: 1, Name: "admin", Admin: true}, postElementsRenumber, "/api/elements/renumber?mid=pv-a1&to=pv-a2", "")
+
+	if status != fiber.StatusInternalServerError {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusInternalServerError)
+	}
+
+	if fake.count("ROLLBACK") != 1 || fake.count("COMMIT") != 0 {
+		t.Error("renumbering wasn't rolled back")
+	}
+
+	if count := fake.count("INSERT INTO midaliases "); count != 0 {
+		t.Errorf("alias was stored %d times", count)
+	}
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// header the partner-websites send their api-key in
+const apiKeyHeader = "X-API-Key"
+
+// key under which the api-key of the request is stored in the request-locals
+const localsAPIKey = "apikey"
+
+// api-key in the database, only the hash of the key is stored
+type APIKeyDB struct {
+	Kid     int
+	Name    string
+	Keyhash string
+	// allowed origins, separated by commas
+	Origins string
+	// maximum number of requests per day, zero for unlimited
+	Quota   int
+	Created string
+	Revoked *string
+}
+
+// hashes an api-key for the lookup in the database
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// returns the active api-keys by their hash
+func getActiveAPIKeys() (map[string]APIKeyDB, error) {
+	if keys, found := cachedAPIKeys.Get("active"); found {
+		return keys, nil
+	} else if res, err := store.Select[APIKeyDB]("apikeys", "revoked IS NULL"); err != nil {
+		return nil, err
+	} else {
+		keys := make(map[string]APIKeyDB, len(res))
+
+		for _, key := range res {
+			keys[key.Keyhash] = key
+		}
+
+		cachedAPIKeys.Set("active", keys)
+
+		return keys, nil
+	}
+}
+
+// splits the comma-separated origins of an api-key
+func (key APIKeyDB) origins() []string {
+	origins := []string{}
+
+	for _, origin := range strings.Split(key.Origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return origins
+}
+
+// allows cross-origin-requests from the origins of the active api-keys
+var APIKeyCORS = cors.New(cors.Config{
+	AllowOriginsFunc: func(origin string) bool {
+		if keys, err := getActiveAPIKeys(); err != nil {
+			logger.Error().Msgf("can't get api-keys: %v", err)
+
+			return false
+		} else {
+			for _, key := range keys {
+				if slices.Contains(key.origins(), origin) {
+					return true
+				}
+			}
+
+			return false
+		}
+	},
+	AllowHeaders:  "Content-Type, " + apiKeyHeader,
+	ExposeHeaders: warningsHeader + ", " + messageCodeHeader,
+})
+
+// middleware checking the api-key of partner-requests. Requests without a key
+// are passed unchanged, requests with one are checked against its origins and quota
+func CheckAPIKey(c *fiber.Ctx) error {
+	response := responseMessage{}
+
+	key := c.Get(apiKeyHeader)
+
+	if key == "" {
+		return c.Next()
+	}
+
+	if keys, err := getActiveAPIKeys(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get api-keys: %v", err)
+	} else if apiKey, ok := keys[hashAPIKey(key)]; !ok {
+		response.Status = fiber.StatusUnauthorized
+		response.Message = "invalid api-key"
+
+		logger.Info().Msg("request with invalid api-key")
+	} else if origins := apiKey.origins(); len(origins) > 0 && !slices.Contains(origins, c.Get(fiber.HeaderOrigin)) {
+		response.Status = fiber.StatusForbidden
+		response.Message = "origin not allowed for api-key"
+
+		logger.Info().Msgf("api-key %q used from foreign origin %q", apiKey.Name, c.Get(fiber.HeaderOrigin))
+	} else if requests, err := countAPIKeyRequest(apiKey.Kid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't count request of api-key %q: %v", apiKey.Name, err)
+	} else if apiKey.Quota > 0 && requests > apiKey.Quota {
+		response.Status = fiber.StatusTooManyRequests
+		response.Message = "quota of api-key exceeded"
+
+		logger.Info().Msgf("quota of api-key %q exceeded", apiKey.Name)
+	} else {
+		c.Locals(localsAPIKey, apiKey)
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// count the successful reservations separately
+		if c.Method() == fiber.MethodPost && c.Response().StatusCode() < fiber.StatusBadRequest {
+			if _, err := store.Exec("UPDATE apikeyusage SET reservations = reservations + 1 WHERE kid = ? AND day = ?", apiKey.Kid, time.Now().Format(time.DateOnly)); err != nil {
+				logger.Error().Msgf("can't count reservation of api-key %q: %v", apiKey.Name, err)
+			}
+		}
+
+		return nil
+	}
+
+	return response.send(c)
+}
+
+// counts a request of an api-key
+//
+// @returns the number of requests of the key today
+func countAPIKeyRequest(kid int) (int, error) {
+	today := time.Now().Format(time.DateOnly)
+
+	if _, err := store.Exec("INSERT INTO apikeyusage (kid, day, requests) VALUES (?, ?, 1) ON DUPLICATE KEY UPDATE requests = requests + 1", kid, today); err != nil {
+		return 0, err
+	}
+
+	var requests int
+
+	err := store.QueryRow("SELECT requests FROM apikeyusage WHERE kid = ? AND day = ?", kid, today).Scan(&requests)
+
+	return requests, err
+}
+
+// returns the name of the api-key of the request, empty for requests without one
+func getAPIKeyName(c *fiber.Ctx) string {
+	if apiKey, ok := c.Locals(localsAPIKey).(APIKeyDB); ok {
+		return apiKey.Name
+	} else {
+		return ""
+	}
+}
+
+// handles get-requests for the api-keys with their total usage
+func getAPIKeys(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if keys, err := store.Select[APIKeyDB]("apikeys", "kid > 0 ORDER BY kid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get api-keys from database: %v", err)
+	} else {
+		result := make([]APIKey, len(keys))
+
+		for ii, key := range keys {
+			result[ii] = APIKey{
+				Kid:     key.Kid,
+				Name:    key.Name,
+				Origins: key.origins(),
+				Quota:   key.Quota,
+				Created: key.Created,
+				Revoked: key.Revoked,
+			}
+
+			if err := store.QueryRow("SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(reservations), 0) FROM apikeyusage WHERE kid = ?", key.Kid).Scan(&result[ii].Requests, &result[ii].Reservations); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't get usage of api-key %q: %v", key.Name, err)
+
+				return response
+			}
+		}
+
+		response.Data = result
+	}
+
+	return response
+}
+
+// handles get-requests for the daily usage of an api-key
+func getAPIKeysUsage(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if kid := c.QueryInt("kid", -1); kid < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid kid"
+
+		logger.Info().Msg("query doesn't include valid kid")
+	} else if usage, err := store.Select[APIKeyUsage]("apikeyusage", "kid = ? ORDER BY day", kid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get usage of api-key %d: %v", kid, err)
+	} else {
+		response.Data = usage
+	}
+
+	return response
+}
+
+// handles post-requests for creating an api-key. The key is only returned once
+func postAPIKeys(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := APIKeyBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ name string; origins []string; quota int }"`)
+	} else if body.Name == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "api-key needs a name"
+
+		logger.Info().Msg("can't create api-key: name is missing")
+	} else {
+		secret := make([]byte, 24)
+
+		if _, err := rand.Read(secret); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't create api-key: %v", err)
+		} else {
+			key := hex.EncodeToString(secret)
+
+			if err := store.Insert("apikeys", struct {
+				Name    string
+				Keyhash string
+				Origins string
+				Quota   int
+				Created string
+			}{
+				Name:    body.Name,
+				Keyhash: hashAPIKey(key),
+				Origins: strings.Join(body.Origins, ","),
+				Quota:   body.Quota,
+				Created: time.Now().Format(time.DateTime),
+			}); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't write api-key to database: %v", err)
+			} else {
+				cachedAPIKeys.Delete("active")
+
+				response.Data = NewAPIKey{Name: body.Name, Key: key}
+
+				logger.Info().Msgf("created api-key %q", body.Name)
+			}
+		}
+	}
+
+	return response
+}
+
+// handles delete-requests revoking an api-key, the usage-statistics are kept
+func deleteAPIKeys(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if kid := c.QueryInt("kid", -1); kid < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid kid"
+
+		logger.Info().Msg("query doesn't include valid kid")
+	} else if isDryRun(c) {
+		keys, err := affectedKeys("apikeys", "kid = ? AND revoked IS NULL", func(row struct{ Kid int }) string { return strconv.Itoa(row.Kid) }, kid)
+
+		response = dryRunResponse(appendAffected([]AffectedRows{}, "apikeys", "update", "revocation", keys), err)
+	} else if err := store.Update("apikeys", struct{ Revoked string }{Revoked: time.Now().Format(time.DateTime)}, struct{ Kid int }{Kid: kid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't revoke api-key %d: %v", kid, err)
+	} else {
+		cachedAPIKeys.Delete("active")
+
+		logger.Info().Msgf("revoked api-key %d", kid)
+
+		response = getAPIKeys(c)
+	}
+
+	return response
+}
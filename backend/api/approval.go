@@ -0,0 +1,110 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// data available in the rejection-templates
+type RejectionTemplateData struct {
+	certs.SponsorshipTemplateData
+	Reason string
+}
+
+// retrieves a reservation awaiting approval
+func getPendingReservation(c *fiber.Ctx) (ElementDB, responseMessage) {
+	var response responseMessage
+
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msg("query doesn't include valid mid")
+	} else if res, err := store.Select[ElementDB]("elements", "mid = ? AND reservation IS NOT NULL", mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get reservation for %q from database: %v", mid, err)
+	} else if len(res) != 1 || !res[0].Pending {
+		response.Status = fiber.StatusNotFound
+		response.Message = "no pending reservation found"
+
+		logger.Info().Msgf("no pending reservation for %q", mid)
+	} else {
+		return res[0], response
+	}
+
+	return ElementDB{}, response
+}
+
+// handles post-requests for approving a reservation
+func postReservationsApprove(c *fiber.Ctx) responseMessage {
+	element, response := getPendingReservation(c)
+
+	if response.Status == 0 {
+		// restart the expiration, so the sponsor has the full time after the approval
+		if err := store.Update("elements", struct {
+			Pending     bool
+			Reservation string
+		}{Reservation: time.Now().Format(time.DateTime)}, struct{ Mid string }{Mid: element.Mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't approve reservation for %q: %v", element.Mid, err)
+		} else {
+			cachedElements.Delete("status")
+
+			// only the next reservation after the expiries needs an approval
+			if err := resetExpiries(element.Mid); err != nil {
+				logger.Error().Msgf("can't reset expiries of %q: %v", element.Mid, err)
+			}
+
+			response = getReservations(c)
+
+			logger.Info().Msgf("approved reservation for %q", element.Mid)
+		}
+	}
+
+	return response
+}
+
+// handles post-requests for rejecting a reservation, the sponsor is informed via e-mail
+func postReservationsReject(c *fiber.Ctx) responseMessage {
+	element, response := getPendingReservation(c)
+
+	body := RejectionBody{}
+
+	if response.Status != 0 {
+		return response
+	} else if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ reason string }"`)
+	} else {
+		templateData := RejectionTemplateData{Reason: body.Reason}
+		templateData.SponsorshipTemplateData.Populate(element.Mid, element.Name, elementShares(element.Mid))
+
+		if element.Mail != nil {
+			if err := mailer.SendTemplate(*element.Mail, "rejection_mail", templateData); err != nil {
+				logger.Error().Msgf("can't send rejection-mail for %q: %v", element.Mid, err)
+			}
+		}
+
+		if err := store.Delete("elements", struct{ Mid string }{Mid: element.Mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't remove rejected reservation for %q from database: %v", element.Mid, err)
+		} else {
+			cachedElements.Delete("status")
+
+			response = getReservations(c)
+
+			logger.Info().Msgf("rejected reservation for %q", element.Mid)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// copies a confirmed sponsorship into the archive. The archive is only ever inserted into,
+// later edits of the element don't change it. It is written within the transaction of the confirmation
+func archiveSponsorship(tx *store.Tx, element ElementDB, user UserDB, serial int, confirmed string) error {
+	fields := element.Fields
+	if len(fields) == 0 {
+		fields = json.RawMessage("{}")
+	}
+
+	if mail, err := store.Seal(element.Mail); err != nil {
+		return err
+	} else if giftmail, err := store.Seal(element.Giftmail); err != nil {
+		return err
+	} else {
+		_, err := tx.Exec(
+			"INSERT INTO sponsorships_archive (mid, name, mail, source, reservation, confirmed, uid, username, amount, fields, buyer, giftmail, serial) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			element.Mid, element.Name, mail, element.Source, element.Reservation, confirmed, user.Uid, user.Name, elementPrice(element.Mid), string(fields), element.Buyer, giftmail, serial,
+		)
+
+		return err
+	}
+}
+
+// handles get-requests for the archived sponsorships, optionally of a single element ("mid" in the query)
+func getSponsorshipsArchive(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	var archive []ArchivedSponsorship
+	var err error
+
+	if mid := c.Query("mid"); mid != "" {
+		archive, err = store.Select[ArchivedSponsorship]("sponsorships_archive", "mid = ? ORDER BY aid", mid)
+	} else {
+		archive, err = store.Select[ArchivedSponsorship]("sponsorships_archive", "aid > 0 ORDER BY aid")
+	}
+
+	if err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get archived sponsorships from database: %v", err)
+	} else {
+		response.Data = archive
+	}
+
+	return response
+}
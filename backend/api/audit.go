@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// actions recorded in the audit-log
+const (
+	auditConfirm = "confirm"
+	auditDelete  = "delete"
+)
+
+// entry of the audit-log in the database
+type AuditDB struct {
+	Uid int
+	// verified mail-address of the user at the time of the action, empty if there was none
+	Mail    string
+	Action  string
+	Mid     string
+	Created string
+}
+
+// records an action of the logged-in user in the audit-log. The action already happened,
+// so a failure is only logged
+func recordAudit(c *fiber.Ctx, action, mid string) {
+	user := getUser(c)
+
+	if err := store.Insert("audit", struct {
+		Uid    int
+		Mail   string
+		Action string
+		Mid    string
+	}{Uid: user.Uid, Mail: user.verifiedMail(), Action: action, Mid: mid}); err != nil {
+		logger.Error().Msgf("can't record %q of %q in the audit-log: %v", action, mid, err)
+	}
+}
+
+// aggregates the audit-log per user
+func userStatistics() (map[int]UserStatistics, error) {
+	if entries, err := store.Select[AuditDB]("audit", "*"); err != nil {
+		return nil, err
+	} else {
+		statistics := map[int]UserStatistics{}
+
+		for _, entry := range entries {
+			stats := statistics[entry.Uid]
+
+			switch entry.Action {
+			case auditConfirm:
+				stats.Confirmations++
+			case auditDelete:
+				stats.Deletions++
+			}
+
+			if stats.Lastchange == nil || entry.Created > *stats.Lastchange {
+				stats.Lastchange = &entry.Created
+			}
+
+			statistics[entry.Uid] = stats
+		}
+
+		return statistics, nil
+	}
+}
@@ -0,0 +1,337 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// payload of the JSON webtoken
+type JWTPayload struct {
+	Uid int `json:"uid"`
+	Tid int `json:"tid"`
+	// unix-time of the last activity, for the idle-timeout
+	Active int64 `json:"active"`
+}
+
+// complete JSON webtoken
+type JWT struct {
+	backendConfig.Payload
+	CustomClaims JWTPayload
+}
+
+// extracts the json webtoken from the request
+func extractJWT(c *fiber.Ctx) (JWTPayload, error) {
+	// get the session-cookie
+	cookie := c.Cookies("session")
+
+	token, err := jwt.ParseWithClaims(cookie, &JWT{}, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected JWT signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(config.ClientSession.JwtSignature), nil
+	})
+
+	if err != nil {
+		return JWTPayload{}, err
+	}
+
+	// extract the claims from the JWT
+	if claims, ok := token.Claims.(*JWT); ok && token.Valid {
+		return claims.CustomClaims, nil
+	} else {
+		return JWTPayload{}, fmt.Errorf("invalid JWT")
+	}
+}
+
+// wether the session was inactive for longer than the idle-timeout
+func sessionIdle(payload JWTPayload) bool {
+	return config.SessionIdleTimeout > 0 && time.Since(time.Unix(payload.Active, 0)) > config.SessionIdleTimeout
+}
+
+// renews the session-cookie with the current time as last activity
+func renewSession(c *fiber.Ctx, user UserDB) error {
+	if jwt, err := config.SignJWT(JWTPayload{
+		Uid:    user.Uid,
+		Tid:    user.Tid,
+		Active: time.Now().Unix(),
+	}); err != nil {
+		return err
+	} else {
+		setSessionCookie(c, &jwt)
+
+		return nil
+	}
+}
+
+func setSessionCookie(c *fiber.Ctx, jwt *string) {
+	var value string
+
+	if jwt == nil {
+		value = c.Cookies("session")
+	} else {
+		value = *jwt
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     "session",
+		Value:    value,
+		HTTPOnly: true,
+		SameSite: "strict",
+		MaxAge:   int(config.SessionExpire.Seconds()),
+	})
+}
+
+// user-roles
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+// key under which the authenticated user is stored in the request-locals
+const localsUser = "user"
+
+// checks wether the user has a role
+func (user UserDB) hasRole(role string) bool {
+	switch role {
+	case roleUser:
+		return true
+	case roleAdmin:
+		return user.Admin
+	default:
+		return false
+	}
+}
+
+// retrieves the user the request is from, returns nil if the request isn't authorized
+func authenticateUser(c *fiber.Ctx) (*UserDB, error) {
+	payload, err := extractJWT(c)
+
+	if err != nil {
+		return nil, nil
+	} else if sessionIdle(payload) {
+		logger.Info().Msgf("session of user with uid = %q expired after inactivity", payload.Uid)
+
+		return nil, nil
+	}
+
+	// retrieve the user from the database
+	response, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", payload.Uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// if exactly one user came back, the tID is valid and the account is active, the user is authorized
+	if len(response) == 1 && response[0].Tid == payload.Tid && !response[0].Deactivated {
+		return &response[0], nil
+	} else {
+		return nil, nil
+	}
+}
+
+// middleware allowing only requests from users with the given role
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		response := responseMessage{}
+
+		if user, err := authenticateUser(c); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't check user: %v", err)
+		} else if user == nil || !user.hasRole(role) {
+			response.Status = fiber.StatusUnauthorized
+
+			logger.Info().Msgf("request is not authorized as %s", role)
+		} else {
+			// reset the expiration of the cookie and the idle-timeout
+			if err := renewSession(c, *user); err != nil {
+				logger.Error().Msgf("can't renew session of user with uid = %q: %v", user.Uid, err)
+
+				setSessionCookie(c, nil)
+			}
+
+			c.Locals(localsUser, *user)
+
+			touchUser(user.Uid)
+
+			return c.Next()
+		}
+
+		return response.send(c)
+	}
+}
+
+// middleware allowing only requests from logged-in users
+var RequireUser = RequireRole(roleUser)
+
+// middleware allowing only requests from the admin
+var RequireAdmin = RequireRole(roleAdmin)
+
+// retrieves the user stored by the authorization-middleware
+func getUser(c *fiber.Ctx) UserDB {
+	return c.Locals(localsUser).(UserDB)
+}
+
+// handle welcome-messages from clients
+func handleWelcome(c *fiber.Ctx) error {
+	logger.Debug().Msgf("HTTP %s request: %q", c.Method(), c.OriginalURL())
+
+	response := responseMessage{}
+	response.Data = UserLogin{
+		LoggedIn: false,
+	}
+
+	if user, err := authenticateUser(c); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Warn().Msgf("can't check user: %v", err)
+	} else if user == nil {
+		response.Status = fiber.StatusNoContent
+	} else {
+		response.Data = UserLogin{
+			Uid:      user.Uid,
+			Name:     user.Name,
+			LoggedIn: true,
+		}
+
+		logger.Debug().Msgf("welcomed user with uid = %v", user.Uid)
+	}
+
+	return response.send(c)
+}
+
+// retrieves the current tid for a specific user from the database
+func getTokenId(uid int) (int, error) {
+	if response, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", uid); err != nil {
+		return -1, err
+	} else if len(response) != 1 {
+		return -1, fmt.Errorf("can't get user with uid = %q from database", uid)
+	} else {
+		return response[0].Tid, nil
+	}
+}
+
+// increases the tid of a user
+func incTokenId(uid int) error {
+	_, err := store.Exec("UPDATE users SET tid = tid + 1 WHERE uid = ?", uid)
+
+	return err
+}
+
+var messageWrongLogin = "Unkown user or wrong password"
+
+// handles login-requests
+func handleLogin(c *fiber.Ctx) error {
+	logger.Debug().Msgf("HTTP %s request: %q", c.Method(), c.OriginalURL())
+
+	var response responseMessage
+
+	body := LoginBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "can't parse message-body"
+
+		logger.Warn().Msgf("can't parse login-body: %v", err)
+	} else {
+		// try to get the hashed password from the database
+		dbResult, err := store.Select[UserDB]("users", "name = ? LIMIT 1", body.User)
+
+		if err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't get users from the database: %v", err)
+		} else if len(dbResult) != 1 {
+			response.Status = fiber.StatusForbidden
+			response.Message = messageWrongLogin
+
+			logger.Info().Msgf("user with name = %q doesn't exist", body.User)
+		} else {
+			response.Data = UserLogin{
+				LoggedIn: false,
+			}
+
+			user := dbResult[0]
+
+			if len(dbResult) != 1 || comparePassword(user.Password, body.Password) != nil {
+				response.Status = fiber.StatusUnauthorized
+				response.Message = messageWrongLogin
+
+				logger.Debug().Msgf("can't login: wrong username or password")
+			} else if user.Deactivated {
+				response.Status = fiber.StatusForbidden
+				response.Message = "account is deactivated"
+
+				logger.Info().Msgf("deactivated user with uid = %q tried to login", user.Uid)
+			} else {
+				// get the token-id
+				if tid, err := getTokenId(user.Uid); err != nil {
+					response.Status = fiber.StatusInternalServerError
+
+					logger.Error().Msgf("can't get tid for user with uid = %q", user.Uid)
+				} else {
+					// create the jwt
+					jwt, err := config.SignJWT(JWTPayload{
+						Uid:    user.Uid,
+						Tid:    tid,
+						Active: time.Now().Unix(),
+					})
+
+					if err != nil {
+						response.Status = fiber.StatusInternalServerError
+
+						logger.Error().Msgf("json-webtoken creation failed: %v", err)
+					} else {
+						setSessionCookie(c, &jwt)
+
+						response.Data = UserLogin{
+							Uid:      user.Uid,
+							Name:     user.Name,
+							LoggedIn: true,
+						}
+
+						if _, err := store.Exec("UPDATE users SET lastlogin = ? WHERE uid = ?", time.Now().Format(time.DateTime), user.Uid); err != nil {
+							logger.Error().Msgf("can't store login-time of user with uid = %q: %v", user.Uid, err)
+						}
+
+						migratePasswordHash(user, body.Password)
+
+						logger.Info().Msgf("user with uid = %q logged in", user.Uid)
+					}
+				}
+			}
+		}
+	}
+
+	return response.send(c)
+}
+
+// removes the session-coockie from a request
+func removeSessionCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "session",
+		Value:    "",
+		HTTPOnly: true,
+		SameSite: "strict",
+		Expires:  time.Unix(0, 0),
+	})
+}
+
+// handles logout-requests
+func handleLogout(c *fiber.Ctx) error {
+	logger.Debug().Msgf("HTTP %s request: %q", c.Method(), c.OriginalURL())
+
+	removeSessionCookie(c)
+
+	return responseMessage{
+		Data: UserLogin{
+			LoggedIn: false,
+		},
+	}.send(c)
+}
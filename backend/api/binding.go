@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// identifiers of the element and the user a request refers to
+type RequestIDs struct {
+	Mid string
+	// negative, if the request doesn't include a uid
+	Uid int
+}
+
+// identifiers in the body of a request. Some reverse-proxies strip or re-encode the
+// query of patch-requests, so they are accepted in the body as well
+type requestIDsBody struct {
+	Mid string `json:"mid"`
+	Uid *int   `json:"uid"`
+}
+
+// key of the identifiers in the locals of the request
+const requestIDsLocal = "requestIDs"
+
+// reads the identifiers of the request from the query and the JSON-body.
+// Identifiers present in both have to match
+func requestIDs(c *fiber.Ctx) (RequestIDs, error) {
+	if ids, ok := c.Locals(requestIDsLocal).(RequestIDs); ok {
+		return ids, nil
+	}
+
+	body := requestIDsBody{}
+
+	if len(c.Body()) > 0 && strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return RequestIDs{}, messageErrorf("invalid message-body")
+		}
+	}
+
+	ids := RequestIDs{Mid: body.Mid, Uid: -1}
+
+	if body.Uid != nil {
+		ids.Uid = *body.Uid
+	}
+
+	if mid := c.Query("mid"); mid != "" {
+		if body.Mid != "" && body.Mid != mid {
+			return RequestIDs{}, messageErrorf("%s in query and body differ", "mid")
+		}
+
+		ids.Mid = mid
+	}
+
+	if c.Query("uid") != "" {
+		uid := c.QueryInt("uid", -1)
+
+		if body.Uid != nil && *body.Uid != uid {
+			return RequestIDs{}, messageErrorf("%s in query and body differ", "uid")
+		}
+
+		ids.Uid = uid
+	}
+
+	c.Locals(requestIDsLocal, ids)
+
+	return ids, nil
+}
+
+// binds the identifiers and the body of a request. The identifier named by require ("mid", "uid"
+// or empty for none) has to be present and the body is skipped, if it is nil.
+// The response is only set, if the request is invalid
+func bindRequest(c *fiber.Ctx, require string, body any) (RequestIDs, responseMessage) {
+	var response responseMessage
+
+	ids, err := requestIDs(c)
+
+	if err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message, response.Args = errorMessage(err)
+
+		logger.Info().Msgf("can't bind request: %v", err)
+	} else if require == "mid" && ids.Mid == "" || require == "uid" && ids.Uid < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid " + require
+
+		logger.Info().Msgf("query doesn't include valid %s", require)
+	} else if body != nil {
+		if err := c.BodyParser(body); err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "invalid message-body"
+
+			logger.Warn().Msgf("body can't be parsed as %T: %v", body, err)
+		}
+	}
+
+	return ids, response
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// handles get-requests for the branding of the deployment
+func getBranding(c *fiber.Ctx) responseMessage {
+	branding := config.Branding
+
+	// send empty collections instead of null
+	if branding.Colors == nil {
+		branding.Colors = map[string]string{}
+	}
+	if branding.Links == nil {
+		branding.Links = []backendConfig.Link{}
+	}
+
+	return responseMessage{
+		Data: branding,
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+	"github.com/patrickmn/go-cache"
+)
+
+// part of the cache holding the values of one dataset. The keys are prefixed with the
+// name of the namespace, so the datasets can't collide
+type cacheNamespace[T any] struct {
+	name string
+	// expiration if none is configured, zero for "cache.expiration"
+	fallback time.Duration
+
+	hits    atomic.Int64
+	misses  atomic.Int64
+	sets    atomic.Int64
+	deletes atomic.Int64
+}
+
+// namespaces of the cache, for reporting their metrics
+var namespaces []interface{ metrics() CacheMetrics }
+
+// creates a namespace of the cache, the name has to be listed in config.CacheNamespaces
+func newCacheNamespace[T any](name string, fallback time.Duration) *cacheNamespace[T] {
+	ns := &cacheNamespace[T]{name: name, fallback: fallback}
+
+	namespaces = append(namespaces, ns)
+
+	return ns
+}
+
+var (
+	cachedElements = newCacheNamespace[ElementsCache]("elements", 0)
+	cachedAPIKeys  = newCacheNamespace[map[string]APIKeyDB]("apikeys", 0)
+	// the last yield is kept, even if the monitoring is unreachable for a while
+	cachedYield    = newCacheNamespace[Yield]("yield", cache.NoExpiration)
+	cachedActivity = newCacheNamespace[struct{}]("activity", activityResolution)
+	cachedMX       = newCacheNamespace[bool]("mx", time.Hour)
+)
+
+// returns the expiration of the values of the namespace
+func (ns *cacheNamespace[T]) Expiration() time.Duration {
+	if expiration, ok := config.Cache.Namespaces[ns.name]; ok {
+		return expiration
+	} else if ns.fallback != 0 {
+		return ns.fallback
+	} else {
+		return config.Cache.Expiration
+	}
+}
+
+func (ns *cacheNamespace[T]) key(key string) string {
+	return ns.name + "/" + key
+}
+
+func (ns *cacheNamespace[T]) Get(key string) (T, bool) {
+	// a poisoned cache returns empty values as hits
+	if faults.CachePoisoned() {
+		ns.hits.Add(1)
+
+		var zero T
+
+		return zero, true
+	}
+
+	if value, found := dbCache.Get(ns.key(key)); found {
+		ns.hits.Add(1)
+
+		return value.(T), true
+	} else {
+		ns.misses.Add(1)
+
+		var zero T
+
+		return zero, false
+	}
+}
+
+// stores a value with the expiration of the namespace
+func (ns *cacheNamespace[T]) Set(key string, value T) {
+	ns.SetExpiring(key, value, ns.Expiration())
+}
+
+// stores a value with an individual expiration
+func (ns *cacheNamespace[T]) SetExpiring(key string, value T, expiration time.Duration) {
+	ns.sets.Add(1)
+
+	dbCache.Set(ns.key(key), value, expiration)
+}
+
+func (ns *cacheNamespace[T]) Delete(key string) {
+	ns.deletes.Add(1)
+
+	dbCache.Delete(ns.key(key))
+}
+
+// counts the values currently stored in the namespace
+func (ns *cacheNamespace[T]) count() int {
+	count := 0
+
+	for key := range dbCache.Items() {
+		if strings.HasPrefix(key, ns.name+"/") {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (ns *cacheNamespace[T]) metrics() CacheMetrics {
+	return CacheMetrics{
+		Namespace:  ns.name,
+		Expiration: ns.Expiration().String(),
+		Items:      ns.count(),
+		Hits:       ns.hits.Load(),
+		Misses:     ns.misses.Load(),
+		Sets:       ns.sets.Load(),
+		Deletes:    ns.deletes.Load(),
+	}
+}
+
+// handles get-requests for the metrics of the cache-namespaces
+func getAdminCache(c *fiber.Ctx) responseMessage {
+	metrics := make([]CacheMetrics, len(namespaces))
+
+	for ii, ns := range namespaces {
+		metrics[ii] = ns.metrics()
+	}
+
+	return responseMessage{
+		Data: metrics,
+	}
+}
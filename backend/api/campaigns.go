@@ -0,0 +1,330 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// states of the campaign-mails
+const (
+	campaignQueued = "queued"
+	campaignSent   = "sent"
+	campaignFailed = "failed"
+)
+
+// campaign in the database
+type CampaignDB struct {
+	Cid     int
+	Subject string
+	Body    string
+	Created string
+}
+
+// queued campaign-mail in the database
+type CampaignMailDB struct {
+	Cid      int
+	Mail     string
+	Name     string
+	Elements string
+}
+
+// data available in the campaign-templates
+type CampaignTemplateData struct {
+	Name string
+	// elements of the sponsor, e.g. "PV-Modul A1"
+	Elements []string
+}
+
+// renders subject and body of a campaign for a recipient
+func renderCampaignMail(campaign CampaignDB, name, elements string) (string, string, error) {
+	data := CampaignTemplateData{
+		Name: name,
+	}
+
+	for _, mid := range strings.Split(elements, ",") {
+		data.Elements = append(data.Elements, certs.ElementName(mid, elementShares(mid)))
+	}
+
+	if subject, err := lib.ExecuteTemplate(campaign.Subject, data); err != nil {
+		return "", "", err
+	} else if body, err := lib.ExecuteTemplate(campaign.Body, data); err != nil {
+		return "", "", err
+	} else {
+		return subject, body, nil
+	}
+}
+
+// retrieves the sponsors matching the filter, grouped by their mail-address
+func getCampaignRecipients(filter CampaignFilter) ([]CampaignMailDB, error) {
+	where := []string{"reservation IS NULL", "mail IS NOT NULL"}
+	args := []any{}
+
+	if filter.Prefix != "" {
+		where = append(where, "mid LIKE ?")
+		args = append(args, filter.Prefix+"%")
+	}
+
+	if filter.ConfirmedAfter != "" {
+		where = append(where, "confirmed >= ?")
+		args = append(args, filter.ConfirmedAfter)
+	}
+
+	if filter.ConfirmedBefore != "" {
+		where = append(where, "confirmed < ?")
+		args = append(args, filter.ConfirmedBefore)
+	}
+
+	if !filter.IncludeOptOut {
+		where = append(where, "optout = FALSE")
+	}
+
+	if elements, err := store.Select[ElementDBNoReservation]("elements", strings.Join(where, " AND ")+" ORDER BY mid", args...); err != nil {
+		return nil, err
+	} else {
+		recipients := []CampaignMailDB{}
+		index := map[string]int{}
+
+		for _, element := range elements {
+			mail := strings.ToLower(*element.Mail)
+
+			if ii, ok := index[mail]; ok {
+				recipients[ii].Elements += "," + element.Mid
+			} else {
+				index[mail] = len(recipients)
+
+				recipients = append(recipients, CampaignMailDB{
+					Mail:     mail,
+					Name:     element.Name,
+					Elements: element.Mid,
+				})
+			}
+		}
+
+		return recipients, nil
+	}
+}
+
+// creates the delivery-report of a campaign
+func getCampaignReport(campaign CampaignDB) (CampaignReport, error) {
+	report := CampaignReport{
+		Campaign: Campaign{
+			Cid:     campaign.Cid,
+			Subject: campaign.Subject,
+			Created: campaign.Created,
+		},
+	}
+
+	if recipients, err := store.Select[CampaignRecipient]("campaignmails", "cid = ? ORDER BY mail", campaign.Cid); err != nil {
+		return report, err
+	} else {
+		report.Recipients = recipients
+
+		for _, recipient := range recipients {
+			switch recipient.Status {
+			case campaignQueued:
+				report.Queued++
+			case campaignSent:
+				report.Sent++
+			case campaignFailed:
+				report.Failed++
+			}
+		}
+
+		return report, nil
+	}
+}
+
+// handles get-requests for listing the campaigns
+func getCampaigns(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if campaigns, err := selectForRead[CampaignDB](c, "campaigns", "cid > 0 ORDER BY cid DESC"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get campaigns from database: %v", err)
+	} else {
+		summaries := []Campaign{}
+
+		for _, campaign := range campaigns {
+			if report, err := getCampaignReport(campaign); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't get report of campaign %d: %v", campaign.Cid, err)
+
+				return response
+			} else {
+				summaries = append(summaries, report.Campaign)
+			}
+		}
+
+		response.Data = summaries
+	}
+
+	return response
+}
+
+// handles get-requests for the delivery-report of a campaign
+func getCampaignsReport(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if cid := c.QueryInt("cid", -1); cid < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid cid"
+
+		logger.Info().Msg("query doesn't include valid cid")
+	} else if campaigns, err := store.Select[CampaignDB]("campaigns", "cid = ?", cid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get campaign %d from database: %v", cid, err)
+	} else if len(campaigns) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "campaign doesn't exist"
+
+		logger.Info().Msgf("campaign %d doesn't exist", cid)
+	} else if report, err := getCampaignReport(campaigns[0]); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get report of campaign %d: %v", cid, err)
+	} else {
+		response.Data = report
+	}
+
+	return response
+}
+
+// handles post-requests for creating a campaign. The mails are queued
+// and sent by the scheduler
+func postCampaigns(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := CampaignBody{}
+	created := time.Now().Format(time.DateTime)
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ subject string; body string; filter struct }"`)
+	} else if body.Subject == "" || body.Body == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "subject and body are required"
+
+		logger.Info().Msg("can't create campaign: subject or body is missing")
+	} else if _, _, err := renderCampaignMail(CampaignDB{Subject: body.Subject, Body: body.Body}, "", "pv-a1"); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid template: %v"
+		response.Args = []any{err}
+
+		logger.Info().Msgf("can't create campaign: invalid template: %v", err)
+	} else if recipients, err := getCampaignRecipients(body.Filter); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get recipients of campaign: %v", err)
+	} else if res, err := store.Exec("INSERT INTO campaigns (subject, body, created) VALUES (?, ?, ?)", body.Subject, body.Body, created); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't write campaign to database: %v", err)
+	} else if cid, err := res.LastInsertId(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get id of campaign: %v", err)
+	} else {
+		// queue the mails
+		for _, recipient := range recipients {
+			recipient.Cid = int(cid)
+
+			if err := store.Insert("campaignmails", recipient); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't queue campaign-mail for %q: %v", recipient.Mail, err)
+
+				return response
+			}
+		}
+
+		logger.Info().Msgf("queued campaign %d for %d recipients", cid, len(recipients))
+
+		if report, err := getCampaignReport(CampaignDB{Cid: int(cid), Subject: body.Subject, Body: body.Body, Created: created}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't get report of campaign %d: %v", cid, err)
+		} else {
+			response.Data = report
+		}
+	}
+
+	return response
+}
+
+// number of campaign-mails sent per run of the job, the job runs once a minute
+func campaignMailsPerRun() int {
+	if config.ConfigYaml.Campaigns.MailsPerMinute > 0 {
+		return config.ConfigYaml.Campaigns.MailsPerMinute
+	} else {
+		return 30
+	}
+}
+
+// sends the next queued campaign-mails
+func sendQueuedCampaignMails() error {
+	if queued, err := store.Select[CampaignMailDB]("campaignmails", "status = ? ORDER BY cid, mail LIMIT ?", campaignQueued, campaignMailsPerRun()); err != nil {
+		return err
+	} else {
+		campaigns := map[int]CampaignDB{}
+
+		for _, mail := range queued {
+			campaign, ok := campaigns[mail.Cid]
+
+			if !ok {
+				if res, err := store.Select[CampaignDB]("campaigns", "cid = ?", mail.Cid); err != nil {
+					return err
+				} else if len(res) != 1 {
+					return fmt.Errorf("campaign %d doesn't exist", mail.Cid)
+				} else {
+					campaign = res[0]
+					campaigns[mail.Cid] = campaign
+				}
+			}
+
+			status := struct {
+				Status string
+				Error  *string
+				Sent   *string
+			}{Status: campaignSent}
+
+			if subject, body, err := renderCampaignMail(campaign, mail.Name, mail.Elements); err != nil {
+				status.Status = campaignFailed
+				status.Error = lib.Ptr(err.Error())
+			} else if err := mailer.Send(mail.Mail, subject, body); errors.Is(err, mailer.ErrThrottled) {
+				// the mail stays queued for a later run
+				return nil
+			} else if err != nil {
+				status.Status = campaignFailed
+				status.Error = lib.Ptr(err.Error())
+			} else {
+				status.Sent = lib.Ptr(time.Now().Format(time.DateTime))
+			}
+
+			if status.Status == campaignFailed {
+				logger.Warn().Msgf("can't send campaign-mail of campaign %d to %q: %v", mail.Cid, mail.Mail, *status.Error)
+			}
+
+			if err := store.Update("campaignmails", status, struct {
+				Cid  int
+				Mail string
+			}{Cid: mail.Cid, Mail: mail.Mail}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
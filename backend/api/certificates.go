@@ -0,0 +1,258 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// handles get-requests for downloading the certificate of an element
+func getCertificates(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if err := checkCapability(c, capabilityIssueCertificates); err != nil {
+		response.Status = fiber.StatusForbidden
+		response.Message = "missing permission to issue certificates"
+
+		logger.Info().Msgf("can't download certificate: %v", err)
+	} else if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include mid"
+
+		logger.Info().Msg("query doesn't include mid")
+	} else if release, ok := acquireCertificateSlot(); !ok {
+		response.Status = fiber.StatusTooManyRequests
+		response.Message = "too many requests, try again later"
+	} else {
+		defer release()
+
+		// get the element from the database
+		if res, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't get element %q from database: %v", mid, err)
+		} else if len(res) != 1 {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "query doesn't include valid mid"
+
+			logger.Info().Msgf("query doesn't include valid mid: %q", mid)
+		} else {
+			// create the pdf
+			certData := certs.CertificateData{
+				Reservation: certs.ReservationData{
+					Mid:   mid,
+					Name:  res[0].Name,
+					Texts: elementTexts(res[0]),
+				},
+				Templates: certificateTemplates(mid),
+				Shares:    elementShares(mid),
+			}
+
+			if err := issueCertificate(&certData); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't issue certificate for %q: %v", mid, err)
+			} else if err := certData.Create(); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't create certificate for %q; %v", mid, err)
+			} else {
+				defer certData.Cleanup()
+
+				c.Attachment(certData.PDFFile)
+				c.SendFile(certData.PDFFile)
+			}
+		}
+	}
+
+	return response
+}
+
+// handles get-requests rendering a certificate with arbitrary data for checking the layout.
+// Nothing is written to the database, the pdf is returned inline
+func getCertificatesPreview(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mid := c.Query("mid")
+
+	if ok, err := isValidMid(mid); err != nil || !ok {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msgf("can't preview certificate: invalid element-name: %q", mid)
+	} else if release, ok := acquireCertificateSlot(); !ok {
+		response.Status = fiber.StatusTooManyRequests
+		response.Message = "too many requests, try again later"
+	} else {
+		defer release()
+
+		certData := certs.CertificateData{
+			Reservation: certs.ReservationData{
+				Mid:   mid,
+				Name:  c.Query("name"),
+				Texts: map[string]string{"dedication": c.Query("dedication")},
+			},
+			// placeholders with the full length of real serials and codes
+			Serial:    999999,
+			Code:      strings.Repeat("X", verificationCodeLength),
+			Templates: certificateTemplates(mid),
+			Shares:    elementShares(mid),
+		}
+
+		if err := certData.Create(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't create certificate-preview for %q: %v", mid, err)
+		} else {
+			defer certData.Cleanup()
+
+			c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", path.Base(certData.PDFFile)))
+			c.SendFile(certData.PDFFile)
+		}
+	}
+
+	return response
+}
+
+// characters of the verification-codes, without the easily confused ones
+const verificationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const verificationCodeLength = 12
+
+// creates a random verification-code
+func newVerificationCode() (string, error) {
+	bytes := make([]byte, verificationCodeLength)
+
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	for ii, b := range bytes {
+		bytes[ii] = verificationCodeAlphabet[int(b)%len(verificationCodeAlphabet)]
+	}
+
+	return string(bytes), nil
+}
+
+// hashes a mail-address, so certificates can be found by it without storing the address
+func hashMail(mail string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(mail))))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// assigns the serial-number and verification-code to the certificate. A certificate
+// already issued for the element and name is reused, so repeated downloads verify the same
+func issueCertificate(certData *certs.CertificateData) error {
+	mid := certData.Reservation.Mid
+	name := certData.Reservation.Name
+
+	// the hash of the recipient is stored for resending the certificate
+	mailHash := ""
+	if certData.Reservation.Mail != "" {
+		mailHash = hashMail(certData.Reservation.Mail)
+	}
+
+	if issued, err := store.Select[CertificateDB]("certificates", "mid = ? AND name = ? ORDER BY serial DESC LIMIT 1", mid, name); err != nil {
+		return err
+	} else if len(issued) == 1 {
+		certData.Serial = issued[0].Serial
+		certData.Code = issued[0].Code
+
+		if mailHash != "" {
+			if err := store.Update("certificates", struct{ Mailhash string }{Mailhash: mailHash}, struct{ Serial int }{Serial: issued[0].Serial}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	} else if code, err := newVerificationCode(); err != nil {
+		return err
+	} else if res, err := store.Exec("INSERT INTO certificates (code, mid, name, issued, mailhash) VALUES (?, ?, ?, ?, ?)", code, mid, name, time.Now().Format(time.DateTime), mailHash); err != nil {
+		return err
+	} else if serial, err := res.LastInsertId(); err != nil {
+		return err
+	} else {
+		certData.Serial = int(serial)
+		certData.Code = code
+
+		return nil
+	}
+}
+
+// removes the certificates of an element, including the ones issued before a renumbering,
+// so they don't verify anymore
+func revokeCertificates(mid string) error {
+	if history, err := midHistory(mid); err != nil {
+		return err
+	} else {
+		for _, oldMid := range history {
+			if err := store.Delete("certificates", struct{ Mid string }{Mid: oldMid}); err != nil {
+				return err
+			}
+		}
+
+		// cached verifications of the revoked certificates mustn't succeed anymore
+		dropCachedResponses("/api/certificates/verify")
+
+		return nil
+	}
+}
+
+// creates the initials of a name, e.g. "M. M." for "Max Mustermann"
+func sponsorInitials(name string) string {
+	initials := []string{}
+
+	for _, part := range strings.Fields(name) {
+		initials = append(initials, string([]rune(part)[:1])+".")
+	}
+
+	return strings.Join(initials, " ")
+}
+
+// handles get-requests for verifying a certificate by its verification-code
+func getCertificatesVerify(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	// accept the code as printed, with the separators and in any case
+	code := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(c.Query("code")), "-", ""))
+
+	if len(code) != verificationCodeLength {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid code"
+
+		logger.Info().Msg("query doesn't include valid code")
+	} else if res, err := store.Select[CertificateDB]("certificates", "code = ?", code); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get certificate from database: %v", err)
+	} else if len(res) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "unknown certificate"
+
+		logger.Info().Msgf("verification of unknown certificate %q", code)
+	} else if current, err := resolveMid(res[0].Mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't resolve mid %q: %v", res[0].Mid, err)
+	} else {
+		response.Data = CertificateVerification{
+			Serial:     certs.FormatSerial(res[0].Serial),
+			Mid:        res[0].Mid,
+			Element:    certs.ElementName(res[0].Mid, elementShares(res[0].Mid)),
+			Issued:     res[0].Issued,
+			Initials:   sponsorInitials(res[0].Name),
+			CurrentMid: current,
+		}
+	}
+
+	return response
+}
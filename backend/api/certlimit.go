@@ -0,0 +1,108 @@
+package api
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// renderers available for the certificates on request, created with the server
+var certificateSlots chan struct{}
+
+// usage of the renderers since the start
+var certificateLimit struct {
+	queued    atomic.Int64
+	generated atomic.Int64
+	rejected  atomic.Int64
+
+	sync.Mutex
+	maxQueued     int64
+	maxWait       time.Duration
+	lastRejection *string
+}
+
+// number of certificates rendered at the same time on request
+func certificateConcurrency() int {
+	if config.ConfigYaml.Certificates.MaxConcurrent > 0 {
+		return config.ConfigYaml.Certificates.MaxConcurrent
+	} else {
+		return 2
+	}
+}
+
+// number of requests waiting for a free renderer
+func certificateQueueDepth() int64 {
+	if config.ConfigYaml.Certificates.QueueDepth > 0 {
+		return int64(config.ConfigYaml.Certificates.QueueDepth)
+	} else {
+		return 10
+	}
+}
+
+// waits for a free renderer, false if the queue is full. The returned function releases it
+func acquireCertificateSlot() (func(), bool) {
+	release := func() {
+		<-certificateSlots
+
+		certificateLimit.generated.Add(1)
+	}
+
+	select {
+	case certificateSlots <- struct{}{}:
+		return release, true
+	default:
+	}
+
+	queued := certificateLimit.queued.Add(1)
+	if queued > certificateQueueDepth() {
+		certificateLimit.queued.Add(-1)
+		certificateLimit.rejected.Add(1)
+
+		now := time.Now().Format(time.DateTime)
+
+		certificateLimit.Lock()
+		certificateLimit.lastRejection = &now
+		certificateLimit.Unlock()
+
+		logger.Warn().Msgf("rejected certificate-request: %d requests are already waiting for a renderer", queued-1)
+
+		return nil, false
+	}
+
+	logger.Info().Msgf("certificate-request waits for a renderer, %d requests queued", queued)
+
+	start := time.Now()
+
+	certificateSlots <- struct{}{}
+
+	certificateLimit.queued.Add(-1)
+
+	certificateLimit.Lock()
+	certificateLimit.maxQueued = max(certificateLimit.maxQueued, queued)
+	certificateLimit.maxWait = max(certificateLimit.maxWait, time.Since(start))
+	certificateLimit.Unlock()
+
+	return release, true
+}
+
+// handles get-requests for the usage of the renderers of the certificates
+func getAdminCertificates(c *fiber.Ctx) responseMessage {
+	certificateLimit.Lock()
+	defer certificateLimit.Unlock()
+
+	return responseMessage{
+		Data: CertificateMetrics{
+			Concurrency:   certificateConcurrency(),
+			QueueDepth:    certificateQueueDepth(),
+			Active:        len(certificateSlots),
+			Queued:        certificateLimit.queued.Load(),
+			Generated:     certificateLimit.generated.Load(),
+			Rejected:      certificateLimit.rejected.Load(),
+			MaxQueued:     certificateLimit.maxQueued,
+			MaxWait:       certificateLimit.maxWait.Seconds(),
+			LastRejection: certificateLimit.lastRejection,
+		},
+	}
+}
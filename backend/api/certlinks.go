@@ -0,0 +1,180 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// creates the token authorizing the download of a certificate, as
+// "<expiry>-<serial>-<signature>"
+func certificateToken(mid string, serial int, expires time.Time) string {
+	prefix := strconv.FormatInt(expires.Unix(), 10) + "-" + strconv.Itoa(serial)
+
+	mac := hmac.New(sha256.New, []byte(config.ClientSession.JwtSignature))
+	mac.Write([]byte("certificate:" + mid + ":" + prefix))
+
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// checks the download-token of a certificate, returns the serial of the certificate
+func parseCertificateToken(mid, token string) (int, error) {
+	if parts := strings.SplitN(token, "-", 3); len(parts) != 3 {
+		return 0, fmt.Errorf("malformed token")
+	} else if unix, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, fmt.Errorf("malformed token")
+	} else if serial, err := strconv.Atoi(parts[1]); err != nil {
+		return 0, fmt.Errorf("malformed token")
+	} else if expires := time.Unix(unix, 0); !hmac.Equal([]byte(token), []byte(certificateToken(mid, serial, expires))) {
+		return 0, fmt.Errorf("invalid token")
+	} else if time.Now().After(expires) {
+		return 0, fmt.Errorf("token expired at %s", expires.Format(time.DateTime))
+	} else {
+		return serial, nil
+	}
+}
+
+// returns the endpoint of the download-links. Without a configured one it is derived from
+// the request, for mails sent in the background there is no link then
+func certificateDownloadBase(c *fiber.Ctx) string {
+	if config.ConfigYaml.Certificates.DownloadURL != "" {
+		return config.ConfigYaml.Certificates.DownloadURL
+	} else if c != nil {
+		return c.BaseURL() + "/api/certificates/download"
+	} else {
+		return ""
+	}
+}
+
+// creates the signed download-link of a certificate, empty if there is no endpoint
+func certificateDownloadURL(base, mid string, serial int, expires time.Time) string {
+	if base == "" {
+		return ""
+	}
+
+	query := url.Values{
+		"mid":   {mid},
+		"token": {certificateToken(mid, serial, expires)},
+	}
+
+	return base + "?" + query.Encode()
+}
+
+// issues the certificate and adds the signed download-link for the mails to it
+func issueLinkedCertificate(certData *certs.CertificateData, base string) error {
+	if err := issueCertificate(certData); err != nil {
+		return err
+	} else {
+		certData.Download = certificateDownloadURL(base, certData.Reservation.Mid, certData.Serial, time.Now().Add(config.CertificateLinkExpire))
+
+		return nil
+	}
+}
+
+// handles get-requests of admins creating a download-link for the certificate of an element
+func getCertificatesLink(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include mid"
+
+		logger.Info().Msg("query doesn't include mid")
+	} else if res, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get element %q from database: %v", mid, err)
+	} else if len(res) != 1 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msgf("query doesn't include valid mid: %q", mid)
+	} else {
+		certData := certs.CertificateData{
+			Reservation: certs.ReservationData{
+				Mid:  mid,
+				Name: res[0].Name,
+			},
+		}
+
+		expires := time.Now().Add(config.CertificateLinkExpire)
+
+		if err := issueCertificate(&certData); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't issue certificate for %q: %v", mid, err)
+		} else {
+			response.Data = CertificateLink{
+				URL:     certificateDownloadURL(certificateDownloadBase(c), mid, certData.Serial, expires),
+				Expires: expires.Format(time.DateTime),
+			}
+
+			logger.Info().Msgf("created download-link for certificate %d of %q", certData.Serial, mid)
+		}
+	}
+
+	return response
+}
+
+// handles get-requests downloading a certificate with a signed link, without a session
+func getCertificatesDownload(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mid := c.Query("mid")
+
+	if serial, err := parseCertificateToken(mid, c.Query("token")); err != nil {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid download-link"
+
+		logger.Info().Msgf("invalid certificate-download-link for %q: %v", mid, err)
+	} else if res, err := store.Select[CertificateDB]("certificates", "serial = ? AND mid = ?", serial, mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get certificate %d from database: %v", serial, err)
+	} else if len(res) != 1 {
+		// the certificate was revoked since the link was created
+		response.Status = fiber.StatusNotFound
+		response.Message = "certificate doesn't exist anymore"
+
+		logger.Info().Msgf("download of revoked certificate %d of %q", serial, mid)
+	} else if release, ok := acquireCertificateSlot(); !ok {
+		response.Status = fiber.StatusTooManyRequests
+		response.Message = "too many requests, try again later"
+	} else {
+		defer release()
+
+		certData := certs.CertificateData{
+			Reservation: certs.ReservationData{
+				Mid:   mid,
+				Name:  res[0].Name,
+				Texts: reservationTexts(mid),
+			},
+			Serial:    res[0].Serial,
+			Code:      res[0].Code,
+			Templates: certificateTemplates(mid),
+			Shares:    elementShares(mid),
+		}
+
+		if err := certData.Create(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't create certificate %d: %v", serial, err)
+		} else {
+			defer certData.Cleanup()
+
+			c.Attachment(certData.PDFFile)
+			c.SendFile(certData.PDFFile)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// history of the element-changes, used to answer requests for the changes since a cursor
+var elementChanges = struct {
+	sync.Mutex
+	// identifies the process, so cursors of a previous run are recognized as unknown
+	epoch    int64
+	revision int
+	// revision at which each element changed the last time
+	changed  map[string]int
+	taken    map[string]string
+	reserved []string
+}{
+	epoch:   time.Now().Unix(),
+	changed: make(map[string]int),
+	taken:   make(map[string]string),
+}
+
+// records the changes between the last and the current state of the elements
+//
+// @returns the cursor of the current state
+func recordElementChanges(taken map[string]string, reserved []string) string {
+	elementChanges.Lock()
+	defer elementChanges.Unlock()
+
+	var changedMids []string
+
+	// collect all elements that are new or whose state changed
+	for mid, name := range taken {
+		if oldName, ok := elementChanges.taken[mid]; !ok || oldName != name {
+			changedMids = append(changedMids, mid)
+		}
+	}
+
+	for _, mid := range reserved {
+		if !slices.Contains(elementChanges.reserved, mid) {
+			changedMids = append(changedMids, mid)
+		}
+	}
+
+	// collect all elements that aren't reserved or taken anymore
+	for mid := range elementChanges.taken {
+		if _, ok := taken[mid]; !ok && !slices.Contains(reserved, mid) {
+			changedMids = append(changedMids, mid)
+		}
+	}
+
+	for _, mid := range elementChanges.reserved {
+		if _, ok := taken[mid]; !ok && !slices.Contains(reserved, mid) {
+			changedMids = append(changedMids, mid)
+		}
+	}
+
+	if len(changedMids) > 0 {
+		elementChanges.revision++
+
+		for _, mid := range changedMids {
+			elementChanges.changed[mid] = elementChanges.revision
+		}
+
+		logger.Debug().Msgf("elements changed in revision %d: %v", elementChanges.revision, changedMids)
+	}
+
+	elementChanges.taken = taken
+	elementChanges.reserved = reserved
+
+	return formatCursor(elementChanges.epoch, elementChanges.revision)
+}
+
+func formatCursor(epoch int64, revision int) string {
+	return fmt.Sprintf("%d-%d", epoch, revision)
+}
+
+// parses a cursor, returns -1 if it doesn't belong to the current process
+func parseCursor(cursor string) int {
+	if epoch, revision, found := strings.Cut(cursor, "-"); !found {
+		return -1
+	} else if epoch != strconv.FormatInt(elementChanges.epoch, 10) {
+		return -1
+	} else if n, err := strconv.Atoi(revision); err != nil || n < 0 || n > elementChanges.revision {
+		return -1
+	} else {
+		return n
+	}
+}
+
+// retrieves all element-changes since a cursor. If the cursor is unknown, the
+// complete state is returned
+func getElementChanges(cursor string) ElementsDiff {
+	elementChanges.Lock()
+	defer elementChanges.Unlock()
+
+	diff := ElementsDiff{
+		Cursor:   formatCursor(elementChanges.epoch, elementChanges.revision),
+		Taken:    make(map[string]string),
+		Reserved: []string{},
+		Free:     []string{},
+	}
+
+	since := parseCursor(cursor)
+
+	if since < 0 {
+		diff.Complete = true
+
+		for mid, name := range elementChanges.taken {
+			diff.Taken[mid] = name
+		}
+
+		diff.Reserved = append(diff.Reserved, elementChanges.reserved...)
+
+		return diff
+	}
+
+	for mid, revision := range elementChanges.changed {
+		if revision <= since {
+			continue
+		}
+
+		if name, ok := elementChanges.taken[mid]; ok {
+			diff.Taken[mid] = name
+		} else if slices.Contains(elementChanges.reserved, mid) {
+			diff.Reserved = append(diff.Reserved, mid)
+		} else {
+			diff.Free = append(diff.Free, mid)
+		}
+	}
+
+	return diff
+}
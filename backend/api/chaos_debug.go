@@ -0,0 +1,100 @@
+//go:build debug
+
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+)
+
+// registers the endpoints for injecting faults, only compiled into debug-builds
+func registerChaosEndpoints(app *fiber.App) {
+	logger.Warn().Msg(`debug-build: fault-injection is available at "/api/admin/chaos"`)
+
+	handlers := map[string]func(*fiber.Ctx) responseMessage{
+		fiber.MethodGet:    getAdminChaos,
+		fiber.MethodPost:   postAdminChaos,
+		fiber.MethodDelete: deleteAdminChaos,
+	}
+
+	for method, handler := range handlers {
+		app.Add(method, "/api/admin/chaos", RequireAdmin, func(c *fiber.Ctx) error {
+			return handler(c).send(c)
+		})
+	}
+}
+
+// returns the injected faults
+func chaosState() ChaosState {
+	state := faults.Get()
+
+	return ChaosState{
+		DatabaseDown:  state.DatabaseDown,
+		MailDelay:     state.MailDelay,
+		CachePoisoned: state.CachePoisoned,
+		Expires:       state.Expires,
+	}
+}
+
+// handles get-requests for the injected faults
+func getAdminChaos(c *fiber.Ctx) responseMessage {
+	return responseMessage{
+		Data: chaosState(),
+	}
+}
+
+// handles post-requests for injecting faults
+func postAdminChaos(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := ChaosBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msgf("can't parse chaos-body: %v", err)
+	} else if mailDelay, err := parseChaosDuration(body.MailDelay); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid duration %q"
+		response.Args = []any{body.MailDelay}
+
+		logger.Info().Msgf("can't inject faults: invalid mail-delay %q", body.MailDelay)
+	} else if duration, err := parseChaosDuration(body.Duration); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid duration %q"
+		response.Args = []any{body.Duration}
+
+		logger.Info().Msgf("can't inject faults: invalid duration %q", body.Duration)
+	} else {
+		faults.Set(body.DatabaseDown, mailDelay, body.CachePoisoned, duration)
+
+		response.Data = chaosState()
+
+		logger.Warn().Msgf("injected faults: %+v", response.Data)
+	}
+
+	return response
+}
+
+// handles delete-requests for resetting the injected faults
+func deleteAdminChaos(c *fiber.Ctx) responseMessage {
+	faults.Set(false, 0, false, 0)
+
+	logger.Warn().Msg("reset injected faults")
+
+	return responseMessage{
+		Data: chaosState(),
+	}
+}
+
+// parses a duration, empty for zero
+func parseChaosDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	} else {
+		return time.ParseDuration(value)
+	}
+}
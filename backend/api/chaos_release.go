@@ -0,0 +1,8 @@
+//go:build !debug
+
+package api
+
+import "github.com/gofiber/fiber/v2"
+
+// the endpoints for injecting faults are only compiled into debug-builds
+func registerChaosEndpoints(app *fiber.App) {}
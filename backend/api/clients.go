@@ -0,0 +1,71 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum length of the recorded user-agents in characters
+const maxUserAgentLength = 512
+
+// records the client-address and the user-agent of a public reservation for investigating abuse.
+// The reservation already happened, so a failure is only logged
+func recordReservationClient(c *fiber.Ctx, mid string, reserved time.Time) {
+	if config.Reservation.ClientRetention <= 0 {
+		return
+	}
+
+	userAgent := []rune(c.Get(fiber.HeaderUserAgent))
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	if err := store.Insert("reservationclients", struct {
+		Mid         string
+		Reservation string
+		Ip          string
+		Useragent   string
+	}{Mid: mid, Reservation: reserved.Format(time.DateTime), Ip: c.IP(), Useragent: string(userAgent)}); err != nil {
+		logger.Error().Msgf("can't record client of the reservation of %q: %v", mid, err)
+	}
+}
+
+// removes the clients of the reservations older than the retention
+func purgeReservationClients() error {
+	if purged, err := store.DeleteBefore("reservationclients", "reservation", time.Now().Add(-config.Reservation.ClientRetention).Format(time.DateTime)); err != nil {
+		return err
+	} else if purged > 0 {
+		logger.Info().Msgf("removed %d recorded clients of reservations", purged)
+	}
+
+	return nil
+}
+
+// handles get-requests for the recorded clients of the reservations, optionally of a single element ("mid" in the query)
+func getReservationsClients(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	var clients []ReservationClient
+	var err error
+
+	if mid := c.Query("mid"); mid != "" {
+		clients, err = store.Select[ReservationClient]("reservationclients", "mid = ? ORDER BY reservation DESC", mid)
+	} else {
+		clients, err = store.Select[ReservationClient]("reservationclients", "mid != '' ORDER BY reservation DESC")
+	}
+
+	if err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get clients of the reservations from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+
+		response.Data = slices.DeleteFunc(clients, func(client ReservationClient) bool { return !inPlant(client.Mid) })
+	}
+
+	return response
+}
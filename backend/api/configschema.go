@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// handles get-requests for the options of the config-file with their defaults and documentation
+func getAdminConfigSchema(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if schema, err := backendConfig.Schema(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't create schema of the config: %v", err)
+	} else {
+		response.Data = schema
+	}
+
+	return response
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// results of the comparisons of the cached elements with the database
+var consistency struct {
+	checks      atomic.Int64
+	divergences atomic.Int64
+
+	sync.Mutex
+	lastCheck      *string
+	lastDivergence *string
+	mids           []string
+}
+
+// returns the elements whose state differs between the cache and the database
+func divergentElements(cached ElementsCache, elements []ElementDB, expirationDate string) []string {
+	state := func(taken map[string]string, reserved, pending []string) map[string]string {
+		states := map[string]string{}
+		maps.Copy(states, taken)
+
+		for _, mid := range reserved {
+			states[mid] = "\x00reserved"
+		}
+		for _, mid := range pending {
+			states[mid] = "\x00pending"
+		}
+
+		return states
+	}
+
+	cachedStates := state(cached.Taken, cached.Reserved, cached.Pending)
+
+	dbStates := map[string]string{}
+	for _, element := range elements {
+		if element.Reservation == nil {
+			dbStates[element.Mid] = moderatedName(element.Name, element.Approvedname)
+		} else if *element.Reservation < expirationDate {
+			// expired reservations are only removed with the next refresh
+			continue
+		} else if element.Pending {
+			dbStates[element.Mid] = "\x00pending"
+		} else {
+			dbStates[element.Mid] = "\x00reserved"
+		}
+	}
+
+	divergent := []string{}
+
+	for mid, cachedState := range cachedStates {
+		// reservations expiring during the check aren't a divergence
+		if dbState, ok := dbStates[mid]; ok && dbState != cachedState || !ok && cachedState != "\x00reserved" {
+			divergent = append(divergent, mid)
+		}
+	}
+
+	for mid := range dbStates {
+		if _, ok := cachedStates[mid]; !ok {
+			divergent = append(divergent, mid)
+		}
+	}
+
+	slices.Sort(divergent)
+
+	return divergent
+}
+
+// compares the cached elements with the database and refreshes the cache, if they diverge.
+// A write between reading the cache and the database can cause a false divergence, the
+// refresh is harmless then
+func checkCacheConsistency() error {
+	cached, found := cachedElements.Get("status")
+	if !found {
+		return nil
+	}
+
+	expirationDate := time.Now().Add(-config.Reservation.Expiration).Format(time.DateTime)
+
+	elements, err := store.Select[ElementDB]("elements", "*")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.DateTime)
+
+	consistency.checks.Add(1)
+
+	consistency.Lock()
+	consistency.lastCheck = &now
+	consistency.Unlock()
+
+	// the cache was replaced meanwhile, so it can't be compared anymore
+	if current, found := cachedElements.Get("status"); !found || current.Cursor != cached.Cursor {
+		return nil
+	}
+
+	if divergent := divergentElements(cached, elements, expirationDate); len(divergent) > 0 {
+		consistency.divergences.Add(1)
+
+		consistency.Lock()
+		consistency.lastDivergence = &now
+		consistency.mids = divergent
+		consistency.Unlock()
+
+		logger.Error().Msgf("cached elements diverge from the database, refreshing the cache: %q", divergent)
+
+		cachedElements.Delete("status")
+
+		return cacheElements()
+	}
+
+	return nil
+}
+
+// handles get-requests for the results of the consistency-checks of the cache
+func getAdminConsistency(c *fiber.Ctx) responseMessage {
+	consistency.Lock()
+	defer consistency.Unlock()
+
+	mids := consistency.mids
+	if mids == nil {
+		mids = []string{}
+	}
+
+	return responseMessage{
+		Data: ConsistencyMetrics{
+			Checks:         consistency.checks.Load(),
+			Divergences:    consistency.divergences.Load(),
+			LastCheck:      consistency.lastCheck,
+			LastDivergence: consistency.lastDivergence,
+			Mids:           mids,
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// handles get-requests for the lock-conflicts of the database-writes
+func getAdminDatabase(c *fiber.Ctx) responseMessage {
+	metrics := store.GetRetryMetrics()
+
+	return responseMessage{
+		Data: DatabaseMetrics{
+			Deadlocks:    metrics.Deadlocks,
+			LockTimeouts: metrics.LockTimeouts,
+			Retries:      metrics.Retries,
+			Failed:       metrics.Failed,
+		},
+	}
+}
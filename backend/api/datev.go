@@ -0,0 +1,165 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maximum lengths of the text-fields of a booking
+const (
+	datevReferenceLength = 36
+	datevTextLength      = 60
+)
+
+// quotes a text-field of the DATEV-format, truncated to the maximum length
+func datevText(s string, length int) string {
+	if runes := []rune(s); len(runes) > length {
+		s = string(runes[:length])
+	}
+
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// formats an amount with a decimal-comma
+func datevAmount(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(amount, 'f', 2, 64), ".", ",", 1)
+}
+
+// creates the "Buchungsstapel" of the confirmed donations of a year in the DATEV-format.
+// The fiscal year is assumed to be the calendar-year
+func datevBookings(donations []ArchivedSponsorship, matchings []Matching, year int) (string, int) {
+	datev := config.ConfigYaml.Datev
+
+	lines := []string{
+		strings.Join([]string{
+			`"EXTF"`, "700", "21", `"Buchungsstapel"`, "13", time.Now().Format("20060102150405000"), "", `"PV"`, `""`, `""`,
+			strconv.Itoa(datev.ConsultantNumber), strconv.Itoa(datev.ClientNumber),
+			fmt.Sprintf("%d0101", year), strconv.Itoa(datev.AccountLength), fmt.Sprintf("%d0101", year), fmt.Sprintf("%d1231", year),
+			datevText(fmt.Sprintf("Patenschaften %d", year), 30), `""`, "1", "0", "0", `"EUR"`, "", `""`, "", "", `""`, "", "", `""`, `""`,
+		}, ";"),
+		strings.Join([]string{
+			"Umsatz (ohne Soll/Haben-Kz)", "Soll/Haben-Kennzeichen", "WKZ Umsatz", "Kurs", "Basis-Umsatz", "WKZ Basis-Umsatz",
+			"Konto", "Gegenkonto (ohne BU-Schlüssel)", "BU-Schlüssel", "Belegdatum", "Belegfeld 1", "Belegfeld 2", "Skonto", "Buchungstext",
+		}, ";"),
+	}
+
+	// repeated confirmations of the same reservation are a single donation
+	booked := map[string]bool{}
+	skipped := 0
+
+	for _, donation := range donations {
+		reservation := ""
+		if donation.Reservation != nil {
+			reservation = *donation.Reservation
+		}
+
+		if key := donation.Mid + "@" + reservation; booked[key] {
+			continue
+		} else {
+			booked[key] = true
+		}
+
+		// bookings without an amount are rejected by the import
+		if donation.Amount <= 0 {
+			skipped++
+
+			continue
+		}
+
+		if confirmed, err := time.ParseInLocation(time.DateTime, donation.Confirmed, time.Local); err != nil {
+			logger.Warn().Msgf("can't parse confirmation-date of archived sponsorship %d: %v", donation.Aid, err)
+
+			skipped++
+		} else {
+			lines = append(lines, strings.Join([]string{
+				datevAmount(donation.Amount), `"S"`, `"EUR"`, "", "", `""`,
+				datev.Account, datev.ContraAccount, `""`, confirmed.Format("0201"),
+				datevText(donation.Mid, datevReferenceLength), `""`, "",
+				datevText(fmt.Sprintf("Spende %s %s", donation.Mid, donation.Name), datevTextLength),
+			}, ";"))
+		}
+	}
+
+	// the confirmed matching-donations of the employers are booked on their payment
+	for _, matching := range matchings {
+		if matching.Amount <= 0 || matching.Confirmed == nil {
+			skipped++
+		} else if confirmed, err := time.ParseInLocation(time.DateTime, *matching.Confirmed, time.Local); err != nil {
+			logger.Warn().Msgf("can't parse confirmation-date of matching %d: %v", matching.Maid, err)
+
+			skipped++
+		} else {
+			lines = append(lines, strings.Join([]string{
+				datevAmount(matching.Amount), `"S"`, `"EUR"`, "", "", `""`,
+				datev.Account, datev.ContraAccount, `""`, confirmed.Format("0201"),
+				datevText(matching.Mid, datevReferenceLength), `""`, "",
+				datevText(fmt.Sprintf("Spende %s %s (Verdopplung)", matching.Mid, matching.Employer), datevTextLength),
+			}, ";"))
+		}
+	}
+
+	return strings.Join(lines, "\r\n") + "\r\n", skipped
+}
+
+// handles get-requests for exporting the donations confirmed in a year ("year" in the query,
+// defaults to the current one) as DATEV-"Buchungsstapel" for the accounting
+func getExportDatev(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	year := c.QueryInt("year", time.Now().Year())
+
+	if config.ConfigYaml.Datev.Account == "" {
+		response.Status = fiber.StatusNotFound
+		response.Message = "datev-export isn't configured"
+
+		logger.Info().Msg("can't export donations: datev-export isn't configured")
+	} else if year < 2000 || year > 9999 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid year"
+
+		logger.Info().Msgf("can't export donations: invalid year %d", year)
+	} else if donations, err := store.Select[ArchivedSponsorship]("sponsorships_archive", "confirmed >= ? AND confirmed < ? ORDER BY confirmed, aid",
+		fmt.Sprintf("%d-01-01 00:00:00", year), fmt.Sprintf("%d-01-01 00:00:00", year+1)); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get archived sponsorships from database: %v", err)
+	} else if matchings, err := store.Select[Matching]("matchings", "confirmed >= ? AND confirmed < ? ORDER BY confirmed, maid",
+		fmt.Sprintf("%d-01-01 00:00:00", year), fmt.Sprintf("%d-01-01 00:00:00", year+1)); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get matchings from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+		donations = slices.DeleteFunc(donations, func(donation ArchivedSponsorship) bool { return !inPlant(donation.Mid) })
+		matchings = slices.DeleteFunc(matchings, func(matching Matching) bool { return !inPlant(matching.Mid) })
+
+		bookings, skipped := datevBookings(donations, matchings, year)
+
+		// the import expects the file in the windows-encoding, other characters are replaced
+		if encoded, err := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(bookings); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't encode datev-export: %v", err)
+		} else {
+			if skipped > 0 {
+				response.Warnings = append(response.Warnings, fmt.Sprintf("%d donations without amount or date were skipped", skipped))
+			}
+
+			c.Attachment(fmt.Sprintf("EXTF_Buchungsstapel_%d.csv", year))
+			c.Set(fiber.HeaderContentType, "text/csv; charset=windows-1252")
+			c.SendString(encoded)
+
+			response.Status = fiber.StatusOK
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,116 @@
+//go:build dev
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// tables of the elements, their reservations and everything derived from the sponsors, emptied by
+// the reset of the development-data. The users, the settings, the api-keys, the campaigns and the
+// audit-log are kept, so the developers stay logged in and the configuration survives the reset
+var devResetTables = []string{
+	"elements", "expiries", "reservationclients", "postaladdresses", "mailqueue", "certificates",
+	"sponsorships_archive", "matchings", "approvednames", "consents", "newsletter", "notifications",
+	"midaliases", "merges", "mergedcontacts", "campaignmails",
+}
+
+// names of the demo-sponsors
+var devNames = []string{"Erika Mustermann", "Max Mustermann", "Lieschen Müller", "Otto Normalverbraucher", "Familie Schmidt"}
+
+// registers the endpoint resetting the development-data, only compiled into dev-builds
+func registerDevEndpoints(app *fiber.App) {
+	logger.Warn().Msg(`dev-build: the data can be reset at "/api/dev/reset"`)
+
+	app.Post("/api/dev/reset", RequireAdmin, func(c *fiber.Ctx) error {
+		return postDevReset(c).send(c)
+	})
+}
+
+// fills the empty elements-table with demo-data: every 4th element is sponsored, every 10th
+// reserved and every 25th awaits the approval
+func seedDevElements(reset *DevReset) error {
+	now := time.Now()
+
+	for ii, mid := range allMids() {
+		name := devNames[ii%len(devNames)]
+		mail := fmt.Sprintf("demo+%d@example.org", ii)
+		source := "demo"
+
+		element := struct {
+			Mid         string
+			Name        string
+			Mail        *string
+			Source      *string
+			Reservation *string
+			Confirmed   *string
+			Pending     bool
+		}{Mid: mid, Name: name, Mail: &mail, Source: &source}
+
+		switch {
+		case ii%25 == 24:
+			element.Reservation = lib.Ptr(now.Add(-time.Duration(ii) * time.Minute).Format(time.DateTime))
+			element.Pending = true
+
+			reset.Pending++
+		case ii%10 == 9:
+			element.Reservation = lib.Ptr(now.Add(-time.Duration(ii) * time.Minute).Format(time.DateTime))
+
+			reset.Reserved++
+		case ii%4 == 3:
+			element.Confirmed = lib.Ptr(now.AddDate(0, 0, -(ii % 90)).Format(time.DateTime))
+			element.Mail = nil
+
+			reset.Sponsored++
+		default:
+			continue
+		}
+
+		if err := store.Insert("elements", element); err != nil {
+			return fmt.Errorf("can't insert %q: %v", mid, err)
+		}
+	}
+
+	return nil
+}
+
+// handles post-requests emptying the elements and their reservations and filling them with demo-data
+func postDevReset(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	reset := DevReset{Removed: map[string]int64{}}
+
+	for _, table := range devResetTables {
+		if result, err := store.Exec("DELETE FROM " + table); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't empty %q: %v", table, err)
+
+			return response
+		} else if removed, err := result.RowsAffected(); err == nil {
+			reset.Removed[table] = removed
+		}
+	}
+
+	if err := seedDevElements(&reset); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't seed demo-data: %v", err)
+	} else {
+		response.Data = reset
+
+		logger.Warn().Msgf("%q reset the data: %d sponsored, %d reserved and %d pending demo-elements", getUser(c).Name, reset.Sponsored, reset.Reserved, reset.Pending)
+	}
+
+	// the caches hold the removed elements
+	cachedElements.Delete("status")
+	dbCache.Flush()
+	responseCache.Flush()
+
+	return response
+}
@@ -0,0 +1,8 @@
+//go:build !dev
+
+package api
+
+import "github.com/gofiber/fiber/v2"
+
+// the endpoint resetting the development-data is only compiled into dev-builds
+func registerDevEndpoints(app *fiber.App) {}
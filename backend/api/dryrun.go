@@ -0,0 +1,98 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// wether the request only asks for the rows it would change ("?dry_run=true")
+func isDryRun(c *fiber.Ctx) bool {
+	return c.QueryBool("dry_run")
+}
+
+// answers a dry-run with the rows that would be changed
+func dryRunResponse(affected []AffectedRows, err error) responseMessage {
+	var response responseMessage
+
+	if err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't determine the rows of the dry-run: %v", err)
+	} else {
+		response.Data = DryRun{
+			DryRun:   true,
+			Affected: affected,
+		}
+	}
+
+	return response
+}
+
+// returns the keys of the rows of a table matching the condition, as strings
+func affectedKeys[T any](table, where string, key func(T) string, args ...any) ([]string, error) {
+	if rows, err := store.Select[T](table, where, args...); err != nil {
+		return nil, err
+	} else {
+		keys := make([]string, len(rows))
+		for ii, row := range rows {
+			keys[ii] = key(row)
+		}
+
+		return keys, nil
+	}
+}
+
+// adds the rows to the list, if there are any
+func appendAffected(affected []AffectedRows, table, action, cause string, keys []string) []AffectedRows {
+	if len(keys) == 0 {
+		return affected
+	}
+
+	return append(affected, AffectedRows{
+		Table:  table,
+		Action: action,
+		Cause:  cause,
+		Rows:   len(keys),
+		Keys:   keys,
+	})
+}
+
+// determines the rows removed with the entry of an element: the entry itself, its postal-address,
+// the certificates including the ones of its former mids and optionally its queued mails
+func elementDeletion(mid string, withMails bool) ([]AffectedRows, error) {
+	affected := []AffectedRows{}
+
+	if elements, err := affectedKeys("elements", "mid = ?", func(row struct{ Mid string }) string { return row.Mid }, mid); err != nil {
+		return nil, err
+	} else if history, err := midHistory(mid); err != nil {
+		return nil, err
+	} else {
+		affected = appendAffected(affected, "elements", "delete", "", elements)
+
+		if addresses, err := affectedKeys("postaladdresses", "mid = ?", func(row struct{ Mid string }) string { return row.Mid }, mid); err != nil {
+			return nil, err
+		} else {
+			affected = appendAffected(affected, "postaladdresses", "delete", "postal-address of the element", addresses)
+		}
+
+		for _, oldMid := range history {
+			if serials, err := affectedKeys("certificates", "mid = ?", func(row struct{ Serial int }) string { return strconv.Itoa(row.Serial) }, oldMid); err != nil {
+				return nil, err
+			} else {
+				affected = appendAffected(affected, "certificates", "delete", "revocation of the certificates of "+oldMid, serials)
+			}
+		}
+
+		if withMails {
+			if mails, err := affectedKeys("mailqueue", "mid = ?", func(row struct{ Qid int }) string { return strconv.Itoa(row.Qid) }, mid); err != nil {
+				return nil, err
+			} else {
+				affected = appendAffected(affected, "mailqueue", "delete", "queued mails of the reservation", mails)
+			}
+		}
+
+		return affected, nil
+	}
+}
@@ -0,0 +1,833 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"golang.org/x/sync/singleflight"
+)
+
+type ElementsCache struct {
+	Taken    map[string]string
+	Reserved []string
+	// reservations awaiting approval, not included in the client-status
+	Pending []string
+	Shares  map[string]ShareAvailability
+	// elements locked by the active embargoes
+	Locked []LockedElements
+	// end of the reservations of the reserved elements
+	Expires map[string]string
+	// precomputed JSON of the client-status, so it isn't encoded on every request
+	JSON json.RawMessage
+	// cursor of the state for requesting the changes since
+	Cursor string
+}
+
+// merges concurrent refreshes of the elements-cache into a single one
+var elementsRefresh singleflight.Group
+
+// caches the elements from the database. Concurrent calls share a single refresh
+func cacheElements() error {
+	_, err, _ := elementsRefresh.Do("elements", func() (any, error) {
+		return nil, refreshElements()
+	})
+
+	return err
+}
+
+// removes the expired reservations and caches the remaining elements
+func refreshElements() error {
+	// the expiration is part of the condition, so concurrent or repeated deletions don't interfere
+	expirationDate := time.Now().Add(-config.Reservation.Expiration).Format(time.DateTime)
+
+	// a failed recording only weakens the throttling, the expired reservations are removed anyway
+	if err := recordExpiries(expirationDate); err != nil {
+		logger.Error().Msgf("can't record expired reservations: %v", err)
+	}
+
+	if purged, err := store.DeleteBefore("elements", "reservation", expirationDate); err != nil {
+		logger.Error().Msgf("can't remove expired elements from database (%d removed before): %v", purged, err)
+
+		return err
+	} else if res, err := store.Select[ElementDB]("elements", "*"); err != nil {
+		return err
+	} else {
+		if purged > 0 {
+			logger.Info().Msgf("removed %d expired reservations", purged)
+		}
+
+		// the addresses of deleted or expired reservations aren't kept
+		if err := purgePostalAddresses(); err != nil {
+			logger.Error().Msgf("can't remove orphaned postal-addresses: %v", err)
+		}
+
+		now := time.Now()
+
+		takenElements := make(map[string]string)
+		reservedElements := []string{}
+		pendingElements := []string{}
+		expires := make(map[string]string)
+
+		// time until the next gift is delivered and its recipient becomes public
+		var untilDelivery time.Duration
+
+		for _, element := range res {
+			if element.Reservation != nil {
+				if element.Pending {
+					pendingElements = append(pendingElements, element.Mid)
+				} else {
+					reservedElements = append(reservedElements, element.Mid)
+
+					if reserved, err := time.ParseInLocation(time.DateTime, *element.Reservation, time.Local); err != nil {
+						logger.Warn().Msgf("can't parse reservation-date of %q: %v", element.Mid, err)
+					} else {
+						expires[element.Mid] = reserved.Add(config.Reservation.Expiration).Format(time.DateTime)
+					}
+				}
+			} else if giftUndelivered(element.Giftdelivery, now) {
+				// the element is shown as taken, but the recipient isn't named before the delivery
+				takenElements[element.Mid] = ""
+
+				if delivery, err := time.ParseInLocation(time.DateTime, *element.Giftdelivery, time.Local); err != nil {
+					logger.Warn().Msgf("can't parse gift-delivery of %q: %v", element.Mid, err)
+				} else if d := delivery.Sub(now); untilDelivery == 0 || d < untilDelivery {
+					untilDelivery = d
+				}
+			} else {
+				takenElements[element.Mid] = moderatedName(element.Name, element.Approvedname)
+			}
+		}
+
+		shares := shareAvailability(takenElements, reservedElements, pendingElements)
+
+		locked := lockedElements(now)
+
+		elementsCache := ElementsCache{
+			Taken:    takenElements,
+			Reserved: reservedElements,
+			Pending:  pendingElements,
+			Shares:   shares,
+			Locked:   locked,
+			Expires:  expires,
+			Cursor:   recordElementChanges(takenElements, reservedElements),
+		}
+
+		if clientStatus, err := json.Marshal(publicClientStatus(elementsCache, func(string) bool { return true }, locked)); err != nil {
+			return err
+		} else {
+			elementsCache.JSON = clientStatus
+		}
+
+		// refresh the cache when an embargo starts or ends or a gift is delivered, so the locked
+		// elements and the names are current
+		expiration := cachedElements.Expiration()
+		for _, next := range []time.Duration{untilEmbargoChange(now), untilDelivery} {
+			if next > 0 && next < expiration {
+				expiration = next
+			}
+		}
+
+		cachedElements.SetExpiring("status", elementsCache, expiration)
+
+		// update the static export
+		if err := exportElements(elementsCache); err != nil {
+			logger.Error().Msgf("can't export elements: %v", err)
+		}
+
+		return nil
+	}
+}
+
+// returns the taken and reserved elements matching the filter, the names of the sponsors
+// are shortened as configured in "public_names"
+func filterElements(taken map[string]string, reserved []string, filter func(mid string) bool) (map[string]string, []string) {
+	filteredTaken := make(map[string]string)
+	filteredReserved := []string{}
+
+	for mid, name := range taken {
+		if filter(mid) {
+			filteredTaken[mid] = publicName(name)
+		}
+	}
+
+	for _, mid := range reserved {
+		if filter(mid) {
+			filteredReserved = append(filteredReserved, mid)
+		}
+	}
+
+	return filteredTaken, filteredReserved
+}
+
+// creates the public client-status of the elements matching the filter, without the hidden ones
+func publicClientStatus(elements ElementsCache, filter func(mid string) bool, locked []LockedElements) ClientStatus {
+	visible := func(mid string) bool { return filter(mid) && !isHidden(mid) }
+
+	taken, reserved := filterElements(elements.Taken, elements.Reserved, visible)
+
+	shares := map[string]ShareAvailability{}
+	for mid, availability := range elements.Shares {
+		if visible(mid) {
+			shares[mid] = availability
+		}
+	}
+
+	return ClientStatus{
+		Taken:    taken,
+		Reserved: reserved,
+		Shares:   shares,
+		Locked:   locked,
+		Expires:  reservationExpires(elements.Expires, reserved),
+		Hidden:   slices.DeleteFunc(getHiddenMids(), func(mid string) bool { return !filter(mid) }),
+	}
+}
+
+// returns the ends of the reservations of the elements
+func reservationExpires(expires map[string]string, mids []string) map[string]string {
+	result := make(map[string]string)
+
+	for _, mid := range mids {
+		if expiration, ok := expires[mid]; ok {
+			result[mid] = expiration
+		}
+	}
+
+	return result
+}
+
+// gets the elements from the cache
+func getElements(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+
+	elements, found := cachedElements.Get("status")
+
+	if !found {
+		if err := cacheElements(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msgf("can't get elements from database: %v", err)
+		} else if elements, found = cachedElements.Get("status"); !found {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msg(`can't get "elements" from cache`)
+		}
+	}
+
+	// if the reponse-status is still unset, there was no error
+	if response.Status == 0 {
+		c.Set(fiber.HeaderETag, fmt.Sprintf("%q", elements.Cursor))
+
+		inPlant := plantFilter(c)
+
+		// if a cursor is given, return only the changes since then
+		if since := c.Query("since"); since != "" {
+			diff := getElementChanges(since)
+
+			// hidden elements are left out of the diff
+			visible := func(mid string) bool { return inPlant(mid) && !isHidden(mid) }
+
+			diff.Taken, diff.Reserved = filterElements(diff.Taken, diff.Reserved, visible)
+			diff.Free = slices.DeleteFunc(diff.Free, func(mid string) bool { return !visible(mid) })
+
+			diff.Expires = reservationExpires(elements.Expires, diff.Reserved)
+
+			response.Data = diff
+		} else if c.Query("plant") != "" {
+			response.Data = publicClientStatus(elements, inPlant, lockedInPlant(elements.Locked, c.Query("plant")))
+		} else {
+			response.Data = elements.JSON
+		}
+
+		logger.Debug().Msg("retrieved elements")
+	}
+
+	return response
+}
+
+// regex to match valid element-names
+func isValidMid(element string) (bool, error) {
+	check, err := checkMid(element)
+
+	return check.Valid, err
+}
+
+// evaluates a mid against the rules of "validate_elements" and explains the result
+func checkMid(mid string) (MidCheck, error) {
+	element, share := certs.SplitShare(mid)
+
+	check := MidCheck{Mid: mid, Share: share}
+
+	if parts, ok := config.Mids.Parse(element); !ok {
+		check.Reason = fmt.Sprintf("doesn't match the regex %q", config.ValidateElements.Regex)
+	} else {
+		check.Descriptor = parts.Descriptor
+
+		// check wether the descriptor-part is valid
+		if rng, ok := config.ValidateElements.ValidElements[parts.Descriptor]; !ok {
+			check.Reason = fmt.Sprintf("descriptor %q isn't in the valid elements", parts.Descriptor)
+
+			// elements split into shares can only be reserved by their shares
+		} else if rng.Shares > 0 && share == 0 {
+			check.Reason = fmt.Sprintf("elements %q are split into %d shares, the mid needs a share (e.g. %q)", parts.Descriptor, rng.Shares, element+certs.ShareSeparator+"1")
+		} else if rng.Shares == 0 && share > 0 {
+			check.Reason = fmt.Sprintf("elements %q aren't split into shares", parts.Descriptor)
+		} else if share > rng.Shares {
+			check.Reason = fmt.Sprintf("share %d exceeds the %d shares of elements %q", share, rng.Shares, parts.Descriptor)
+
+			// try to parse the mid-number
+		} else if n, err := strconv.Atoi(parts.Number); err != nil {
+			check.Reason = fmt.Sprintf("number %q isn't numeric", parts.Number)
+
+			return check, err
+
+			// every element has a single mid, e.g. "pv-a01" isn't "pv-a1"
+		} else if !backendConfig.IsCanonicalNumber(parts.Number, rng.Digits) {
+			check.Number = n
+			check.Reason = fmt.Sprintf("number %q has to be written as %q", parts.Number, backendConfig.FormatMid(parts.Descriptor, n, rng.Digits))
+		} else {
+			check.Number = n
+			check.Valid = rng.From <= n && n <= rng.To
+
+			if check.Valid {
+				check.Reason = fmt.Sprintf("number %d is within %d to %d of elements %q", n, rng.From, rng.To, parts.Descriptor)
+			} else {
+				check.Reason = fmt.Sprintf("number %d is outside of %d to %d of elements %q", n, rng.From, rng.To, parts.Descriptor)
+			}
+		}
+	}
+
+	return check, nil
+}
+
+// checks the example-mids of "validate_elements.valid_elements" against the rules,
+// returns the examples evaluated differently than expected
+func checkMidExamples() []string {
+	problems := []string{}
+
+	for descriptor, rng := range config.ValidateElements.ValidElements {
+		for _, expectValid := range []bool{true, false} {
+			examples := rng.Examples.Invalid
+			if expectValid {
+				examples = rng.Examples.Valid
+			}
+
+			for _, mid := range examples {
+				if check, err := checkMid(mid); err != nil {
+					problems = append(problems, fmt.Sprintf("example %q of %q: %v", mid, descriptor, err))
+				} else if check.Valid != expectValid {
+					problems = append(problems, fmt.Sprintf("example %q of %q is expected to be valid=%t, but %s", mid, descriptor, expectValid, check.Reason))
+				} else if expectValid && check.Descriptor != descriptor {
+					problems = append(problems, fmt.Sprintf("example %q of %q belongs to %q", mid, descriptor, check.Descriptor))
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// handles get-requests for evaluating a mid against the rules ("mid" in the query)
+func getElementsCheck(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include mid"
+
+		logger.Info().Msg("can't check mid: query doesn't include mid")
+	} else if check, err := checkMid(mid); err != nil {
+		response.Data = check
+
+		logger.Info().Msgf("can't parse number of mid %q: %v", mid, err)
+	} else {
+		response.Data = check
+	}
+
+	return response
+}
+
+// handles post-requests for reserving new elements
+func postElements(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+
+	body := ReservationBody{}
+
+	mid := c.Query("mid")
+
+	if ok, err := isValidMid(mid); err != nil || !ok {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid mID"
+
+		logger.Info().Msgf("can't reserve element: invalid element-name: %q", mid)
+	} else if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ name string; mail string; source string }"`)
+	} else {
+		response = reserveElement(c, mid, body)
+	}
+
+	return response
+}
+
+// reserves an element for the sponsor of the request-body
+func reserveElement(c *fiber.Ctx, mid string, body ReservationBody) responseMessage {
+	response := responseMessage{}
+
+	elements, found := cachedElements.Get("status")
+
+	if !found {
+		if err := cacheElements(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msgf("can't get elements from database: %v", err)
+		} else if elements, found = cachedElements.Get("status"); !found {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msg("can't get 'elements' from cache")
+		}
+	}
+
+	// if the status is still unset, there was no error
+	if response.Status == 0 {
+		// check wether the element already exists
+		if _, ok := elements.Taken[mid]; ok {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "element is already taken"
+
+			logger.Info().Msgf("element %q is already taken", mid)
+
+			return response
+		} else if slices.Contains(elements.Reserved, mid) || slices.Contains(elements.Pending, mid) {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "element is currently reserved"
+
+			logger.Info().Msgf("element %q is currently reserved", mid)
+
+			return response
+		} else if isHidden(mid) && !adminOverride(c) {
+			response.Status = fiber.StatusNotFound
+			response.Message = "element isn't available"
+
+			logger.Info().Msgf("can't reserve element %q: element is hidden", mid)
+
+			return response
+		} else if embargo := elementEmbargo(mid, time.Now()); embargo != nil && !adminOverride(c) {
+			response.Status = fiber.StatusForbidden
+			response.Message = "element can't be reserved yet"
+
+			if !embargo.End.IsZero() {
+				response.Message = "element can't be reserved before %s"
+				response.Args = []any{embargo.End.Format(time.DateTime)}
+			}
+
+			logger.Info().Msgf("can't reserve element %q: element is under embargo", mid)
+
+			return response
+		}
+
+		// reject addresses of disposable or non-existent mail-domains
+		addresses := []string{body.Mail}
+		if body.Gift != nil {
+			addresses = append(addresses, body.Gift.Mail)
+		}
+
+		for _, address := range addresses {
+			if address == "" {
+				continue
+			} else if err := checkMailDomain(address); err != nil {
+				response.Status = fiber.StatusBadRequest
+				response.Message, response.Args = errorMessage(err)
+
+				logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+				return response
+			} else if !config.ConfigYaml.MailCheck.Suggestions || body.ConfirmMail {
+				continue
+			} else if suggestion := suggestMailDomain(address); suggestion != "" {
+				// the sponsor has to correct or confirm the address
+				response.Status = fiber.StatusUnprocessableEntity
+				response.Message = "did you mean %q?"
+				response.Args = []any{suggestion}
+
+				logger.Info().Msgf("can't reserve element %q: suggested %q for %q", mid, suggestion, address)
+
+				return response
+			}
+		}
+
+		// check wether the mail-address has reached its reservation-limit
+		if exceeded, err := exceedsMailLimit(c, body.Mail); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't check reservation-limit"
+
+			logger.Error().Msgf("can't check reservation-limit for %q: %v", body.Mail, err)
+
+			return response
+		} else if exceeded {
+			response.Status = fiber.StatusTooManyRequests
+			response.Message = "reservation-limit reached"
+
+			logger.Info().Msgf("can't reserve element %q: reservation-limit for %q reached", mid, body.Mail)
+
+			return response
+		}
+
+		// fall back to the utm-parameter or the partner-website if the body doesn't include a source
+		if body.Source == "" {
+			body.Source = c.Query("utm_source")
+		}
+
+		if body.Source == "" {
+			body.Source = getAPIKeyName(c)
+		}
+
+		source := normalizeSource(body.Source)
+
+		gift, err := parseGift(body)
+		if err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		fields, err := validateFields(body.Fields)
+		if err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		if err := validateDedication(body.Dedication); err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		var dedication *string
+		if body.Dedication != "" {
+			dedication = &body.Dedication
+		}
+
+		matching, err := parseMatching(body)
+		if err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		postal, err := parsePostal(body)
+		if err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		if err := validateLegal(body.Legal); err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", mid, err)
+
+			return response
+		}
+
+		// send the reservation e-mail
+		data := certs.ReservationData{
+			Mail: body.Mail,
+			Mid:  mid,
+			Name: body.Name,
+		}
+
+		pending := config.ConfigYaml.Reservation.RequireApproval
+
+		// elements whose reservations expired repeatedly need an approval
+		if throttled, err := isThrottled(mid); err != nil {
+			logger.Error().Msgf("can't check expiries of %q: %v", mid, err)
+		} else if throttled && !pending {
+			pending = true
+
+			logger.Info().Msgf("reservation of %q requires approval after repeated expiries", mid)
+		}
+
+		// the reservation-time is part of the status-token of the sponsor
+		reserved := time.Now()
+
+		// clear the current cache
+		cachedElements.Delete("status")
+
+		// write the data to the database
+		if err := store.Insert("elements", struct {
+			Mid               string
+			Reservation       string
+			Name              string
+			Mail              *string
+			Source            *string
+			Pending           bool
+			Buyer             *string
+			Giftmail          *string
+			Giftdelivery      *string
+			Fields            json.RawMessage
+			Dedication        *string
+			Matchingemployer  *string
+			Matchingreference *string
+		}{
+			Mid: mid, Reservation: reserved.Format(time.DateTime), Name: gift.Name, Mail: &body.Mail, Source: source, Pending: pending,
+			Buyer: gift.Buyer, Giftmail: gift.Giftmail, Giftdelivery: gift.Giftdelivery,
+			Fields: fields, Dedication: dedication, Matchingemployer: matching.Employer, Matchingreference: matching.Reference,
+		}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while writing reservation to database"
+
+			logger.Error().Msgf("can't write reservation to database: %v", err)
+		} else {
+			recordReservationClient(c, mid, reserved)
+
+			// the warnings are added to the final response
+			var warnings []string
+
+			// store the consents to the legal documents
+			if err := storeConsents(body.Mail, mid, c.IP()); err != nil {
+				logger.Error().Msgf("can't store consents of %q: %v", body.Mail, err)
+			}
+
+			// the reservation is kept without the address, the sponsor can be asked for it again
+			if postal != nil {
+				if err := storePostalAddress(mid, *postal, body.Printed); err != nil {
+					logger.Error().Msgf("can't store postal-address of %q: %v", mid, err)
+
+					warnings = append(warnings, "reservation saved, but the postal address couldn't be stored")
+				}
+			}
+
+			// store the newsletter-consent
+			if body.Newsletter {
+				if err := subscribeNewsletter(body.Mail, body.Name, c.IP()); err != nil {
+					logger.Error().Msgf("can't store newsletter-consent for %q: %v", body.Mail, err)
+				}
+			}
+
+			if pending {
+				notify(roleAdmin, notificationApproval, mid, fmt.Sprintf("reservation of %q awaits approval", mid))
+			} else {
+				notify(roleUser, notificationReservation, mid, fmt.Sprintf("new reservation of %q", mid))
+			}
+
+			// the reservation is kept, even if the mail can't be sent. While the mail-server
+			// isn't available, the mail is queued
+			if msg, err := renderReservationEmail(data, body.Newsletter, pending, gift, reserved); err != nil {
+				logger.Error().Msgf("can't render reservation-mail: %v", err)
+
+				notify(roleAdmin, notificationMailFailed, mid, fmt.Sprintf("reservation-mail for %q couldn't be sent", mid))
+
+				warnings = append(warnings, "reservation saved, but the reservation-mail couldn't be sent")
+			} else if queued, err := sendOrQueueMail(mid, msg); err != nil {
+				logger.Error().Msgf("can't send or queue reservation-mail: %v", err)
+
+				notify(roleAdmin, notificationMailFailed, mid, fmt.Sprintf("reservation-mail for %q couldn't be sent", mid))
+
+				warnings = append(warnings, "reservation saved, but the reservation-mail couldn't be sent")
+			} else if queued {
+				warnings = append(warnings, "reservation saved, the reservation-mail will be sent as soon as possible")
+			}
+
+			response = getElements(c)
+			response.Warnings = append(response.Warnings, warnings...)
+
+			logger.Debug().Msgf("reserved element %q", mid)
+		}
+	}
+
+	return response
+}
+
+// wether an admin requested to skip the restrictions of public reservations with the "override"-query
+func adminOverride(c *fiber.Ctx) bool {
+	if c.QueryBool("override") {
+		if user, err := authenticateUser(c); err == nil && user != nil && user.hasRole(roleAdmin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checks wether the mail-address already has the maximum number of reservations
+// inside the limit-window. Admins can skip the check with the "override"-query
+func exceedsMailLimit(c *fiber.Ctx, mail string) (bool, error) {
+	maxPerMail := config.ConfigYaml.Reservation.MaxPerMail
+
+	// a limit of zero or less disables the check
+	if maxPerMail <= 0 {
+		return false, nil
+	}
+
+	if adminOverride(c) {
+		logger.Info().Msgf("reservation-limit for %q overridden by admin", mail)
+
+		return false, nil
+	}
+
+	windowStart := time.Now().Add(-config.Reservation.LimitWindow).Format(time.DateTime)
+
+	if sealed, err := store.Seal(mail); err != nil {
+		return false, err
+	} else if res, err := store.Select[ElementDB]("elements", "mail = ? AND reservation IS NOT NULL AND reservation > ?", sealed, windowStart); err != nil {
+		return false, err
+	} else {
+		return len(res) >= maxPerMail, nil
+	}
+}
+
+// maximum length of a stored reservation-source
+const maxSourceLength = 64
+
+// cleans up the source of a reservation, returns nil if there is none
+func normalizeSource(source string) *string {
+	source = strings.ToLower(strings.TrimSpace(source))
+
+	if source == "" {
+		return nil
+	}
+
+	if runes := []rune(source); len(runes) > maxSourceLength {
+		source = string(runes[:maxSourceLength])
+	}
+
+	return &source
+}
+
+// sends the reservation-mail for an element
+func renderReservationEmail(data certs.ReservationData, newsletter, pending bool, gift giftData, reserved time.Time) (mailer.Message, error) {
+	templateData := certs.SponsorshipTemplateData{}
+	templateData.Populate(data.Mid, data.Name, elementShares(data.Mid))
+	templateData.Pending = pending
+
+	if gift.Buyer != nil {
+		templateData.Recipient = gift.Name
+	}
+	templateData.Amount = lib.FormatCurrency(elementPrice(data.Mid))
+
+	if newsletter {
+		templateData.Unsubscribe = newsletterUnsubscribeURL(data.Mail)
+	}
+
+	if config.ConfigYaml.Reservation.StatusURL != "" {
+		templateData.Status = reservationStatusURL(data.Mid, reserved)
+	}
+
+	return mailer.Render(data.Mail, "reservation_mail", templateData)
+}
+
+// handles patch-requests for modifying element reservations
+func patchElements(c *fiber.Ctx) responseMessage {
+	body := NameBody{}
+
+	ids, response := bindRequest(c, "", &body)
+	mid := ids.Mid
+
+	if response.Status != 0 {
+		return response
+	} else if ok, err := isValidMid(mid); err != nil || !ok {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid element name"
+
+		logger.Info().Msgf("can't modify element: invalid element-name: %q", mid)
+	} else {
+		// check wether the element already exists
+		if elements, found := cachedElements.Get("status"); found {
+			if _, ok := elements.Taken[mid]; !ok {
+				response.Status = fiber.StatusBadRequest
+				response.Message = "element is already reserved"
+
+				logger.Info().Msgf("element %q is already reserved", mid)
+
+				return response
+			}
+		}
+
+		// clear the current cache
+		cachedElements.Delete("status")
+
+		// write the data to the database
+		if err := store.Update("elements", struct{ Name string }{Name: body.Name}, struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while writing reservation to database"
+
+			logger.Error().Msgf("can't write reservation to database: %v", err)
+		} else {
+			response = getElements(c)
+
+			logger.Debug().Msgf("modified reservation for element %q", mid)
+		}
+	}
+
+	return response
+}
+
+// handle delete-requets for deleting an element reservation
+func deleteElements(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+
+	mid := c.Query("mid")
+
+	if ok, err := isValidMid(mid); !ok || err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid element name"
+
+		logger.Info().Msgf("can't delete element: invalid element-name: %q", mid)
+	} else if isDryRun(c) {
+		response = dryRunResponse(elementDeletion(mid, false))
+	} else {
+		cachedElements.Delete("status")
+
+		if err := store.Delete("elements", struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while deleting reservation from database"
+
+			logger.Error().Msgf("can't delete reservation from database: %v", err)
+		} else if err := revokeCertificates(mid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't revoke certificates of element %q: %v", mid, err)
+		} else {
+			recordAudit(c, auditDelete, mid)
+
+			response = getElements(c)
+
+			logger.Debug().Msgf("deleted reservation for %q", mid)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// creates elements for the fake database, every second one is taken and every tenth one reserved
+func testElements(count int) []map[string]driver.Value {
+	elements := make([]map[string]driver.Value, count)
+
+	for ii := range elements {
+		element := map[string]driver.Value{
+			"mid":     fmt.Sprintf("a%d", ii+1),
+			"name":    "",
+			"pending": false,
+			"fields":  "{}",
+		}
+
+		switch {
+		case ii%10 == 0:
+			element["name"] = "Reserved Sponsor"
+			element["reservation"] = time.Now().Format(time.DateTime)
+		case ii%2 == 0:
+			element["name"] = fmt.Sprintf("Sponsor %d", ii)
+		}
+
+		elements[ii] = element
+	}
+
+	return elements
+}
+
+// answers the statements of a refresh of the elements-cache from the elements
+func elementsHandler(elements []map[string]driver.Value) func(string, []driver.Value) (fakeResult, error) {
+	return func(query string, args []driver.Value) (fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "DELETE FROM elements "), strings.HasPrefix(query, "DELETE FROM postaladdresses "):
+			return fakeResult{}, nil
+		case strings.HasPrefix(query, "SELECT ") && strings.Contains(query, " FROM elements"):
+			return selectResult(query, elements...), nil
+		default:
+			return fakeResult{}, fmt.Errorf("unexpected statement: %s", query)
+		}
+	}
+}
+
+// sends a get-request of the elements to the handler of an app
+func requestElements(handler fasthttp.RequestHandler) *fasthttp.Response {
+	var ctx fasthttp.RequestCtx
+
+	ctx.Request.Header.SetMethod(fiber.MethodGet)
+	ctx.Request.SetRequestURI("/api/elements")
+
+	handler(&ctx)
+
+	return &ctx.Response
+}
+
+// runs get-requests of the elements through the handler of the app
+func benchmarkGetElements(b *testing.B, app *fiber.App, prepare func()) {
+	handler := app.Handler()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for ii := 0; ii < b.N; ii++ {
+		prepare()
+
+		if res := requestElements(handler); res.StatusCode() != fiber.StatusOK {
+			b.Fatalf("unexpected status %d: %s", res.StatusCode(), res.Body())
+		}
+	}
+}
+
+// concurrent requests on an empty cache share a single refresh, which removes the expired
+// reservation once. Refreshing again doesn't remove anything further
+func TestGetElementsConcurrentRefresh(t *testing.T) {
+	const requests = 100
+
+	elements := testElements(20)
+	elements = append(elements, map[string]driver.Value{
+		"mid":         "z1",
+		"name":        "Expired Sponsor",
+		"reservation": time.Now().Add(-72 * time.Hour).Format(time.DateTime),
+		"pending":     false,
+		"fields":      "{}",
+	})
+
+	otherStatements := elementsHandler(nil)
+	misses := cachedElements.misses.Load()
+	var deleted int64
+
+	fake := useFakeDB(t, testConfig(), func(query string, args []driver.Value) (fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query, "DELETE FROM elements "):
+			// keep the refresh running until every request missed the cache, so none of them
+			// arrives after it finished
+			for deadline := time.Now().Add(5 * time.Second); cachedElements.misses.Load()-misses < requests && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+
+			before := args[0].(string)
+			remaining := elements[:0:0]
+
+			for _, element := range elements {
+				if reservation, ok := element["reservation"].(string); ok && reservation < before {
+					deleted++
+				} else {
+					remaining = append(remaining, element)
+				}
+			}
+
+			res := fakeResult{affected: int64(len(elements) - len(remaining))}
+			elements = remaining
+
+			return res, nil
+		case strings.HasPrefix(query, "SELECT ") && strings.Contains(query, " FROM elements"):
+			return selectResult(query, elements...), nil
+		default:
+			return otherStatements(query, args)
+		}
+	})
+
+	handler := testApp(fiber.MethodGet, "/api/elements", getElements).Handler()
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, requests)
+
+	for ii := 0; ii < requests; ii++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			statuses <- requestElements(handler).StatusCode()
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	for status := range statuses {
+		if status != fiber.StatusOK {
+			t.Errorf("request failed with status %d", status)
+		}
+	}
+
+	if count := fake.count("DELETE FROM elements "); count != 1 {
+		t.Errorf("expired reservations were deleted %d times, expected once", count)
+	}
+
+	if count := fake.count("SELECT "); count != 1 {
+		t.Errorf("elements were selected %d times, expected once", count)
+	}
+
+	if deleted != 1 {
+		t.Errorf("%d expired reservations were deleted, expected 1", deleted)
+	}
+
+	cached, found := cachedElements.Get("status")
+	if !found {
+		t.Fatal("elements weren't cached")
+	} else if slices.Contains(cached.Reserved, "z1") {
+		t.Error("expired reservation is still cached")
+	}
+
+	// the expiration is part of the condition, so a second deletion has no effect
+	if err := cacheElements(); err != nil {
+		t.Fatalf("can't refresh elements again: %v", err)
+	}
+
+	recached, _ := cachedElements.Get("status")
+
+	if deleted != 1 {
+		t.Errorf("repeated refresh deleted %d expired reservations in total, expected 1", deleted)
+	} else if !reflect.DeepEqual(cached.Taken, recached.Taken) || !slices.Equal(cached.Reserved, recached.Reserved) {
+		t.Error("repeated refresh changed the elements")
+	}
+}
+
+// the recipient of a gift is only named after its delivery, before the element is taken without a name
+func TestRefreshElementsGiftRecipient(t *testing.T) {
+	elements := []map[string]driver.Value{
+		{"mid": "a1", "name": "Early Recipient", "pending": false, "fields": "{}", "giftdelivery": time.Now().Add(-time.Hour).Format(time.DateTime)},
+		{"mid": "a2", "name": "Later Recipient", "pending": false, "fields": "{}", "giftdelivery": time.Now().Add(time.Hour).Format(time.DateTime)},
+	}
+
+	useFakeDB(t, testConfig(), elementsHandler(elements))
+
+	if err := cacheElements(); err != nil {
+		t.Fatalf("can't cache elements: %v", err)
+	}
+
+	cached, _ := cachedElements.Get("status")
+
+	if name, ok := cached.Taken["a1"]; !ok || name != "Early Recipient" {
+		t.Errorf("delivered gift is %q, expected the recipient", name)
+	}
+
+	if name, ok := cached.Taken["a2"]; !ok {
+		t.Error("undelivered gift isn't taken")
+	} else if name != "" {
+		t.Errorf("recipient of the undelivered gift is public as %q", name)
+	}
+
+	if strings.Contains(string(cached.JSON), "Later Recipient") {
+		t.Error("recipient of the undelivered gift is in the public status")
+	}
+}
+
+// requests of the elements answered from the cache, the common case during a traffic-spike
+func BenchmarkGetElementsCached(b *testing.B) {
+	useFakeDB(b, testConfig(), elementsHandler(testElements(1000)))
+
+	if err := cacheElements(); err != nil {
+		b.Fatalf("can't cache elements: %v", err)
+	}
+
+	benchmarkGetElements(b, testApp(fiber.MethodGet, "/api/elements", getElements), func() {})
+}
+
+// requests of the elements with an expired cache, each one refreshes it from the database
+func BenchmarkGetElementsUncached(b *testing.B) {
+	useFakeDB(b, testConfig(), elementsHandler(testElements(1000)))
+
+	benchmarkGetElements(b, testApp(fiber.MethodGet, "/api/elements", getElements), func() {
+		cachedElements.Delete("status")
+	})
+}
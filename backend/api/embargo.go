@@ -0,0 +1,102 @@
+package api
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+)
+
+// wether the embargo is active at the time
+func embargoActive(embargo backendConfig.EmbargoWindow, now time.Time) bool {
+	return (embargo.Start.IsZero() || !now.Before(embargo.Start)) && (embargo.End.IsZero() || now.Before(embargo.End))
+}
+
+// returns the range of the numbers of the elements of an embargo
+func embargoRange(embargo backendConfig.EmbargoWindow) (int, int) {
+	if embargo.From == 0 && embargo.To == 0 {
+		rng := config.ValidateElements.ValidElements[embargo.Elements]
+
+		return rng.From, rng.To
+	} else {
+		return embargo.From, embargo.To
+	}
+}
+
+// returns the active embargo of an element, nil if it can be reserved
+func elementEmbargo(mid string, now time.Time) *backendConfig.EmbargoWindow {
+	mid, _ = certs.SplitShare(mid)
+
+	if parts, ok := config.Mids.Parse(mid); !ok {
+		return nil
+	} else if n, err := strconv.Atoi(parts.Number); err != nil {
+		return nil
+	} else {
+		for _, embargo := range config.Embargoes {
+			from, to := embargoRange(embargo)
+
+			if embargo.Elements == parts.Descriptor && from <= n && n <= to && embargoActive(embargo, now) {
+				return &embargo
+			}
+		}
+
+		return nil
+	}
+}
+
+// returns the elements locked by the active embargoes
+func lockedElements(now time.Time) []LockedElements {
+	locked := []LockedElements{}
+
+	for _, embargo := range config.Embargoes {
+		if embargoActive(embargo, now) {
+			from, to := embargoRange(embargo)
+
+			var until *string
+			if !embargo.End.IsZero() {
+				until = lib.Ptr(embargo.End.Format(time.DateTime))
+			}
+
+			locked = append(locked, LockedElements{
+				Elements: embargo.Elements,
+				From:     from,
+				To:       to,
+				Digits:   config.ValidateElements.ValidElements[embargo.Elements].Digits,
+				Until:    until,
+			})
+		}
+	}
+
+	return locked
+}
+
+// returns the locked elements belonging to a plant
+func lockedInPlant(locked []LockedElements, plant string) []LockedElements {
+	for _, p := range config.Plants {
+		if p.ID == plant {
+			return slices.DeleteFunc(slices.Clone(locked), func(l LockedElements) bool {
+				return !slices.Contains(p.Elements, l.Elements)
+			})
+		}
+	}
+
+	return []LockedElements{}
+}
+
+// returns the time until the next embargo starts or ends, zero if none will
+func untilEmbargoChange(now time.Time) time.Duration {
+	var next time.Duration
+
+	for _, embargo := range config.Embargoes {
+		for _, t := range []time.Time{embargo.Start, embargo.End} {
+			if d := t.Sub(now); t.After(now) && (next == 0 || d < next) {
+				next = d
+			}
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// maximum number of different error-messages kept, the ones seen longest ago are dropped first
+const maxErrorSummaries = 100
+
+// variable parts of the log-messages, replaced for grouping them
+var (
+	errorQuotedPattern = regexp.MustCompile(`"[^"]*"`)
+	errorNumberPattern = regexp.MustCompile(`\d+`)
+)
+
+// collects the error-level log-events in memory, so they can be inspected without
+// access to the log-files
+type errorCollector struct {
+	sync.Mutex
+	summaries map[string]*ErrorSummary
+}
+
+var recentErrors = &errorCollector{
+	summaries: map[string]*ErrorSummary{},
+}
+
+// groups messages that only differ in their quoted values and numbers
+func errorKind(msg string) string {
+	msg = errorQuotedPattern.ReplaceAllString(msg, `"…"`)
+
+	return errorNumberPattern.ReplaceAllString(msg, "#")
+}
+
+// implements zerolog.Hook
+func (collector *errorCollector) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < zerolog.ErrorLevel {
+		return
+	}
+
+	now := time.Now().Format(time.DateTime)
+	kind := errorKind(msg)
+
+	collector.Lock()
+	defer collector.Unlock()
+
+	if summary, ok := collector.summaries[kind]; ok {
+		summary.Count++
+		summary.Example = msg
+		summary.LastSeen = now
+	} else {
+		// drop the message seen longest ago
+		if len(collector.summaries) >= maxErrorSummaries {
+			var oldest *ErrorSummary
+
+			for _, summary := range collector.summaries {
+				if oldest == nil || summary.LastSeen < oldest.LastSeen {
+					oldest = summary
+				}
+			}
+
+			delete(collector.summaries, oldest.Message)
+		}
+
+		collector.summaries[kind] = &ErrorSummary{
+			Message:   kind,
+			Example:   msg,
+			Level:     level.String(),
+			Count:     1,
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+	}
+}
+
+// returns the error-messages seen since the time, the most recent first
+func (collector *errorCollector) since(since string) []ErrorSummary {
+	collector.Lock()
+	defer collector.Unlock()
+
+	summaries := []ErrorSummary{}
+
+	for _, summary := range collector.summaries {
+		if summary.LastSeen >= since {
+			summaries = append(summaries, *summary)
+		}
+	}
+
+	slices.SortFunc(summaries, func(a, b ErrorSummary) int {
+		return strings.Compare(b.LastSeen, a.LastSeen)
+	})
+
+	return summaries
+}
+
+// handles get-requests for the recent errors, optionally only the ones seen since
+// "since" ("YYYY-MM-DD HH:MM:SS")
+func getAdminErrors(c *fiber.Ctx) responseMessage {
+	return responseMessage{
+		Data: recentErrors.since(c.Query("since")),
+	}
+}
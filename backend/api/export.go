@@ -0,0 +1,64 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cursor of the last exported state of the elements
+var exportedCursor struct {
+	sync.Mutex
+	cursor string
+}
+
+// writes the client-status to the export-file, if it changed since the last export.
+// The file is replaced atomically, so a static mirror never serves a partial file
+func exportElements(elements ElementsCache) error {
+	pth := config.ConfigYaml.Export.Path
+
+	if pth == "" {
+		return nil
+	}
+
+	exportedCursor.Lock()
+	defer exportedCursor.Unlock()
+
+	if exportedCursor.cursor == elements.Cursor {
+		return nil
+	}
+
+	if tmpFile, err := os.CreateTemp(filepath.Dir(pth), filepath.Base(pth)+".*.tmp"); err != nil {
+		return err
+	} else {
+		defer os.Remove(tmpFile.Name())
+
+		if _, err := tmpFile.Write(elements.JSON); err != nil {
+			tmpFile.Close()
+
+			return err
+		} else if err := tmpFile.Close(); err != nil {
+			return err
+		} else if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+			return err
+		} else if err := os.Rename(tmpFile.Name(), pth); err != nil {
+			return err
+		} else {
+			exportedCursor.cursor = elements.Cursor
+
+			logger.Debug().Msgf("exported elements to %q", pth)
+
+			return nil
+		}
+	}
+}
+
+// rebuilds the elements-cache if it was invalidated, which exports a changed state.
+// Without this, changes would only be exported with the next request of the elements
+func refreshElementsExport() error {
+	if _, found := cachedElements.Get("status"); found {
+		return nil
+	} else {
+		return cacheElements()
+	}
+}
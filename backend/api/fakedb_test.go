@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// answer of the fake database to a statement
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// database answering the statements with a handler of the test, so the handlers can be
+// tested without a running MariaDB. The statements are executed one after another
+type fakeDB struct {
+	mu      sync.Mutex
+	handler func(query string, args []driver.Value) (fakeResult, error)
+	queries []string
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn{f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{f}
+}
+
+// runs a statement with the handler and records it
+func (f *fakeDB) run(query string, args []driver.Value) (fakeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, query)
+
+	return f.handler(query, args)
+}
+
+// records a statement of a transaction, the handler doesn't answer it
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, query)
+}
+
+// counts the executed statements starting with the prefix
+func (f *fakeDB) count(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+
+	for _, query := range f.queries {
+		if strings.HasPrefix(query, prefix) {
+			count++
+		}
+	}
+
+	return count
+}
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{d.db}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.db, query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+// the statements of a transaction are run immediately, a rollback is only recorded
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+
+	return fakeTx{c.db}, nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (t fakeTx) Commit() error {
+	t.db.record("COMMIT")
+
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.db.record("ROLLBACK")
+
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+// the number of arguments isn't checked
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if res, err := s.db.run(s.query, args); err != nil {
+		return nil, err
+	} else {
+		return driver.RowsAffected(res.affected), nil
+	}
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if res, err := s.db.run(s.query, args); err != nil {
+		return nil, err
+	} else {
+		return &fakeRows{columns: res.columns, rows: res.rows}, nil
+	}
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+// answers a select with the rows, ordered by the selected columns. Missing columns are NULL
+func selectResult(query string, rows ...map[string]driver.Value) fakeResult {
+	selected := strings.TrimPrefix(query[:strings.Index(query, " FROM ")], "SELECT ")
+
+	var res fakeResult
+
+	for _, column := range strings.Split(selected, ", ") {
+		// renamed columns are selected as "old AS new"
+		if _, alias, ok := strings.Cut(column, " AS "); ok {
+			column = alias
+		}
+
+		res.columns = append(res.columns, column)
+	}
+
+	for _, row := range rows {
+		values := make([]driver.Value, len(res.columns))
+
+		for ii, column := range res.columns {
+			// the mysql-driver returns the texts as bytes
+			if text, ok := row[column].(string); ok {
+				values[ii] = []byte(text)
+			} else {
+				values[ii] = row[column]
+			}
+		}
+
+		res.rows = append(res.rows, values)
+	}
+
+	return res
+}
+
+// config with the settings the handlers need in the tests
+func testConfig() backendConfig.ConfigStruct {
+	var cfg backendConfig.ConfigStruct
+
+	cfg.Reservation.Expiration = 48 * time.Hour
+	cfg.Cache.Expiration = time.Minute
+	cfg.Cache.Purge = time.Minute
+	cfg.Users.PasswordHasher = lib.BcryptHasher{Cost: bcrypt.MinCost}
+
+	return cfg
+}
+
+// sets up the package with the config and a fake database answering with the handler
+func useFakeDB(tb testing.TB, cfg backendConfig.ConfigStruct, handler func(query string, args []driver.Value) (fakeResult, error)) *fakeDB {
+	tb.Helper()
+
+	fake := &fakeDB{handler: handler}
+	conn := sql.OpenDB(fake)
+
+	tb.Cleanup(func() { conn.Close() })
+
+	config = cfg
+	logger = zerolog.Nop()
+
+	store.Use(conn, cfg, logger)
+
+	dbCache = cache.New(config.Cache.Expiration, config.Cache.Purge)
+	responseCache = cache.New(config.Cache.Responses, config.Cache.Purge)
+
+	return fake
+}
+
+// creates an app serving a single endpoint like the server does. The middleware replaces the
+// one of the server, e.g. to set the logged-in user
+func testApp(method, path string, handler func(*fiber.Ctx) responseMessage, middleware ...fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+
+	chain := append(middleware, func(c *fiber.Ctx) error {
+		return handler(c).send(c)
+	})
+
+	app.Add(method, path, chain...)
+
+	return app
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// features that can be switched on and off per deployment, new ones start disabled
+var featureDefaults = map[string]struct {
+	Description string
+	Enabled     bool
+}{
+	"matchings": {Description: "matching-donations of the employers of sponsors", Enabled: false},
+	"next-free": {Description: "suggestion of the next free element", Enabled: true},
+	"v2-api":    {Description: "reservations with the strict body of the v2-api", Enabled: true},
+}
+
+// features switched at runtime, they take precedence over the config
+var featureOverrides = struct {
+	sync.RWMutex
+	enabled map[string]bool
+}{
+	enabled: map[string]bool{},
+}
+
+// loads the features switched at runtime from the database
+func loadFeatures() error {
+	featureOverrides.Lock()
+	defer featureOverrides.Unlock()
+
+	if _, err := store.LoadSetting("features", &featureOverrides.enabled); err != nil {
+		return err
+	}
+
+	// features removed from the code are left out
+	for name := range featureOverrides.enabled {
+		if !slices.Contains(backendConfig.Features, name) {
+			delete(featureOverrides.enabled, name)
+		}
+	}
+
+	return nil
+}
+
+// returns the state of a feature and where it is set: "runtime", "config" or "default"
+func featureState(name string) (bool, string) {
+	featureOverrides.RLock()
+	defer featureOverrides.RUnlock()
+
+	if enabled, ok := featureOverrides.enabled[name]; ok {
+		return enabled, "runtime"
+	} else if enabled, ok := config.ConfigYaml.Features[name]; ok {
+		return enabled, "config"
+	} else {
+		return featureDefaults[name].Enabled, "default"
+	}
+}
+
+// wether a feature is enabled
+func featureEnabled(name string) bool {
+	enabled, _ := featureState(name)
+
+	return enabled
+}
+
+// wraps the handler of an endpoint, so it only exists while the feature is enabled
+func requireFeature(name string, handler func(*fiber.Ctx) responseMessage) func(*fiber.Ctx) responseMessage {
+	return func(c *fiber.Ctx) responseMessage {
+		if !featureEnabled(name) {
+			logger.Info().Msgf("rejected request to %q: feature %q isn't enabled", c.Path(), name)
+
+			return responseMessage{
+				Status:  fiber.StatusNotFound,
+				Message: "feature %q isn't enabled",
+				Args:    []any{name},
+			}
+		}
+
+		return handler(c)
+	}
+}
+
+// handles get-requests for the features and their states
+func getAdminFeatures(c *fiber.Ctx) responseMessage {
+	features := make([]Feature, len(backendConfig.Features))
+
+	for ii, name := range backendConfig.Features {
+		enabled, source := featureState(name)
+
+		features[ii] = Feature{
+			Name:        name,
+			Description: featureDefaults[name].Description,
+			Enabled:     enabled,
+			Source:      source,
+		}
+	}
+
+	return responseMessage{
+		Data: features,
+	}
+}
+
+// stores the features switched at runtime
+func storeFeatureOverride(name string, enabled *bool) error {
+	featureOverrides.Lock()
+	defer featureOverrides.Unlock()
+
+	overrides := map[string]bool{}
+	for feature, state := range featureOverrides.enabled {
+		overrides[feature] = state
+	}
+
+	if enabled == nil {
+		delete(overrides, name)
+	} else {
+		overrides[name] = *enabled
+	}
+
+	if err := store.StoreSetting("features", overrides); err != nil {
+		return err
+	}
+
+	featureOverrides.enabled = overrides
+
+	return nil
+}
+
+// handles patch-requests switching a feature at runtime
+func patchAdminFeatures(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := FeatureBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ name string; enabled bool }"`)
+	} else if !slices.Contains(backendConfig.Features, body.Name) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "unknown feature %q"
+		response.Args = []any{body.Name}
+
+		logger.Info().Msgf("can't switch feature: unknown feature %q", body.Name)
+	} else if err := storeFeatureOverride(body.Name, &body.Enabled); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store feature %q: %v", body.Name, err)
+	} else {
+		logger.Info().Msgf("%q switched feature %q to %t", getUser(c).Name, body.Name, body.Enabled)
+
+		response = getAdminFeatures(c)
+	}
+
+	return response
+}
+
+// handles delete-requests resetting a feature switched at runtime to the config
+func deleteAdminFeatures(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	name := c.Query("name")
+
+	if !slices.Contains(backendConfig.Features, name) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "unknown feature %q"
+		response.Args = []any{name}
+
+		logger.Info().Msgf("can't reset feature: unknown feature %q", name)
+	} else if err := storeFeatureOverride(name, nil); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't reset feature %q: %v", name, err)
+	} else {
+		logger.Info().Msgf("%q reset feature %q to the config", getUser(c).Name, name)
+
+		response = getAdminFeatures(c)
+	}
+
+	return response
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// atom-feed of the recently confirmed sponsorships
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title   string `xml:"title"`
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary"`
+}
+
+// shortens the name of a sponsor: "full", "first" (name), "initials" (e.g. "Max M.").
+// Other modes and empty names result in an empty string
+func displayName(name, mode string) string {
+	words := strings.Fields(name)
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	switch mode {
+	case "full":
+		return strings.Join(words, " ")
+	case "first":
+		return words[0]
+	case "initials":
+		// the first name with the initials of the others
+		for ii := 1; ii < len(words); ii++ {
+			words[ii] = string([]rune(words[ii])[0]) + "."
+		}
+
+		return strings.Join(words, " ")
+	default:
+		return ""
+	}
+}
+
+// shortens the name of a sponsor as configured in "feed.names"
+func feedName(name string) string {
+	if shown := displayName(name, config.ConfigYaml.Feed.Names); shown != "" {
+		return shown
+	} else {
+		return config.ConfigYaml.Feed.Anonymous
+	}
+}
+
+// shortens the name of a sponsor as configured in "public_names"
+func publicName(name string) string {
+	if config.ConfigYaml.PublicNames == "" {
+		return name
+	} else {
+		return displayName(name, config.ConfigYaml.PublicNames)
+	}
+}
+
+// handles get-requests for the atom-feed of the recently confirmed sponsorships
+func getFeed(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if !config.ConfigYaml.Feed.Enabled {
+		response.Status = fiber.StatusNotFound
+		response.Message = "feed isn't enabled"
+
+		logger.Info().Msg("can't send feed: it isn't enabled")
+	} else if elements, err := store.Select[ElementDBNoReservation]("elements", "reservation IS NULL AND confirmed IS NOT NULL ORDER BY confirmed DESC"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get sponsorships from database: %v", err)
+	} else {
+		self := c.BaseURL() + c.Path()
+		now := time.Now()
+
+		feed := atomFeed{
+			Title:   config.ConfigYaml.Feed.Title,
+			ID:      self,
+			Link:    atomLink{Href: self, Rel: "self"},
+			Updated: now.Format(time.RFC3339),
+			Entries: []atomEntry{},
+		}
+
+		for _, element := range elements {
+			if len(feed.Entries) >= config.ConfigYaml.Feed.Entries {
+				break
+			}
+
+			// hidden elements and gifts before their delivery aren't public
+			if isHidden(element.Mid) {
+				continue
+			} else if giftUndelivered(element.Giftdelivery, now) {
+				continue
+			}
+
+			confirmed, err := time.ParseInLocation(time.DateTime, *element.Confirmed, time.Local)
+			if err != nil {
+				logger.Warn().Msgf("can't parse confirmation-date of %q: %v", element.Mid, err)
+
+				continue
+			}
+
+			// the feed was last updated with its newest entry
+			if len(feed.Entries) == 0 {
+				feed.Updated = confirmed.Format(time.RFC3339)
+			}
+
+			feed.Entries = append(feed.Entries, atomEntry{
+				Title:   feedName(moderatedName(element.Name, element.Approvedname)),
+				ID:      fmt.Sprintf("%s#%s-%d", self, element.Mid, confirmed.Unix()),
+				Updated: confirmed.Format(time.RFC3339),
+				Summary: element.Mid,
+			})
+		}
+
+		if body, err := xml.MarshalIndent(feed, "", "\t"); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't encode feed: %v", err)
+		} else {
+			c.Set(fiber.HeaderContentType, "application/atom+xml; charset=utf-8")
+			c.SendString(xml.Header + string(body))
+
+			response.Status = fiber.StatusOK
+		}
+	}
+
+	return response
+}
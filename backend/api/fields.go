@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// default maximum length of text-fields
+const defaultFieldLength = 255
+
+// validates a single value of a custom field
+func validateField(field backendConfig.CustomField, value any) error {
+	switch field.Type {
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return messageErrorf("field %q must be a boolean", field.Name)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return messageErrorf("field %q must be a number", field.Name)
+		}
+	case "select":
+		if s, ok := value.(string); !ok || !slices.Contains(field.Options, s) {
+			return messageErrorf("field %q must be one of %v", field.Name, field.Options)
+		}
+	default:
+		maxLength := field.MaxLength
+		if maxLength <= 0 {
+			maxLength = defaultFieldLength
+		}
+
+		if s, ok := value.(string); !ok {
+			return messageErrorf("field %q must be a text", field.Name)
+		} else if len([]rune(s)) > maxLength {
+			return messageErrorf("field %q is longer than %d characters", field.Name, maxLength)
+		}
+	}
+
+	return nil
+}
+
+// validates the custom fields of a reservation against the configured ones
+//
+// @returns the fields encoded as JSON for the database
+func validateFields(values map[string]any) (json.RawMessage, error) {
+	fields := map[string]backendConfig.CustomField{}
+
+	for _, field := range config.CustomFields {
+		fields[field.Name] = field
+
+		if value, ok := values[field.Name]; !ok || value == nil || value == "" {
+			if field.Required {
+				return nil, messageErrorf("field %q is required", field.Name)
+			}
+
+			delete(values, field.Name)
+		} else if err := validateField(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range values {
+		if _, ok := fields[name]; !ok {
+			return nil, messageErrorf("unknown field %q", name)
+		}
+	}
+
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	return json.Marshal(values)
+}
+
+// default maximum length of the dedications
+const defaultDedicationLength = 200
+
+// validates the dedication of a reservation, empty ones are always valid
+func validateDedication(dedication string) error {
+	if dedication == "" {
+		return nil
+	} else if !slices.Contains(config.ConfigYaml.Certificates.Fields, "dedication") {
+		return messageErrorf("dedications aren't enabled")
+	}
+
+	maxLength := config.ConfigYaml.Certificates.Dedication.MaxLength
+	if maxLength <= 0 {
+		maxLength = defaultDedicationLength
+	}
+
+	if len([]rune(dedication)) > maxLength {
+		return messageErrorf("dedication is longer than %d characters", maxLength)
+	} else if strings.ContainsFunc(dedication, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) {
+		return messageErrorf("dedication contains invalid characters")
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(dedication), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+
+	for _, blocked := range config.ConfigYaml.Certificates.Dedication.BlockedWords {
+		if slices.Contains(words, strings.ToLower(blocked)) {
+			return messageErrorf("dedication contains the blocked word %q", blocked)
+		}
+	}
+
+	return nil
+}
+
+// returns the texts of a reservation that can be printed on its certificate: the dedication
+// and the values of the custom text-fields
+func elementTexts(element ElementDB) map[string]string {
+	texts := map[string]string{}
+
+	if element.Dedication != nil {
+		texts["dedication"] = *element.Dedication
+	}
+
+	values := map[string]any{}
+
+	if len(element.Fields) > 0 {
+		if err := json.Unmarshal(element.Fields, &values); err != nil {
+			logger.Error().Msgf("can't parse fields of %q: %v", element.Mid, err)
+		}
+	}
+
+	for name, value := range values {
+		if text, ok := value.(string); ok {
+			texts[name] = text
+		}
+	}
+
+	return texts
+}
+
+// returns the texts of the reservation of an element for its certificate, empty if it can't be read
+func reservationTexts(mid string) map[string]string {
+	if elements, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+		logger.Error().Msgf("can't get texts of %q from database: %v", mid, err)
+	} else if len(elements) == 1 {
+		return elementTexts(elements[0])
+	}
+
+	return map[string]string{}
+}
+
+// handles get-requests for the custom fields of the reservation-form
+func getFields(c *fiber.Ctx) responseMessage {
+	fields := config.CustomFields
+
+	if fields == nil {
+		fields = []backendConfig.CustomField{}
+	}
+
+	return responseMessage{
+		Data: fields,
+	}
+}
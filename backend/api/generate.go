@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum number of elements of a range generated by a single request
+const maxGeneratedElements = 1000
+
+// handles post-requests generating the elements of a range, e.g. after extending the valid
+// elements in the config. Rows only exist for reserved and sponsored elements, free ones are
+// derived from the config, so nothing is written. Instead, the elements of the range are
+// reported as existing, free or conflicting with the config, which has to cover them first
+func postElementsGenerate(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := GenerateElementsBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ prefix string; from int; to int }"`)
+	} else if body.Prefix == "" || body.From < 0 || body.To < body.From || body.To-body.From >= maxGeneratedElements {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid range"
+
+		logger.Info().Msgf("can't generate elements: invalid range %q from %d to %d", body.Prefix, body.From, body.To)
+	} else if elements, err := store.Select[ElementDB]("elements", "*"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else {
+		// elements split into shares exist with any of their shares
+		existing := make(map[string]bool)
+
+		for _, element := range elements {
+			mid, _ := certs.SplitShare(element.Mid)
+
+			existing[mid] = true
+		}
+
+		generated := GeneratedElements{
+			Existing:  []string{},
+			Free:      []string{},
+			Conflicts: []string{},
+		}
+
+		digits := config.ValidateElements.ValidElements[body.Prefix].Digits
+
+		for n := body.From; n <= body.To; n++ {
+			mid := backendConfig.FormatMid(body.Prefix, n, digits)
+
+			switch {
+			case !isValidElement(mid):
+				generated.Conflicts = append(generated.Conflicts, mid)
+			case existing[mid]:
+				generated.Existing = append(generated.Existing, mid)
+			default:
+				generated.Free = append(generated.Free, mid)
+			}
+		}
+
+		logger.Info().Msgf("generated elements %q from %d to %d: %d existing, %d free, %d conflicting", body.Prefix, body.From, body.To, len(generated.Existing), len(generated.Free), len(generated.Conflicts))
+
+		response.Data = generated
+	}
+
+	return response
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"gopkg.in/yaml.v3"
+)
+
+// sends a post-request generating the elements of a range
+func postGenerate(t *testing.T, body string) (int, GeneratedElements) {
+	t.Helper()
+
+	app := testApp(fiber.MethodPost, "/api/elements/generate", postElementsGenerate)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/api/elements/generate", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	var generated GeneratedElements
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	} else if res.StatusCode == fiber.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(&generated); err != nil {
+			t.Fatalf("can't decode response: %v", err)
+		}
+	}
+
+	return res.StatusCode, generated
+}
+
+// the elements of a range are reported by their state, nothing is written
+func TestPostElementsGenerate(t *testing.T) {
+	cfg := testConfig()
+
+	if mids, err := backendConfig.NewMidScheme(`^(pv-\w|(?:wr|bs)-)(\d{1,2})$`); err != nil {
+		t.Fatalf("can't parse mid-scheme: %v", err)
+	} else {
+		cfg.Mids = mids
+	}
+
+	if err := yaml.Unmarshal([]byte(`
+valid_elements:
+  pv-a: {from: 1, to: 10}
+  bs-: {from: 1, to: 2, shares: 4}
+`), &cfg.ValidateElements); err != nil {
+		t.Fatalf("can't parse valid elements: %v", err)
+	}
+
+	// "pv-a12" is left over from a range that was shortened
+	elements := []map[string]driver.Value{
+		{"mid": "pv-a9", "name": "Sponsor", "pending": false, "fields": "{}"},
+		{"mid": "pv-a12", "name": "Sponsor", "pending": false, "fields": "{}"},
+		{"mid": "bs-1_3", "name": "Sponsor", "pending": false, "fields": "{}"},
+	}
+
+	fake := useFakeDB(t, cfg, func(query string, args []driver.Value) (fakeResult, error) {
+		if strings.HasPrefix(query, "SELECT ") && strings.HasSuffix(query, " FROM elements") {
+			return selectResult(query, elements...), nil
+		} else {
+			return fakeResult{}, fmt.Errorf("unexpected statement: %s", query)
+		}
+	})
+
+	for _, tc := range []struct {
+		body     string
+		expected GeneratedElements
+	}{
+		{
+			body: `{"prefix": "pv-a", "from": 8, "to": 12}`,
+			expected: GeneratedElements{
+				Existing:  []string{"pv-a9"},
+				Free:      []string{"pv-a8", "pv-a10"},
+				Conflicts: []string{"pv-a11", "pv-a12"},
+			},
+		},
+		{
+			body: `{"prefix": "bs-", "from": 1, "to": 2}`,
+			expected: GeneratedElements{
+				Existing:  []string{"bs-1"},
+				Free:      []string{"bs-2"},
+				Conflicts: []string{},
+			},
+		},
+	} {
+		if status, generated := postGenerate(t, tc.body); status != fiber.StatusOK {
+			t.Errorf("status for %s is %d, expected %d", tc.body, status, fiber.StatusOK)
+		} else if !reflect.DeepEqual(generated, tc.expected) {
+			t.Errorf("generated %+v for %s, expected %+v", generated, tc.body, tc.expected)
+		}
+	}
+
+	if status, _ := postGenerate(t, `{"prefix": "pv-a", "from": 12, "to": 8}`); status != fiber.StatusBadRequest {
+		t.Errorf("status for an inverted range is %d, expected %d", status, fiber.StatusBadRequest)
+	}
+
+	if count := fake.count("SELECT "); count != len(fake.queries) {
+		t.Errorf("%d statements other than selects were executed", len(fake.queries)-count)
+	}
+}
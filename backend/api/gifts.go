@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// gift-data of a reservation as stored in the database
+type giftData struct {
+	// name of the element: the recipient for gifts, otherwise the sponsor
+	Name         string
+	Buyer        *string
+	Giftmail     *string
+	Giftdelivery *string
+}
+
+// confirmed gift whose mail to the recipient is due
+type GiftDB struct {
+	Mid          string
+	Name         string
+	Buyer        *string
+	Giftmail     *string
+	Giftdelivery *string
+}
+
+// wether a gift is delivered later, its recipient isn't public until then
+func giftUndelivered(delivery *string, now time.Time) bool {
+	return delivery != nil && *delivery > now.Format(time.DateTime)
+}
+
+// data available in the gift-templates
+type GiftTemplateData struct {
+	certs.SponsorshipTemplateData
+	Buyer string
+}
+
+// extracts the gift-data from a reservation-body
+func parseGift(body ReservationBody) (giftData, error) {
+	data := giftData{
+		Name: body.Name,
+	}
+
+	if body.Gift == nil {
+		return data, nil
+	}
+
+	if strings.TrimSpace(body.Gift.Recipient) == "" {
+		return data, messageErrorf("gift doesn't include a recipient")
+	}
+
+	data.Name = body.Gift.Recipient
+	data.Buyer = &body.Name
+
+	if body.Gift.Mail != "" {
+		data.Giftmail = &body.Gift.Mail
+	}
+
+	// the delivery is either a date or a date with time
+	if body.Gift.Delivery != "" {
+		if delivery, err := time.ParseInLocation(time.DateOnly, body.Gift.Delivery, time.Local); err == nil {
+			data.Giftdelivery = lib.Ptr(delivery.Format(time.DateTime))
+		} else if delivery, err := time.ParseInLocation(time.DateTime, body.Gift.Delivery, time.Local); err == nil {
+			data.Giftdelivery = lib.Ptr(delivery.Format(time.DateTime))
+		} else {
+			return data, messageErrorf("invalid delivery-date %q", body.Gift.Delivery)
+		}
+	}
+
+	return data, nil
+}
+
+// sends the certificate of a gift to its recipient
+func sendGiftEmail(gift GiftDB) error {
+	certData := certs.CertificateData{
+		Reservation: certs.ReservationData{
+			Mid:   gift.Mid,
+			Name:  gift.Name,
+			Mail:  *gift.Giftmail,
+			Texts: reservationTexts(gift.Mid),
+		},
+		Templates: certificateTemplates(gift.Mid),
+		Shares:    elementShares(gift.Mid),
+	}
+
+	defer certData.Cleanup()
+
+	templateData := GiftTemplateData{}
+
+	if gift.Buyer != nil {
+		templateData.Buyer = *gift.Buyer
+	}
+
+	if err := issueLinkedCertificate(&certData, certificateDownloadBase(nil)); err != nil {
+		return err
+	} else if err := certData.Create(); err != nil {
+		return err
+	} else {
+		templateData.SponsorshipTemplateData = certData.TemplateData
+
+		return mailer.SendTemplate(*gift.Giftmail, "gift_mail", templateData, certData.PDFFile)
+	}
+}
+
+// sends the mails of the confirmed gifts whose delivery-date is reached
+func sendDueGiftEmails() error {
+	now := time.Now().Format(time.DateTime)
+
+	if gifts, err := store.Select[GiftDB]("elements", "confirmed IS NOT NULL AND giftmail IS NOT NULL AND (giftdelivery IS NULL OR giftdelivery <= ?)", now); err != nil {
+		return err
+	} else {
+		for _, gift := range gifts {
+			if err := sendGiftEmail(gift); err != nil {
+				logger.Error().Msgf("can't send gift-mail for %q: %v", gift.Mid, err)
+
+				notify(roleAdmin, notificationMailFailed, gift.Mid, fmt.Sprintf("gift-mail for %q couldn't be sent", gift.Mid))
+
+				continue
+			}
+
+			// the mail-address of the recipient isn't needed anymore
+			if err := store.Update("elements", struct{ Giftmail *string }{}, struct{ Mid string }{Mid: gift.Mid}); err != nil {
+				logger.Error().Msgf("can't mark gift-mail for %q as sent: %v", gift.Mid, err)
+			} else {
+				logger.Info().Msgf("sent gift-mail for %q", gift.Mid)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// checks that the sponsor accepted the current versions of all legal documents
+func validateLegal(accepted map[string]string) error {
+	for _, document := range config.Legal {
+		if version, ok := accepted[document.Kind]; !ok {
+			return messageErrorf("consent to %q is required", document.Kind)
+		} else if version != document.Version {
+			return messageErrorf("consent to an outdated version of %q", document.Kind)
+		}
+	}
+
+	return nil
+}
+
+// stores the consents of a reservation to the current legal documents
+func storeConsents(mail, mid, ip string) error {
+	now := time.Now().Format(time.DateTime)
+
+	for _, document := range config.Legal {
+		if err := store.Insert("consents", ConsentDB{
+			Mail:     mail,
+			Mid:      mid,
+			Kind:     document.Kind,
+			Version:  document.Version,
+			Accepted: now,
+			Ip:       ip,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handles get-requests for the current versions of the legal documents
+func getLegal(c *fiber.Ctx) responseMessage {
+	documents := config.Legal
+
+	if documents == nil {
+		documents = []backendConfig.LegalDocument{}
+	}
+
+	return responseMessage{
+		Data: documents,
+	}
+}
+
+// handles get-requests for the stored consents of a mail-address
+func getConsents(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if mail := c.Query("mail"); mail == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include mail"
+
+		logger.Info().Msg("query doesn't include mail")
+	} else if sealed, err := store.Seal(mail); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't encrypt mail-address %q: %v", mail, err)
+	} else if consents, err := store.Select[ConsentDB]("consents", "mail = ? ORDER BY accepted", sealed); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get consents from database: %v", err)
+	} else {
+		response.Data = consents
+	}
+
+	return response
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// handles get-requests for the paths of the current and the rotated logfiles
+func getAdminLogs(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if backups, err := config.LogBackups(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't list the rotated logfiles: %v", err)
+	} else {
+		response.Data = LogFiles{
+			Current: config.LogFile,
+			Backups: backups,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// time a lookup of the mx-records may take, slower dns-servers don't block the reservation
+const mxLookupTimeout = 3 * time.Second
+
+// domains of the disposable mail-providers, loaded from "mail_check.blocklist" and "mail_check.blocked_domains"
+var disposableDomains = struct {
+	sync.RWMutex
+	domains map[string]bool
+}{}
+
+// loads the blocklist of the disposable mail-domains, one domain per line. Empty lines
+// and lines starting with "#" are skipped
+func loadDisposableDomains() error {
+	domains := make(map[string]bool)
+
+	for _, domain := range config.ConfigYaml.MailCheck.BlockedDomains {
+		domains[strings.ToLower(domain)] = true
+	}
+
+	if path := config.ConfigYaml.MailCheck.Blocklist; path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+
+		for scanner.Scan() {
+			if line := strings.ToLower(strings.TrimSpace(scanner.Text())); line != "" && !strings.HasPrefix(line, "#") {
+				domains[line] = true
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	disposableDomains.Lock()
+	disposableDomains.domains = domains
+	disposableDomains.Unlock()
+
+	logger.Debug().Msgf("loaded %d disposable mail-domains", len(domains))
+
+	return nil
+}
+
+// wether a domain or one of its parents is on the blocklist
+func isDisposableDomain(domain string) bool {
+	disposableDomains.RLock()
+	defer disposableDomains.RUnlock()
+
+	for {
+		if disposableDomains.domains[domain] {
+			return true
+		} else if _, parent, found := strings.Cut(domain, "."); !found {
+			return false
+		} else {
+			domain = parent
+		}
+	}
+}
+
+// wether a domain has mx-records. Failed lookups other than a non-existent domain
+// count as success, so an unreachable dns-server doesn't block the reservations
+func hasMXRecords(domain string) bool {
+	if result, found := cachedMX.Get(domain); found {
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mxLookupTimeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+
+	var dnsErr *net.DNSError
+
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		logger.Warn().Msgf("can't look up mx-records of %q: %v", domain, err)
+
+		return true
+	}
+
+	// a null-mx (".") declares, that the domain doesn't accept mails
+	result := len(records) > 0 && !(len(records) == 1 && records[0].Host == ".")
+
+	cachedMX.Set(domain, result)
+
+	return result
+}
+
+// checks the domain of a mail-address against the blocklist and optionally for mx-records
+func checkMailDomain(address string) error {
+	_, domain, _ := strings.Cut(address, "@")
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+
+	if domain == "" {
+		return messageErrorf("invalid mail-address %q", address)
+	} else if isDisposableDomain(domain) {
+		return messageErrorf("disposable mail-addresses aren't accepted, domain %q", domain)
+	} else if config.ConfigYaml.MailCheck.MX && !hasMXRecords(domain) {
+		return messageErrorf("mail-domain %q can't receive mails", domain)
+	} else {
+		return nil
+	}
+}
+
+// common domains of mail-providers, the typos of the mail-domains are corrected to
+var commonMailDomains = []string{
+	"gmail.com", "googlemail.com", "gmx.de", "gmx.net", "web.de", "t-online.de", "yahoo.com", "yahoo.de",
+	"hotmail.com", "hotmail.de", "outlook.com", "outlook.de", "live.com", "live.de", "icloud.com", "me.com",
+	"aol.com", "freenet.de", "posteo.de", "mailbox.org", "arcor.de", "protonmail.com", "proton.me", "mail.com",
+	"mail.de",
+}
+
+// top-level-domains accepted as they are, even if they differ from the one of a known domain
+// (e.g. "gmx.at" besides "gmx.de")
+var validMailTLDs = []string{
+	"com", "net", "org", "de", "at", "ch", "li", "lu", "eu", "fr", "it", "nl", "be", "es", "uk", "us",
+	"dk", "se", "no", "pl", "cz", "info", "biz", "io", "me", "email", "online",
+}
+
+// returns a correction of a probable typo in the domain of a mail-address, empty if the domain
+// looks correct
+func suggestMailDomain(address string) string {
+	local, domain, found := strings.Cut(address, "@")
+	domain = strings.ToLower(domain)
+
+	if !found || domain == "" {
+		return ""
+	}
+
+	known := slices.Concat(commonMailDomains, config.ConfigYaml.MailCheck.KnownDomains)
+	if slices.Contains(known, domain) {
+		return ""
+	}
+
+	split := func(domain string) (string, string) {
+		if ii := strings.LastIndex(domain, "."); ii < 0 {
+			return domain, ""
+		} else {
+			return domain[:ii], domain[ii+1:]
+		}
+	}
+
+	label, tld := split(domain)
+
+	suggestion := ""
+	best := 0
+
+	for _, candidate := range known {
+		candidateLabel, candidateTLD := split(candidate)
+
+		// a valid top-level-domain is kept, other typos are corrected
+		if tld != candidateTLD && slices.Contains(validMailTLDs, tld) {
+			continue
+		}
+
+		// short names tolerate fewer typos, so different providers aren't mistaken for each other
+		maxDistance := 2
+		if len(candidateLabel) < 5 {
+			maxDistance = 1
+		}
+
+		labelDistance := editDistance(label, candidateLabel)
+		distance := labelDistance + min(editDistance(tld, candidateTLD), 1)
+
+		if labelDistance <= maxDistance && (suggestion == "" || distance < best) {
+			suggestion, best = candidate, distance
+		}
+	}
+
+	if suggestion == "" {
+		return ""
+	}
+
+	return local + "@" + suggestion
+}
+
+// handles get-requests for a suggested correction of a mail-address, e.g. while it is entered
+func getMailSuggestion(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if address := c.Query("mail"); address == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include mail"
+
+		logger.Info().Msg("can't suggest mail-address: query doesn't include mail")
+	} else {
+		response.Data = MailSuggestion{
+			Mail:       address,
+			Suggestion: suggestMailDomain(address),
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum number of queued mails sent per run of the queue
+const mailQueueBatch = 50
+
+// mail in the queue in the database
+type MailQueueDB struct {
+	Qid       int
+	Mid       string
+	Recipient string
+	Subject   string
+	Html      string
+	Plain     string
+	Queued    string
+	Attempts  int
+	Lasterror *string
+}
+
+// wether the mail-server failed. While degraded, mails are queued right away
+// instead of waiting for the connection-timeout with every request
+var mailDegraded atomic.Bool
+
+// sends a mail or queues it, if the mail-server isn't available. The first failure
+// switches to the degraded mode and alerts the admins
+func sendOrQueueMail(mid string, msg mailer.Message) (bool, error) {
+	if !mailDegraded.Load() {
+		if err := msg.Send(); err == nil {
+			return false, nil
+		} else if errors.Is(err, mailer.ErrThrottled) {
+			// the mail-server is fine, the mail is only sent later
+			logger.Warn().Msgf("can't send mail to %q, queueing it: %v", msg.To, err)
+		} else {
+			logger.Warn().Msgf("can't send mail to %q, queueing it: %v", msg.To, err)
+
+			if !mailDegraded.Swap(true) {
+				notify(roleAdmin, notificationMailFailed, "", "mail-server isn't available, mails are queued until it is back")
+			}
+		}
+	}
+
+	// the recipient and the texts are encrypted by the store
+	if err := store.Insert("mailqueue", struct {
+		Mid       string
+		Recipient string
+		Subject   string
+		Html      string
+		Plain     string
+		Queued    string
+	}{
+		Mid: mid, Recipient: msg.To, Subject: msg.Subject, Html: msg.HTML, Plain: msg.Plain, Queued: time.Now().Format(time.DateTime),
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// sends the queued mails, oldest first. The run stops at the first failure, as the
+// mail-server is probably still unavailable, or when the hourly limit of mails is reached
+func sendQueuedMails() error {
+	if queued, err := store.Select[MailQueueDB]("mailqueue", "qid > 0 ORDER BY qid LIMIT ?", mailQueueBatch); err != nil {
+		return err
+	} else {
+		for _, mail := range queued {
+			msg := mailer.Message{
+				To:      mail.Recipient,
+				Subject: mail.Subject,
+				HTML:    mail.Html,
+				Plain:   mail.Plain,
+			}
+
+			if err := msg.Send(); errors.Is(err, mailer.ErrThrottled) {
+				// the remaining mails are sent in a later run
+				return nil
+			} else if err != nil {
+				mailDegraded.Store(true)
+
+				if _, err := store.Exec("UPDATE mailqueue SET attempts = attempts + 1, lasterror = ? WHERE qid = ?", err.Error(), mail.Qid); err != nil {
+					return err
+				}
+
+				return err
+			} else if err := store.Delete("mailqueue", struct{ Qid int }{Qid: mail.Qid}); err != nil {
+				return err
+			}
+		}
+
+		if mailDegraded.Swap(false) {
+			logger.Info().Msgf("mail-server is available again, sent %d queued mails", len(queued))
+		}
+
+		return nil
+	}
+}
+
+// returns the elements with queued mails
+func mailPendingMids() (map[string]bool, error) {
+	if queued, err := store.Select[struct{ Mid string }]("mailqueue", "mid != ''"); err != nil {
+		return nil, err
+	} else {
+		mids := map[string]bool{}
+
+		for _, mail := range queued {
+			mids[mail.Mid] = true
+		}
+
+		return mids, nil
+	}
+}
+
+// handles get-requests for the queued mails
+func getAdminMails(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if queued, err := store.Select[QueuedMail]("mailqueue", "qid > 0 ORDER BY qid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get queued mails from database: %v", err)
+	} else {
+		response.Data = queued
+	}
+
+	return response
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// current state of the maintenance-mode
+var maintenance = struct {
+	sync.RWMutex
+	Maintenance
+}{}
+
+// loads the maintenance-mode from the database
+func loadMaintenance() error {
+	maintenance.Lock()
+	defer maintenance.Unlock()
+
+	if _, err := store.LoadSetting("maintenance", &maintenance.Maintenance); err != nil {
+		return err
+	}
+
+	if maintenance.Enabled {
+		logger.Warn().Msgf("maintenance-mode is enabled: %q", maintenance.Message)
+	}
+
+	return nil
+}
+
+// middleware rejecting write-requests while the maintenance-mode is enabled
+func RejectDuringMaintenance(c *fiber.Ctx) error {
+	if c.Method() == fiber.MethodGet {
+		return c.Next()
+	}
+
+	maintenance.RLock()
+	enabled, message := maintenance.Enabled, maintenance.Message
+	maintenance.RUnlock()
+
+	if enabled {
+		logger.Info().Msgf("rejected %s request to %q during maintenance", c.Method(), c.Path())
+
+		return responseMessage{
+			Status:  fiber.StatusServiceUnavailable,
+			Message: message,
+		}.send(c)
+	} else {
+		return c.Next()
+	}
+}
+
+// handles get-requests for the maintenance-mode
+func getMaintenance(c *fiber.Ctx) responseMessage {
+	maintenance.RLock()
+	defer maintenance.RUnlock()
+
+	return responseMessage{
+		Data: maintenance.Maintenance,
+	}
+}
+
+// handles post-requests to enable or disable the maintenance-mode
+func postMaintenance(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := Maintenance{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ enabled bool; message string }"`)
+	} else {
+		if body.Message == "" {
+			body.Message = config.Maintenance.Message
+		}
+
+		if err := store.StoreSetting("maintenance", body); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't store maintenance-mode"
+
+			logger.Error().Msgf("can't store maintenance-mode: %v", err)
+		} else {
+			maintenance.Lock()
+			maintenance.Maintenance = body
+			maintenance.Unlock()
+
+			logger.Info().Msgf("set maintenance-mode to %v: %q", body.Enabled, body.Message)
+
+			response = getMaintenance(c)
+		}
+	}
+
+	return response
+}
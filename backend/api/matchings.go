@@ -0,0 +1,200 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// employer doubling the donation of a reservation, as stored with the element
+type matchingData struct {
+	Employer  *string
+	Reference *string
+}
+
+// extracts the matching-data from a reservation-body
+func parseMatching(body ReservationBody) (matchingData, error) {
+	data := matchingData{}
+
+	if body.Matching == nil {
+		return data, nil
+	} else if !featureEnabled("matchings") {
+		return data, messageErrorf("feature %q isn't enabled", "matchings")
+	}
+
+	employer := strings.TrimSpace(body.Matching.Employer)
+
+	if employer == "" {
+		return data, messageErrorf("matching doesn't include an employer")
+	} else if utf8.RuneCountInString(employer) > maxReservationName {
+		return data, messageErrorf("employer is longer than %d characters", maxReservationName)
+	} else if utf8.RuneCountInString(body.Matching.Reference) > maxReservationSource {
+		return data, messageErrorf("reference is longer than %d characters", maxReservationSource)
+	}
+
+	data.Employer = &employer
+
+	if reference := strings.TrimSpace(body.Matching.Reference); reference != "" {
+		data.Reference = &reference
+	}
+
+	return data, nil
+}
+
+// creates the pending matching-donation of a confirmed sponsorship, if its sponsor flagged one
+func createMatching(element ElementDB) error {
+	if element.Matchingemployer == nil {
+		return nil
+	}
+
+	if err := store.Insert("matchings", struct {
+		Mid       string
+		Employer  string
+		Reference *string
+		Amount    float64
+	}{
+		Mid: element.Mid, Employer: *element.Matchingemployer, Reference: element.Matchingreference, Amount: elementPrice(element.Mid),
+	}); err != nil {
+		return err
+	}
+
+	notify(roleAdmin, notificationMatching, element.Mid, fmt.Sprintf("matching-donation of %q for %q awaits the payment", *element.Matchingemployer, element.Mid))
+
+	return nil
+}
+
+// handles get-requests for the matching-donations, optionally only the "pending" or "confirmed" ones ("state" in the query)
+func getMatchings(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	where := "maid > 0"
+
+	switch state := c.Query("state"); state {
+	case "":
+	case "pending":
+		where = "confirmed IS NULL"
+	case "confirmed":
+		where = "confirmed IS NOT NULL"
+	default:
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid state %q"
+		response.Args = []any{state}
+
+		logger.Info().Msgf("can't get matchings: invalid state %q", state)
+
+		return response
+	}
+
+	if matchings, err := store.Select[Matching]("matchings", where+" ORDER BY created, maid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get matchings from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+
+		response.Data = slices.DeleteFunc(matchings, func(matching Matching) bool { return !inPlant(matching.Mid) })
+	}
+
+	return response
+}
+
+// reads a pending matching-donation, the response is only set if it doesn't exist
+func selectPendingMatching(maid int) (Matching, responseMessage) {
+	var response responseMessage
+
+	if matchings, err := store.Select[Matching]("matchings", "maid = ?", maid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get matching %d from database: %v", maid, err)
+	} else if len(matchings) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "matching doesn't exist"
+
+		logger.Info().Msgf("matching %d doesn't exist", maid)
+	} else if matchings[0].Confirmed != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "matching is already confirmed"
+
+		logger.Info().Msgf("matching %d is already confirmed", maid)
+	} else {
+		return matchings[0], response
+	}
+
+	return Matching{}, response
+}
+
+// handles post-requests confirming the payment of a matching-donation by the employer.
+// The amount defaults to the expected one
+func postMatchingsConfirm(c *fiber.Ctx) responseMessage {
+	body := MatchingConfirmBody{}
+
+	maid := c.QueryInt("maid")
+
+	// the body is optional
+	if err := c.BodyParser(&body); err != nil && len(c.Body()) > 0 {
+		logger.Warn().Msg(`body can't be parsed as "struct{ amount *float64 }"`)
+
+		return responseMessage{
+			Status:  fiber.StatusBadRequest,
+			Message: "invalid message-body",
+		}
+	}
+
+	matching, response := selectPendingMatching(maid)
+
+	if response.Status != 0 {
+		return response
+	}
+
+	if body.Amount != nil {
+		matching.Amount = *body.Amount
+	}
+
+	if matching.Amount < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "amount can't be negative"
+	} else if err := store.Update("matchings", struct {
+		Amount    float64
+		Confirmed string
+		Uid       int
+	}{
+		Amount: matching.Amount, Confirmed: time.Now().Format(time.DateTime), Uid: getUser(c).Uid,
+	}, struct{ Maid int }{Maid: maid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't confirm matching %d: %v", maid, err)
+	} else {
+		logger.Info().Msgf("%q confirmed matching %d of %q over %s", getUser(c).Name, maid, matching.Mid, lib.FormatCurrency(matching.Amount))
+
+		response = getMatchings(c)
+	}
+
+	return response
+}
+
+// handles delete-requests removing a pending matching-donation, e.g. if the employer doesn't pay
+func deleteMatchings(c *fiber.Ctx) responseMessage {
+	maid := c.QueryInt("maid")
+
+	matching, response := selectPendingMatching(maid)
+
+	if response.Status != 0 {
+		return response
+	} else if _, err := store.Exec("DELETE FROM matchings WHERE maid = ? AND confirmed IS NULL", maid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't delete matching %d: %v", maid, err)
+	} else {
+		logger.Info().Msgf("%q deleted matching %d of %q", getUser(c).Name, maid, matching.Mid)
+
+		response = getMatchings(c)
+	}
+
+	return response
+}
@@ -0,0 +1,435 @@
+package api
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum edit-distance of two normalized names to be considered similar
+const maxNameDistance = 2
+
+// minimum length of a normalized name to be compared by its edit-distance
+const minNameLength = 6
+
+// merge of sponsors in the database
+type MergeDB struct {
+	Mgid      int
+	Canonical string
+	Merged    string
+	Uid       int
+	Undone    *string
+}
+
+// contact of an element before a merge in the database
+type MergedContactDB struct {
+	Mgid int
+	Mid  string
+	Name string
+	Mail *string
+}
+
+// normalizes a name for the comparison: lower-case letters and digits of the
+// words in alphabetical order
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	slices.Sort(words)
+
+	return strings.Join(words, " ")
+}
+
+// returns the levenshtein-distance of two strings
+func editDistance(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for jj := range previous {
+		previous[jj] = jj
+	}
+
+	for ii := 1; ii <= len(ra); ii++ {
+		current[0] = ii
+
+		for jj := 1; jj <= len(rb); jj++ {
+			cost := 1
+			if ra[ii-1] == rb[jj-1] {
+				cost = 0
+			}
+
+			current[jj] = min(previous[jj]+1, current[jj-1]+1, previous[jj-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// wether two names probably belong to the same person
+func similarNames(a, b string) bool {
+	a = normalizeName(a)
+	b = normalizeName(b)
+
+	if a == "" || b == "" {
+		return false
+	} else if a == b {
+		return true
+	} else {
+		return len(a) >= minNameLength && len(b) >= minNameLength && editDistance(a, b) <= maxNameDistance
+	}
+}
+
+// normalizes a mail-address for the comparison
+func normalizeMail(mail *string) string {
+	if mail == nil {
+		return ""
+	} else {
+		return strings.ToLower(strings.TrimSpace(*mail))
+	}
+}
+
+// groups the elements whose contacts differ but probably belong to the same sponsor
+func findDuplicates(elements []ElementDB) []DuplicateGroup {
+	// union-find over the elements
+	parents := make([]int, len(elements))
+	for ii := range parents {
+		parents[ii] = ii
+	}
+
+	var find func(int) int
+	find = func(ii int) int {
+		if parents[ii] != ii {
+			parents[ii] = find(parents[ii])
+		}
+
+		return parents[ii]
+	}
+
+	reasons := map[int]map[string]bool{}
+
+	link := func(a, b int, reason string) {
+		ra, rb := find(a), find(b)
+
+		if ra != rb {
+			parents[rb] = ra
+
+			if reasons[ra] == nil {
+				reasons[ra] = map[string]bool{}
+			}
+			for r := range reasons[rb] {
+				reasons[ra][r] = true
+			}
+			delete(reasons, rb)
+		} else if reasons[ra] == nil {
+			reasons[ra] = map[string]bool{}
+		}
+
+		reasons[ra][reason] = true
+	}
+
+	for ii := range elements {
+		for jj := ii + 1; jj < len(elements); jj++ {
+			a := elements[ii]
+			b := elements[jj]
+
+			mailA := normalizeMail(a.Mail)
+			mailB := normalizeMail(b.Mail)
+
+			// identical contacts are already consolidated
+			if mailA == mailB && a.Name == b.Name {
+				continue
+			}
+
+			if mailA != "" && mailA == mailB {
+				link(ii, jj, "mail")
+			} else if similarNames(a.Name, b.Name) {
+				link(ii, jj, "name")
+			}
+		}
+	}
+
+	groups := map[int]*DuplicateGroup{}
+	roots := []int{}
+
+	for ii, element := range elements {
+		root := find(ii)
+
+		if _, ok := reasons[root]; !ok {
+			continue
+		}
+
+		if groups[root] == nil {
+			groups[root] = &DuplicateGroup{Reasons: []string{}, Elements: []ElementDB{}}
+			roots = append(roots, root)
+
+			for reason := range reasons[root] {
+				groups[root].Reasons = append(groups[root].Reasons, reason)
+			}
+			slices.Sort(groups[root].Reasons)
+		}
+
+		groups[root].Elements = append(groups[root].Elements, element)
+	}
+
+	duplicates := make([]DuplicateGroup, len(roots))
+	for ii, root := range roots {
+		duplicates[ii] = *groups[root]
+	}
+
+	return duplicates
+}
+
+// handles get-requests for the likely duplicate sponsors
+func getDuplicates(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if elements, err := selectForRead[ElementDB](c, "elements", "mid != '' ORDER BY mid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else {
+		response.Data = findDuplicates(elements)
+	}
+
+	return response
+}
+
+// returns the merges with their contacts, newest first
+func getMergesData() ([]Merge, error) {
+	if merges, err := store.Select[MergeDB]("merges", "mgid > 0 ORDER BY mgid DESC"); err != nil {
+		return nil, err
+	} else {
+		result := make([]Merge, len(merges))
+
+		for ii, merge := range merges {
+			result[ii] = Merge{
+				Mgid:      merge.Mgid,
+				Canonical: merge.Canonical,
+				Merged:    merge.Merged,
+				Uid:       merge.Uid,
+				Undone:    merge.Undone,
+				Contacts:  []MergedContact{},
+			}
+
+			if contacts, err := store.Select[MergedContactDB]("mergedcontacts", "mgid = ?", merge.Mgid); err != nil {
+				return nil, err
+			} else {
+				for _, contact := range contacts {
+					result[ii].Contacts = append(result[ii].Contacts, MergedContact{
+						Mid:  contact.Mid,
+						Name: contact.Name,
+						Mail: contact.Mail,
+					})
+				}
+			}
+		}
+
+		return result, nil
+	}
+}
+
+// handles get-requests for the merges of sponsors
+func getMerges(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if merges, err := getMergesData(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get merges from database: %v", err)
+	} else {
+		response.Data = merges
+	}
+
+	return response
+}
+
+// handles post-requests merging sponsors: the elements get the name and the
+// mail-address of the canonical element, the previous contacts are kept for the undo
+func postMerges(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := MergeBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ canonical string; mids []string }"`)
+	} else if body.Canonical == "" || len(body.Mids) == 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "canonical and mids are required"
+
+		logger.Info().Msg("can't merge sponsors: canonical or mids are missing")
+	} else if canonical, err := store.Select[ElementDB]("elements", "mid = ?", body.Canonical); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get element %q from database: %v", body.Canonical, err)
+	} else if len(canonical) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "canonical element doesn't exist"
+
+		logger.Info().Msgf("can't merge sponsors: element %q doesn't exist", body.Canonical)
+	} else {
+		// collect the elements to merge
+		elements := []ElementDB{}
+
+		for _, mid := range body.Mids {
+			if mid == body.Canonical {
+				continue
+			} else if res, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't get element %q from database: %v", mid, err)
+
+				return response
+			} else if len(res) != 1 {
+				response.Status = fiber.StatusNotFound
+				response.Message = "element %q doesn't exist"
+				response.Args = []any{mid}
+
+				logger.Info().Msgf("can't merge sponsors: element %q doesn't exist", mid)
+
+				return response
+			} else {
+				elements = append(elements, res[0])
+			}
+		}
+
+		if isDryRun(c) {
+			mids := make([]string, len(elements))
+			for ii, element := range elements {
+				mids[ii] = element.Mid
+			}
+
+			return dryRunResponse(append(appendAffected([]AffectedRows{}, "elements", "update", "", mids),
+				AffectedRows{Table: "merges", Action: "insert", Rows: 1, Keys: []string{}},
+				AffectedRows{Table: "mergedcontacts", Action: "insert", Cause: "backup of the merged contacts", Rows: len(mids), Keys: []string{}},
+			), nil)
+		}
+
+		if res, err := store.Exec("INSERT INTO merges (canonical, merged, uid) VALUES (?, ?, ?)", body.Canonical, time.Now().Format(time.DateTime), getUser(c).Uid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't write merge to database: %v", err)
+		} else if mgid, err := res.LastInsertId(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't get id of merge: %v", err)
+		} else {
+			for _, element := range elements {
+				if err := store.Insert("mergedcontacts", MergedContactDB{
+					Mgid: int(mgid),
+					Mid:  element.Mid,
+					Name: element.Name,
+					Mail: element.Mail,
+				}); err != nil {
+					response.Status = fiber.StatusInternalServerError
+
+					logger.Error().Msgf("can't store contact of %q: %v", element.Mid, err)
+
+					return response
+				} else if err := store.Update("elements", struct {
+					Name string
+					Mail *string
+				}{Name: canonical[0].Name, Mail: canonical[0].Mail}, struct{ Mid string }{Mid: element.Mid}); err != nil {
+					response.Status = fiber.StatusInternalServerError
+
+					logger.Error().Msgf("can't merge element %q into %q: %v", element.Mid, body.Canonical, err)
+
+					return response
+				}
+			}
+
+			cachedElements.Delete("status")
+
+			logger.Info().Msgf("merged %d elements into %q", len(elements), body.Canonical)
+
+			response = getMerges(c)
+		}
+	}
+
+	return response
+}
+
+// handles delete-requests undoing a merge within the undo-window
+func deleteMerges(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mgid := c.QueryInt("mgid")
+
+	if merges, err := store.Select[MergeDB]("merges", "mgid = ?", mgid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get merge %d from database: %v", mgid, err)
+	} else if len(merges) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "merge doesn't exist"
+
+		logger.Info().Msgf("can't undo merge %d: merge doesn't exist", mgid)
+	} else if merges[0].Undone != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "merge is already undone"
+
+		logger.Info().Msgf("can't undo merge %d: merge is already undone", mgid)
+	} else if merged, err := time.ParseInLocation(time.DateTime, merges[0].Merged, time.Local); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't parse date of merge %d: %v", mgid, err)
+	} else if time.Since(merged) > config.Merges.UndoWindow {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "undo-window of the merge has expired"
+
+		logger.Info().Msgf("can't undo merge %d: undo-window has expired", mgid)
+	} else if contacts, err := store.Select[MergedContactDB]("mergedcontacts", "mgid = ?", mgid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get contacts of merge %d from database: %v", mgid, err)
+	} else if isDryRun(c) {
+		mids := make([]string, len(contacts))
+		for ii, contact := range contacts {
+			mids[ii] = contact.Mid
+		}
+
+		affected := appendAffected([]AffectedRows{}, "merges", "update", "", []string{strconv.Itoa(mgid)})
+
+		response = dryRunResponse(appendAffected(affected, "elements", "update", "restore of the merged contacts", mids), nil)
+	} else {
+		for _, contact := range contacts {
+			if err := store.Update("elements", struct {
+				Name string
+				Mail *string
+			}{Name: contact.Name, Mail: contact.Mail}, struct{ Mid string }{Mid: contact.Mid}); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't restore contact of %q: %v", contact.Mid, err)
+
+				return response
+			}
+		}
+
+		if err := store.Update("merges", struct{ Undone string }{Undone: time.Now().Format(time.DateTime)}, struct{ Mgid int }{Mgid: mgid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't mark merge %d as undone: %v", mgid, err)
+		} else {
+			cachedElements.Delete("status")
+
+			logger.Info().Msgf("undid merge %d", mgid)
+
+			response = getMerges(c)
+		}
+	}
+
+	return response
+}
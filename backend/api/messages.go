@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// header the language-independent code of the response-message is sent in
+const messageCodeHeader = "X-Message-Code"
+
+// translations of the response-messages, by language and english message. Messages
+// with placeholders are looked up by their format, missing ones are sent in english
+var messageCatalog = map[string]map[string]string{
+	"de": {
+		"Unkown user or wrong password":                                     "Unbekannter Benutzer oder falsches Passwort",
+		"account is deactivated":                                            "Das Konto ist deaktiviert",
+		"admin-role has to be confirmed by another admin":                   "Die Admin-Rolle muss von einem anderen Admin bestätigt werden",
+		"admins can't be deleted":                                           "Admins können nicht gelöscht werden",
+		"api-key needs a name":                                              "Der API-Schlüssel braucht einen Namen",
+		"campaign doesn't exist":                                            "Die Kampagne existiert nicht",
+		"can't add user to database":                                        "Der Benutzer kann nicht gespeichert werden",
+		"can't check reservation-limit":                                     "Das Reservierungslimit kann nicht geprüft werden",
+		"can't delete user":                                                 "Der Benutzer kann nicht gelöscht werden",
+		"can't get elements":                                                "Die Elemente können nicht geladen werden",
+		"can't get users from database":                                     "Die Benutzer können nicht geladen werden",
+		"can't parse message-body":                                          "Die Anfrage kann nicht gelesen werden",
+		"can't store maintenance-mode":                                      "Der Wartungsmodus kann nicht gespeichert werden",
+		"can't update password":                                             "Das Passwort kann nicht geändert werden",
+		"canonical and mids are required":                                   "Das Hauptelement und die Elemente werden benötigt",
+		"canonical element doesn't exist":                                   "Das Hauptelement existiert nicht",
+		"certificate doesn't exist anymore":                                 "Die Urkunde existiert nicht mehr",
+		"certificates are sent, if there are any for the mail-address":      "Die Urkunden werden versendet, falls es welche für die E-Mail-Adresse gibt",
+		"datev-export isn't configured":                                     "Der DATEV-Export ist nicht eingerichtet",
+		"element belongs to a plant you aren't assigned to":                 "Das Element gehört zu einer Anlage, der du nicht zugeordnet bist",
+		"element can't be reserved yet":                                     "Das Element kann noch nicht reserviert werden",
+		"element can't be reserved before %s":                               "Das Element kann erst ab %s reserviert werden",
+		"element doesn't exist or target is already taken":                  "Das Element existiert nicht oder das Ziel ist bereits vergeben",
+		"element doesn't exist":                                             "Das Element existiert nicht",
+		"element %q doesn't exist":                                          "Das Element %q existiert nicht",
+		"element is already reserved":                                       "Das Element ist bereits reserviert",
+		"element is already taken":                                          "Das Element ist bereits vergeben",
+		"element is currently reserved":                                     "Das Element ist momentan reserviert",
+		"element isn't available":                                           "Das Element ist nicht verfügbar",
+		"error while creating certificate":                                  "Fehler beim Erstellen der Urkunde",
+		"error while deleting reservation from database":                    "Fehler beim Löschen der Reservierung",
+		"error while issuing certificate":                                   "Fehler beim Ausstellen der Urkunde",
+		"error while sending certificate":                                   "Fehler beim Versenden der Urkunde",
+		"error while writing reservation to database":                       "Fehler beim Speichern der Reservierung",
+		"invalid api-key":                                                   "Ungültiger API-Schlüssel",
+		"invalid download-link":                                             "Ungültiger Download-Link",
+		"invalid element name":                                              "Ungültiger Elementname",
+		"invalid element %q":                                                "Ungültiges Element %q",
+		"invalid mID":                                                       "Ungültige Element-ID",
+		"invalid message-body":                                              "Ungültige Anfrage",
+		"invalid message-body: %v":                                          "Ungültige Anfrage: %v",
+		"invalid password":                                                  "Ungültiges Passwort",
+		"invalid range":                                                     "Ungültiger Bereich",
+		"invalid status-link":                                               "Ungültiger Status-Link",
+		"invalid target element-name":                                       "Ungültiger Name des Zielelements",
+		"invalid template: %v":                                              "Ungültige Vorlage: %v",
+		"invalid unsubscribe-link":                                          "Ungültiger Abmelde-Link",
+		"job doesn't exist":                                                 "Der Job existiert nicht",
+		"job is already running":                                            "Der Job läuft bereits",
+		"did you mean %q?":                                                  "Meinten Sie %q?",
+		"disposable mail-addresses aren't accepted, domain %q":              "E-Mail-Adressen von Wegwerf-Anbietern werden nicht akzeptiert (Domain %q)",
+		"mail-domain %q can't receive mails":                                "Die Domain %q kann keine E-Mails empfangen",
+		"invalid pagination":                                                "Ungültige Seitenangabe",
+		"count has to be between 1 and %d":                                  "Die Anzahl muss zwischen 1 und %d liegen",
+		"invalid duration %q":                                               "Ungültige Dauer %q",
+		"invalid state %q":                                                  "Ungültiger Status %q",
+		"feature %q isn't enabled":                                          "Die Funktion %q ist nicht aktiviert",
+		"unknown feature %q":                                                "Unbekannte Funktion %q",
+		"feed isn't enabled":                                                "Der Feed ist nicht aktiviert",
+		"mail is required":                                                  "Die E-Mail-Adresse fehlt",
+		"matching doesn't exist":                                            "Die Verdopplung existiert nicht",
+		"matching is already confirmed":                                     "Die Verdopplung ist bereits bestätigt",
+		"amount can't be negative":                                          "Der Betrag darf nicht negativ sein",
+		"merge doesn't exist":                                               "Die Zusammenführung existiert nicht",
+		"merge is already undone":                                           "Die Zusammenführung ist bereits rückgängig gemacht",
+		"missing permission to confirm reservations":                        "Keine Berechtigung zum Bestätigen von Reservierungen",
+		"missing permission to issue certificates":                          "Keine Berechtigung zum Ausstellen von Urkunden",
+		"monitoring is disabled":                                            "Die Überwachung ist deaktiviert",
+		"no pending admin-role":                                             "Keine offene Admin-Rolle",
+		"no pending reservation found":                                      "Keine offene Reservierung gefunden",
+		"no sponsorship found":                                              "Keine Patenschaft gefunden",
+		"name was changed in the meantime":                                  "Der Name wurde zwischenzeitlich geändert",
+		"no reservation found":                                              "Keine Reservierung gefunden",
+		"notes are too long":                                                "Die Notizen sind zu lang",
+		"origin not allowed for api-key":                                    "Die Herkunft ist für den API-Schlüssel nicht erlaubt",
+		"query doesn't include mail":                                        "Die Anfrage enthält keine E-Mail-Adresse",
+		"query doesn't include mid":                                         "Die Anfrage enthält keine Element-ID",
+		"query doesn't include valid cid":                                   "Die Anfrage enthält keine gültige Kampagnen-ID",
+		"query doesn't include valid code":                                  "Die Anfrage enthält keinen gültigen Code",
+		"query doesn't include valid kid":                                   "Die Anfrage enthält keine gültige Schlüssel-ID",
+		"query doesn't include valid mid":                                   "Die Anfrage enthält keine gültige Element-ID",
+		"query doesn't include valid uid":                                   "Die Anfrage enthält keine gültige Benutzer-ID",
+		"query doesn't include valid year":                                  "Die Anfrage enthält kein gültiges Jahr",
+		"quota of api-key exceeded":                                         "Das Kontingent des API-Schlüssels ist aufgebraucht",
+		"request of the admin-role expired":                                 "Die Anfrage der Admin-Rolle ist abgelaufen",
+		"reservation is already confirmed":                                  "Die Reservierung ist bereits bestätigt",
+		"reservation isn't approved yet":                                    "Die Reservierung ist noch nicht freigegeben",
+		"reservation-limit reached":                                         "Das Reservierungslimit ist erreicht",
+		"revocation of the admin-role has to be confirmed by another admin": "Das Entziehen der Admin-Rolle muss von einem anderen Admin bestätigt werden",
+		"short-links aren't enabled":                                        "Kurzlinks sind nicht aktiviert",
+		"subject and body are required":                                     "Betreff und Text werden benötigt",
+		"too many requests, try again later":                                "Zu viele Anfragen, bitte später erneut versuchen",
+		"undo-window of the merge has expired":                              "Die Zusammenführung kann nicht mehr rückgängig gemacht werden",
+		"unknown certificate":                                               "Unbekannte Urkunde",
+		"unsubscribed":                                                      "Abgemeldet",
+		"user already exists":                                               "Der Benutzer existiert bereits",
+		"user-name is reserved":                                             "Der Benutzername ist reserviert",
+		"user doesn't exist":                                                "Der Benutzer existiert nicht",
+		"wrong current password":                                            "Das bisherige Passwort ist falsch",
+		"yield isn't available yet":                                         "Der Ertrag ist noch nicht verfügbar",
+		"field %q must be a boolean":                                        "Das Feld %q muss ein Wahrheitswert sein",
+		"field %q must be a number":                                         "Das Feld %q muss eine Zahl sein",
+		"field %q must be one of %v":                                        "Das Feld %q muss einer der Werte %v sein",
+		"field %q must be a text":                                           "Das Feld %q muss ein Text sein",
+		"field %q is longer than %d characters":                             "Das Feld %q ist länger als %d Zeichen",
+		"field %q is required":                                              "Das Feld %q ist erforderlich",
+		"unknown field %q":                                                  "Unbekanntes Feld %q",
+		"dedications aren't enabled":                                        "Widmungen sind nicht aktiviert",
+		"dedication is longer than %d characters":                           "Die Widmung ist länger als %d Zeichen",
+		"dedication contains invalid characters":                            "Die Widmung enthält ungültige Zeichen",
+		"dedication contains the blocked word %q":                           "Die Widmung enthält das gesperrte Wort %q",
+		"consent to %q is required":                                         "Die Zustimmung zu %q ist erforderlich",
+		"consent to an outdated version of %q":                              "Zustimmung zu einer veralteten Version von %q",
+		"matching doesn't include an employer":                              "Die Verdopplung enthält keinen Arbeitgeber",
+		"employer is longer than %d characters":                             "Der Arbeitgeber ist länger als %d Zeichen",
+		"reference is longer than %d characters":                            "Die Referenz ist länger als %d Zeichen",
+		"gift doesn't include a recipient":                                  "Das Geschenk enthält keinen Empfänger",
+		"invalid date %q":                                                   "Ungültiges Datum %q",
+		"invalid delivery-date %q":                                          "Ungültiges Lieferdatum %q",
+		"invalid mail-address %q":                                           "Ungültige E-Mail-Adresse %q",
+		"postal delivery isn't available":                                   "Der Versand per Post ist nicht verfügbar",
+		"printed certificate requires a postal address":                     "Für eine gedruckte Urkunde wird eine Postanschrift benötigt",
+		"postal address is longer than %d characters":                       "Die Postanschrift ist länger als %d Zeichen",
+		"postal address is incomplete":                                      "Die Postanschrift ist unvollständig",
+		"invalid postal code %q":                                            "Ungültige Postleitzahl %q",
+		"invalid reset-link":                                                "Ungültiger Link zum Zurücksetzen",
+		"invalid verification-link":                                         "Ungültiger Bestätigungslink",
+		"mail-address is verified":                                          "Die E-Mail-Adresse ist bestätigt",
+		"password-resets aren't enabled":                                    "Das Zurücksetzen von Passwörtern ist nicht aktiviert",
+		"reset-link is sent, if the user has a verified mail-address":       "Der Link wird versendet, falls der Benutzer eine bestätigte E-Mail-Adresse hat",
+		"password of an admin can only be reset by themselves":              "Das Passwort eines Admins kann nur von ihm selbst zurückgesetzt werden",
+		"reset-link was sent to the admin":                                  "Der Link zum Zurücksetzen wurde an den Admin gesendet",
+		"verification-link was sent to the mail-address":                    "Der Bestätigungslink wurde an die E-Mail-Adresse gesendet",
+		"%s in query and body differ":                                       "%s in Anfrage und Inhalt unterscheiden sich",
+		"invalid mid %q":                                                    "Ungültige Element-ID %q",
+		"name is longer than %d characters":                                 "Der Name ist länger als %d Zeichen",
+		"source is longer than %d characters":                               "Die Quelle ist länger als %d Zeichen",
+		"recipient is longer than %d characters":                            "Der Empfänger ist länger als %d Zeichen",
+	},
+}
+
+// error with a user-facing message, translated by its format
+type messageError struct {
+	Format string
+	Args   []any
+}
+
+func (err messageError) Error() string {
+	return fmt.Sprintf(err.Format, err.Args...)
+}
+
+// creates an error whose message is translated for the response
+func messageErrorf(format string, args ...any) error {
+	return messageError{Format: format, Args: args}
+}
+
+// returns the format and the arguments of the message of an error for the response
+func errorMessage(err error) (string, []any) {
+	var msgErr messageError
+
+	if errors.As(err, &msgErr) {
+		return msgErr.Format, msgErr.Args
+	} else {
+		return err.Error(), nil
+	}
+}
+
+// placeholders of the messages, left out of the codes
+var formatVerbRegex = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// creates the language-independent code of a message from its format,
+// e.g. "element_is_already_taken"
+func messageCode(format string) string {
+	format = strings.ReplaceAll(formatVerbRegex.ReplaceAllString(format, ""), "'", "")
+
+	words := strings.FieldsFunc(strings.ToLower(format), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, "_")
+}
+
+// translates a message to the language the client accepts, english is the fallback
+func translateMessage(c *fiber.Ctx, format string, args []any) string {
+	offers := []string{"en"}
+	for language := range messageCatalog {
+		offers = append(offers, language)
+	}
+
+	if translation, ok := messageCatalog[c.AcceptsLanguages(offers...)][format]; ok {
+		format = translation
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(format, args...)
+	} else {
+		return format
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// wether the names of the sponsors are only shown publicly after an approval
+func nameModeration() bool {
+	return config.ConfigYaml.NameModeration.Enabled
+}
+
+// returns the name of a sponsor shown publicly, empty while it awaits the moderation
+func moderatedName(name string, approved *string) string {
+	if !nameModeration() || (approved != nil && *approved == name) {
+		return name
+	} else {
+		return ""
+	}
+}
+
+// approves the name of a sponsor again, if it was approved for an earlier sponsorship of the mail-address
+func autoApproveName(mid, name, mail string) error {
+	if !nameModeration() || !config.ConfigYaml.NameModeration.AutoApproveReturning || name == "" || mail == "" {
+		return nil
+	}
+
+	if approved, err := store.Select[struct{ Name string }]("approvednames", "mailhash = ? AND name = ? LIMIT 1", hashMail(mail), name); err != nil {
+		return err
+	} else if len(approved) == 0 {
+		return nil
+	} else if err := store.Update("elements", struct{ Approvedname string }{Approvedname: name}, struct{ Mid string }{Mid: mid}); err != nil {
+		return err
+	}
+
+	logger.Info().Msgf("approved name of %q automatically: returning sponsor", mid)
+
+	return nil
+}
+
+// handles get-requests for the names of the sponsors awaiting the moderation
+func getModerationNames(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if names, err := store.Select[NameModeration]("elements", "reservation IS NULL AND name != '' AND (approvedname IS NULL OR approvedname != name) AND (rejectedname IS NULL OR rejectedname != name) ORDER BY confirmed"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get names awaiting moderation from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+
+		response.Data = slices.DeleteFunc(names, func(name NameModeration) bool { return !inPlant(name.Mid) })
+	}
+
+	return response
+}
+
+// reads the sponsorship a moderation-request refers to. The name in the body has to match the
+// current one, so a name changed in the meantime isn't approved unseen
+func moderationTarget(c *fiber.Ctx) (string, string, responseMessage) {
+	body := NameBody{}
+
+	ids, response := bindRequest(c, "mid", &body)
+
+	if response.Status != 0 {
+		return "", "", response
+	} else if names, err := store.Select[NameModeration]("elements", "mid = ? AND reservation IS NULL", ids.Mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get sponsorship of %q from database: %v", ids.Mid, err)
+	} else if len(names) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "no sponsorship found"
+
+		logger.Info().Msgf("can't moderate name of %q: no sponsorship", ids.Mid)
+	} else if names[0].Name != body.Name {
+		response.Status = fiber.StatusConflict
+		response.Message = "name was changed in the meantime"
+
+		logger.Info().Msgf("can't moderate name of %q: it was changed", ids.Mid)
+	}
+
+	return ids.Mid, body.Name, response
+}
+
+// handles post-requests approving the name of a sponsor for the public display
+func postModerationNamesApprove(c *fiber.Ctx) responseMessage {
+	mid, name, response := moderationTarget(c)
+
+	if response.Status != 0 {
+		return response
+	} else if err := store.Update("elements", struct{ Approvedname string }{Approvedname: name}, struct{ Mid string }{Mid: mid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store approval of the name of %q: %v", mid, err)
+	} else {
+		cachedElements.Delete("status")
+
+		logger.Info().Msgf("%q approved the name of %q", getUser(c).Name, mid)
+
+		// the mail-address of the sponsor is only kept as hash with the certificate
+		if certificates, err := store.Select[struct{ Mailhash string }]("certificates", "mid = ? AND name = ? AND mailhash != '' ORDER BY serial DESC LIMIT 1", mid, name); err != nil {
+			logger.Error().Msgf("can't get certificate of %q from database: %v", mid, err)
+		} else if len(certificates) == 1 {
+			if err := store.Insert("approvednames", struct {
+				Mailhash string
+				Name     string
+			}{Mailhash: certificates[0].Mailhash, Name: name}); err != nil {
+				logger.Error().Msgf("can't store approved name of %q: %v", mid, err)
+			}
+		}
+
+		response = getModerationNames(c)
+	}
+
+	return response
+}
+
+// handles post-requests rejecting the name of a sponsor, the sponsorship is shown without it
+func postModerationNamesReject(c *fiber.Ctx) responseMessage {
+	mid, name, response := moderationTarget(c)
+
+	if response.Status != 0 {
+		return response
+	} else if err := store.Update("elements", struct{ Rejectedname string }{Rejectedname: name}, struct{ Mid string }{Mid: mid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store rejection of the name of %q: %v", mid, err)
+	} else {
+		logger.Info().Msgf("%q rejected the name of %q", getUser(c).Name, mid)
+
+		response = getModerationNames(c)
+	}
+
+	return response
+}
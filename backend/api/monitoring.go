@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+)
+
+// client for the requests to the monitoring-api
+var monitoringClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// retrieves the yield from a monitoring-provider
+type yieldProvider func() (Yield, error)
+
+var yieldProviders = map[string]yieldProvider{
+	"fronius":   getFroniusYield,
+	"solaredge": getSolarEdgeYield,
+}
+
+// requests an url and decodes the JSON-response
+func getMonitoringJSON(u string, result any) error {
+	res, err := monitoringClient.Get(u)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("monitoring-api responded with %s", res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// retrieves the yield from a fronius-datalogger. The individual inverters are
+// reported as strings
+func getFroniusYield() (Yield, error) {
+	var response struct {
+		Body struct {
+			Data struct {
+				TotalEnergy struct {
+					Values map[string]float64
+				} `json:"TOTAL_ENERGY"`
+				YearEnergy struct {
+					Values map[string]float64
+				} `json:"YEAR_ENERGY"`
+			}
+		}
+	}
+
+	yield := Yield{
+		Strings: make(map[string]StringYield),
+	}
+
+	if u, err := url.JoinPath(config.ConfigYaml.Monitoring.URL, "solar_api/v1/GetInverterRealtimeData.cgi"); err != nil {
+		return yield, err
+	} else if err := getMonitoringJSON(u+"?Scope=System", &response); err != nil {
+		return yield, err
+	} else {
+		// the values are reported in Wh
+		for inverter, total := range response.Body.Data.TotalEnergy.Values {
+			stringYield := StringYield{
+				Total: total / 1000,
+				Year:  response.Body.Data.YearEnergy.Values[inverter] / 1000,
+			}
+
+			yield.Strings[inverter] = stringYield
+			yield.Total += stringYield.Total
+			yield.Year += stringYield.Year
+		}
+
+		return yield, nil
+	}
+}
+
+// retrieves the yield from the solaredge-monitoring. Only the total of the
+// site is available
+func getSolarEdgeYield() (Yield, error) {
+	var response struct {
+		Overview struct {
+			LifeTimeData struct {
+				Energy float64
+			}
+			LastYearData struct {
+				Energy float64
+			}
+		}
+	}
+
+	yield := Yield{
+		Strings: make(map[string]StringYield),
+	}
+
+	u := fmt.Sprintf("https://monitoringapi.solaredge.com/site/%s/overview?api_key=%s", url.PathEscape(config.ConfigYaml.Monitoring.SiteID), url.QueryEscape(config.ConfigYaml.Monitoring.APIKey))
+
+	if err := getMonitoringJSON(u, &response); err != nil {
+		return yield, err
+	} else {
+		// the values are reported in Wh
+		yield.Total = response.Overview.LifeTimeData.Energy / 1000
+		yield.Year = response.Overview.LastYearData.Energy / 1000
+
+		return yield, nil
+	}
+}
+
+// retrieves the yield from the configured provider and stores it in the cache
+func cacheYield() error {
+	provider, ok := yieldProviders[config.ConfigYaml.Monitoring.Provider]
+	if !ok {
+		return fmt.Errorf("unknown monitoring-provider %q", config.ConfigYaml.Monitoring.Provider)
+	}
+
+	if yield, err := provider(); err != nil {
+		return err
+	} else {
+		yield.Updated = time.Now().Format(time.DateTime)
+
+		cachedYield.Set("plant", yield)
+
+		logger.Debug().Msgf("retrieved yield: %s kWh", strconv.FormatFloat(yield.Total, 'f', 1, 64))
+
+		return nil
+	}
+}
+
+// retrieves the cached yield of the plant
+func getCachedYield() (Yield, bool) {
+	return cachedYield.Get("plant")
+}
+
+// handles get-requests for the yield of the plant
+func getYield(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if config.ConfigYaml.Monitoring.Provider == "" {
+		response.Status = fiber.StatusNotFound
+		response.Message = "monitoring is disabled"
+	} else if yield, found := getCachedYield(); !found {
+		response.Status = fiber.StatusServiceUnavailable
+		response.Message = "yield isn't available yet"
+
+		logger.Info().Msg("yield isn't cached yet")
+	} else {
+		response.Data = yield
+	}
+
+	return response
+}
+
+// retrieves the element-group (e.g. "pv-a") of a mid
+func getElementGroup(mid string) string {
+	mid, _ = certs.SplitShare(mid)
+
+	return config.Mids.Descriptor(mid)
+}
+
+// wether an element produces energy
+func isProducingElement(group string) bool {
+	return strings.HasPrefix(group, "pv")
+}
+
+// number of producing elements, either of a string or of the whole plant if
+// the string is empty
+func countProducingElements(stringID string) int {
+	count := 0
+
+	for group, rng := range config.ValidateElements.ValidElements {
+		if !isProducingElement(group) {
+			continue
+		}
+
+		if stringID == "" || config.ConfigYaml.Monitoring.Strings[group] == stringID {
+			count += rng.To - rng.From + 1
+		}
+	}
+
+	return count
+}
+
+// attributes the yield proportionally to a single element. If the
+// element-group belongs to a monitored string, only the yield of that string is
+// shared
+func attributeYield(yield Yield, mid string) ElementYield {
+	elementYield := ElementYield{
+		Mid: mid,
+	}
+
+	group := getElementGroup(mid)
+
+	if !isProducingElement(group) {
+		return elementYield
+	}
+
+	total, year := yield.Total, yield.Year
+	stringID := config.ConfigYaml.Monitoring.Strings[group]
+
+	if stringYield, ok := yield.Strings[stringID]; ok && stringID != "" {
+		total, year = stringYield.Total, stringYield.Year
+	} else {
+		stringID = ""
+	}
+
+	// shares get their fraction of the element
+	if shares := elementShares(mid); shares > 0 {
+		total, year = total/float64(shares), year/float64(shares)
+	}
+
+	if count := countProducingElements(stringID); count > 0 {
+		elementYield.Total = total / float64(count)
+		elementYield.Year = year / float64(count)
+	}
+
+	return elementYield
+}
+
+// handles get-requests for the yield of a single element
+func getElementYield(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mid, err := resolveMid(c.Query("mid"))
+
+	if err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't resolve mid %q: %v", c.Query("mid"), err)
+	} else if ok, err := isValidMid(mid); err != nil || !ok {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid mID"
+
+		logger.Info().Msgf("can't get yield: invalid element-name: %q", mid)
+	} else if response = getYield(c); response.Status == 0 {
+		response.Data = attributeYield(response.Data.(Yield), mid)
+	}
+
+	return response
+}
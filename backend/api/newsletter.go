@@ -0,0 +1,105 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// creates the token authorizing the unsubscription of a mail-address
+func newsletterToken(mail string) string {
+	mac := hmac.New(sha256.New, []byte(config.ClientSession.JwtSignature))
+	mac.Write([]byte("newsletter:" + strings.ToLower(mail)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// creates the unsubscribe-link for a mail-address
+func newsletterUnsubscribeURL(mail string) string {
+	query := url.Values{
+		"mail":  {mail},
+		"token": {newsletterToken(mail)},
+	}
+
+	return config.Newsletter.UnsubscribeURL + "?" + query.Encode()
+}
+
+// stores the newsletter-consent of a mail-address, a previous consent is replaced
+func subscribeNewsletter(mail, name, ip string) error {
+	if sealed, err := store.Seal(mail); err != nil {
+		return err
+	} else {
+		_, err := store.Exec("REPLACE INTO newsletter (mail, name, consent, ip) VALUES (?, ?, ?, ?)", sealed, name, time.Now().Format(time.DateTime), ip)
+
+		return err
+	}
+}
+
+// handles get-requests for exporting the newsletter-subscriptions. With
+// "format=csv" they are returned as csv-file
+func getNewsletter(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if subscriptions, err := selectForRead[NewsletterDB](c, "newsletter", "*"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get newsletter-subscriptions from database: %v", err)
+	} else if c.Query("format") == "csv" {
+		var buf strings.Builder
+
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"mail", "name", "consent", "ip"})
+
+		for _, subscription := range subscriptions {
+			w.Write([]string{subscription.Mail, subscription.Name, subscription.Consent, subscription.Ip})
+		}
+
+		w.Flush()
+
+		c.Attachment("newsletter.csv")
+		c.SendString(buf.String())
+
+		response.Status = fiber.StatusOK
+	} else {
+		response.Data = subscriptions
+	}
+
+	return response
+}
+
+// handles delete-requests from unsubscribe-links
+func deleteNewsletter(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mail := c.Query("mail")
+	token := c.Query("token")
+
+	if mail == "" || !hmac.Equal([]byte(token), []byte(newsletterToken(mail))) {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid unsubscribe-link"
+
+		logger.Info().Msgf("invalid unsubscribe-link for %q", mail)
+	} else if sealed, err := store.Seal(mail); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't encrypt mail-address %q: %v", mail, err)
+	} else if _, err := store.Exec("DELETE FROM newsletter WHERE mail = ?", sealed); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't remove %q from the newsletter: %v", mail, err)
+	} else {
+		response.Status = fiber.StatusOK
+		response.Message = "unsubscribed"
+
+		logger.Info().Msgf("unsubscribed %q from the newsletter", mail)
+	}
+
+	return response
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum length of the notes of an element in characters
+const maxNotesLength = 2000
+
+// handles patch-requests for changing the internal notes of an element
+func patchElementNotes(c *fiber.Ctx) responseMessage {
+	body := NotesBody{}
+
+	ids, response := bindRequest(c, "mid", &body)
+	mid := ids.Mid
+
+	if response.Status != 0 {
+		return response
+	} else if len([]rune(body.Notes)) > maxNotesLength {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "notes are too long"
+
+		logger.Info().Msgf("can't set notes of element %q: notes are too long", mid)
+	} else {
+		// store empty notes as NULL
+		var notes *string
+
+		if body.Notes != "" {
+			notes = &body.Notes
+		}
+
+		if res, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't get element %q from database: %v", mid, err)
+		} else if len(res) != 1 {
+			response.Status = fiber.StatusNotFound
+			response.Message = "element doesn't exist"
+
+			logger.Info().Msgf("can't set notes of element %q: element doesn't exist", mid)
+		} else if err := store.Update("elements", struct{ Notes *string }{Notes: notes}, struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't update notes of element %q: %v", mid, err)
+		} else {
+			res[0].Notes = notes
+
+			response.Data = res[0]
+
+			logger.Debug().Msgf("updated notes of element %q", mid)
+		}
+	}
+
+	return response
+}
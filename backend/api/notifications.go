@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/notifier"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// kinds of the notifications
+const (
+	notificationReservation = "reservation"
+	notificationApproval    = "approval"
+	notificationMailFailed  = "mail-failed"
+	notificationExpiring    = "expiring"
+	// grant of the admin-role awaiting confirmation
+	notificationAdminApproval = "admin-approval"
+	// matching-donation of an employer awaiting the payment
+	notificationMatching = "matching"
+	// only pushed to the notification-channels
+	notificationDatabaseDown = "database-down"
+)
+
+// time after which read notifications are removed
+const notificationRetention = 30 * 24 * time.Hour
+
+// creates a notification for all users with the role. Users who still have an
+// unread notification of the same kind for the element don't get another one
+func notify(role, kind, mid, message string) {
+	notifier.Publish(kind, message)
+
+	if users, err := store.Select[UserDB]("users", "deactivated = FALSE"); err != nil {
+		logger.Error().Msgf("can't get users for notification: %v", err)
+	} else {
+		recipients := []UserDB{}
+
+		for _, user := range users {
+			if !user.hasRole(role) {
+				continue
+			}
+
+			if res, err := store.Exec(
+				"INSERT INTO notifications (uid, kind, mid, message) SELECT ?, ?, ?, ? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM notifications WHERE uid = ? AND kind = ? AND mid = ? AND readat IS NULL)",
+				user.Uid, kind, mid, message, user.Uid, kind, mid,
+			); err != nil {
+				logger.Error().Msgf("can't create notification for user with uid = %q: %v", user.Uid, err)
+			} else if rows, err := res.RowsAffected(); err == nil && rows > 0 {
+				recipients = append(recipients, user)
+			}
+		}
+
+		// only the users who got a new notification receive a mail
+		mailNotification(recipients, kind, message)
+	}
+}
+
+// handles get-requests for the notifications of the user, "unread=true" returns only the unread ones
+func getNotifications(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	where := "uid = ?"
+
+	if c.QueryBool("unread") {
+		where += " AND readat IS NULL"
+	}
+
+	if notifications, err := store.Select[Notification]("notifications", where+" ORDER BY nid DESC", getUser(c).Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get notifications from database: %v", err)
+	} else {
+		response.Data = notifications
+	}
+
+	return response
+}
+
+// handles patch-requests marking a notification as read, without "nid" all notifications of the user
+func patchNotificationsRead(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	uid := getUser(c).Uid
+	now := time.Now().Format(time.DateTime)
+
+	var err error
+
+	if nid := c.QueryInt("nid", -1); nid >= 0 {
+		_, err = store.Exec("UPDATE notifications SET readat = ? WHERE nid = ? AND uid = ? AND readat IS NULL", now, nid, uid)
+	} else {
+		_, err = store.Exec("UPDATE notifications SET readat = ? WHERE uid = ? AND readat IS NULL", now, uid)
+	}
+
+	if err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't mark notifications as read: %v", err)
+	} else {
+		response = getNotifications(c)
+	}
+
+	return response
+}
+
+// wether the database wasn't reachable at the last check
+var databaseDown atomic.Bool
+
+// pushes an event to the notification-channels when the database becomes unreachable or reachable again
+func checkDatabase() error {
+	if err := store.Ping(); err != nil {
+		if !databaseDown.Swap(true) {
+			notifier.Publish(notificationDatabaseDown, fmt.Sprintf("database isn't reachable: %v", err))
+		}
+
+		return err
+	} else if databaseDown.Swap(false) {
+		notifier.Publish(notificationDatabaseDown, "database is reachable again")
+	}
+
+	return nil
+}
+
+// notifies about the reservations expiring within the next day and removes old read notifications
+func checkNotifications() error {
+	// reservations made before this time expire within the next day
+	expiring := time.Now().Add(24*time.Hour - config.Reservation.Expiration).Format(time.DateTime)
+
+	if elements, err := store.Select[ElementDB]("elements", "reservation IS NOT NULL AND reservation < ?", expiring); err != nil {
+		return err
+	} else {
+		for _, element := range elements {
+			notify(roleUser, notificationExpiring, element.Mid, fmt.Sprintf("reservation of %q expires today", element.Mid))
+		}
+	}
+
+	_, err := store.Exec("DELETE FROM notifications WHERE readat IS NOT NULL AND readat < ?", time.Now().Add(-notificationRetention).Format(time.DateTime))
+
+	return err
+}
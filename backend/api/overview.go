@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// states of the elements in the overview
+const (
+	elementFree     = "free"
+	elementReserved = "reserved"
+	elementPending  = "pending"
+	elementTaken    = "taken"
+)
+
+// default and maximum number of elements per page of the overview
+const (
+	overviewPerPage    = 100
+	overviewMaxPerPage = 1000
+)
+
+// returns the mids of all valid elements, the elements split into shares by their shares
+func allMids() []string {
+	mids := []string{}
+
+	for _, descriptor := range slices.Sorted(maps.Keys(config.ValidateElements.ValidElements)) {
+		rng := config.ValidateElements.ValidElements[descriptor]
+
+		for n := rng.From; n <= rng.To; n++ {
+			mid := backendConfig.FormatMid(descriptor, n, rng.Digits)
+
+			if rng.Shares == 0 {
+				mids = append(mids, mid)
+			} else {
+				for share := 1; share <= rng.Shares; share++ {
+					mids = append(mids, fmt.Sprintf("%s%s%d", mid, certs.ShareSeparator, share))
+				}
+			}
+		}
+	}
+
+	return mids
+}
+
+// builds the overview-entry of an element with its database-entry, nil for free elements
+func overviewElement(mid string, element *ElementDBAdmin) AdminElement {
+	entry := AdminElement{
+		Mid:     mid,
+		State:   elementFree,
+		Amount:  elementPrice(mid),
+		Hidden:  isHidden(mid),
+		Element: element,
+	}
+
+	if element == nil {
+		return entry
+	} else if element.Reservation == nil {
+		entry.State = elementTaken
+	} else {
+		if element.Pending {
+			entry.State = elementPending
+		} else {
+			entry.State = elementReserved
+		}
+
+		if reserved, err := time.ParseInLocation(time.DateTime, *element.Reservation, time.Local); err != nil {
+			logger.Warn().Msgf("can't parse reservation-date of %q: %v", mid, err)
+		} else {
+			expires := reserved.Add(config.Reservation.Expiration).Format(time.DateTime)
+			entry.Expires = &expires
+		}
+	}
+
+	return entry
+}
+
+// handles get-requests for the overview of all elements with their state and sponsor.
+// Optionally filtered by "state" and paginated by "page" (starting at 1) and "per_page"
+func getElementsAdmin(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	page := c.QueryInt("page", 1)
+	perPage := c.QueryInt("per_page", overviewPerPage)
+	state := c.Query("state")
+
+	if page < 1 || perPage < 1 || perPage > overviewMaxPerPage {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid pagination"
+
+		logger.Info().Msgf("can't get element-overview: invalid pagination (page %d with %d elements)", page, perPage)
+	} else if state != "" && !slices.Contains([]string{elementFree, elementReserved, elementPending, elementTaken}, state) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid state %q"
+		response.Args = []any{state}
+
+		logger.Info().Msgf("can't get element-overview: invalid state %q", state)
+	} else if res, err := selectForRead[ElementDBAdmin](c, "elements", "*"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else {
+		stored := make(map[string]*ElementDBAdmin, len(res))
+		for ii := range res {
+			stored[res[ii].Mid] = &res[ii]
+		}
+
+		// the valid elements in their order, followed by stored elements outside of the rules
+		mids := allMids()
+		for _, mid := range slices.Sorted(maps.Keys(stored)) {
+			if !slices.Contains(mids, mid) {
+				mids = append(mids, mid)
+			}
+		}
+
+		inPlant := plantFilter(c)
+
+		overview := AdminElements{
+			Page:     page,
+			PerPage:  perPage,
+			Elements: []AdminElement{},
+		}
+
+		for _, mid := range mids {
+			if !inPlant(mid) {
+				continue
+			}
+
+			entry := overviewElement(mid, stored[mid])
+
+			if state != "" && entry.State != state {
+				continue
+			}
+
+			if overview.Total >= (page-1)*perPage && len(overview.Elements) < perPage {
+				overview.Elements = append(overview.Elements, entry)
+			}
+
+			overview.Total++
+		}
+
+		response.Data = overview
+	}
+
+	return response
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// returns the plant an element belongs to, empty if no plants are configured
+// or the element belongs to none
+func plantOf(mid string) string {
+	mid, _ = certs.SplitShare(mid)
+
+	if parts, ok := config.Mids.Parse(mid); ok {
+		for _, plant := range config.Plants {
+			if slices.Contains(plant.Elements, parts.Descriptor) {
+				return plant.ID
+			}
+		}
+	}
+
+	return ""
+}
+
+// returns the directory of the certificate-templates of an element, the ones of its
+// element-type take precedence over the ones of its plant
+func certificateTemplates(mid string) string {
+	if templates := certs.TypeOf(mid).Certificates; templates != "" {
+		return templates
+	}
+
+	id := plantOf(mid)
+
+	for _, plant := range config.Plants {
+		if plant.ID == id {
+			return plant.Certificates
+		}
+	}
+
+	return ""
+}
+
+// returns the plants assigned to a user, nil if the user manages all plants
+func userPlants(user UserDB) []string {
+	var plants []string
+
+	for _, plant := range config.Plants {
+		if slices.Contains(plant.Users, user.Name) {
+			plants = append(plants, plant.ID)
+		}
+	}
+
+	return plants
+}
+
+// returns a filter for the elements of the requested plant ("plant" in the query)
+// that are visible to the logged-in user
+func plantFilter(c *fiber.Ctx) func(mid string) bool {
+	requested := c.Query("plant")
+
+	var assigned []string
+	if user, ok := c.Locals(localsUser).(UserDB); ok {
+		assigned = userPlants(user)
+	}
+
+	return func(mid string) bool {
+		if requested == "" && assigned == nil {
+			return true
+		}
+
+		plant := plantOf(mid)
+
+		return (requested == "" || plant == requested) && (assigned == nil || slices.Contains(assigned, plant))
+	}
+}
+
+// rejects requests of users for elements ("mid" in the query or the body) of plants they aren't assigned to
+func RestrictToPlants(c *fiber.Ctx) error {
+	mid := c.Query("mid")
+
+	// invalid identifiers are rejected by the handler, the query is checked nonetheless
+	if ids, err := requestIDs(c); err == nil && ids.Mid != "" {
+		mid = ids.Mid
+	}
+
+	if mid != "" {
+		if plants := userPlants(getUser(c)); plants != nil && !slices.Contains(plants, plantOf(mid)) {
+			logger.Info().Msgf("user %q isn't assigned to the plant of %q", getUser(c).Name, mid)
+
+			return responseMessage{
+				Status:  fiber.StatusForbidden,
+				Message: "element belongs to a plant you aren't assigned to",
+			}.send(c)
+		}
+	}
+
+	return c.Next()
+}
+
+// handles get-requests for the plants
+func getPlants(c *fiber.Ctx) responseMessage {
+	plants := config.Plants
+
+	if plants == nil {
+		plants = []backendConfig.Plant{}
+	}
+
+	return responseMessage{
+		Data: plants,
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// maximum length of the parts of a postal-address
+const maxPostalLength = 100
+
+// postal-codes of the supported countries
+var postalCodeRegex = regexp.MustCompile(`^[0-9A-Za-z -]{3,10}$`)
+
+// stored postal-address in the database, the address is encrypted
+type PostalAddressDB struct {
+	Mid      string
+	Address  []byte
+	Printed  bool
+	Exported *string
+}
+
+// validates the postal-address of a reservation-body, nil if it has none
+func parsePostal(body ReservationBody) (*PostalBody, error) {
+	if body.Postal == nil && !body.Printed {
+		return nil, nil
+	} else if config.PostalKey == nil {
+		return nil, messageErrorf("postal delivery isn't available")
+	} else if body.Postal == nil {
+		return nil, messageErrorf("printed certificate requires a postal address")
+	}
+
+	address := PostalBody{
+		Street:  strings.TrimSpace(body.Postal.Street),
+		Zip:     strings.TrimSpace(body.Postal.Zip),
+		City:    strings.TrimSpace(body.Postal.City),
+		Country: strings.TrimSpace(body.Postal.Country),
+	}
+
+	for _, part := range []string{address.Street, address.City, address.Country} {
+		if utf8.RuneCountInString(part) > maxPostalLength {
+			return nil, messageErrorf("postal address is longer than %d characters", maxPostalLength)
+		}
+	}
+
+	if address.Street == "" || address.Zip == "" || address.City == "" {
+		return nil, messageErrorf("postal address is incomplete")
+	} else if !postalCodeRegex.MatchString(address.Zip) {
+		return nil, messageErrorf("invalid postal code %q", address.Zip)
+	} else {
+		return &address, nil
+	}
+}
+
+// stores the encrypted postal-address of a reservation
+func storePostalAddress(mid string, address PostalBody, printed bool) error {
+	if plaintext, err := json.Marshal(address); err != nil {
+		return err
+	} else if encrypted, err := lib.Encrypt(config.PostalKey, plaintext); err != nil {
+		return err
+	} else {
+		return store.Insert("postaladdresses", struct {
+			Mid     string
+			Address []byte
+			Printed bool
+		}{Mid: mid, Address: encrypted, Printed: printed})
+	}
+}
+
+// decrypts a stored postal-address
+func decryptPostalAddress(row PostalAddressDB) (PostalBody, error) {
+	var address PostalBody
+
+	if plaintext, err := lib.Decrypt(config.PostalKey, row.Address); err != nil {
+		return address, err
+	} else {
+		err := json.Unmarshal(plaintext, &address)
+
+		return address, err
+	}
+}
+
+// removes the addresses whose element was deleted or expired
+func purgePostalAddresses() error {
+	_, err := store.Exec("DELETE FROM postaladdresses WHERE mid NOT IN (SELECT mid FROM elements)")
+
+	return err
+}
+
+// handles get-requests exporting the addresses of the confirmed sponsorships with a printed
+// certificate as csv. Only the ones not marked as sent are included, unless "all" is set
+func getExportAddresses(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	where := "printed"
+	if !c.QueryBool("all") {
+		where += " AND exported IS NULL"
+	}
+
+	if config.PostalKey == nil {
+		response.Status = fiber.StatusNotFound
+		response.Message = "postal delivery isn't available"
+
+		logger.Info().Msg("can't export addresses: postal delivery isn't available")
+	} else if addresses, err := store.Select[PostalAddressDB]("postaladdresses", where+" ORDER BY mid"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get postal-addresses from database: %v", err)
+	} else if elements, err := store.Select[struct {
+		Mid       string
+		Name      string
+		Confirmed *string
+	}]("elements", "confirmed IS NOT NULL"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else {
+		names := make(map[string]string, len(elements))
+		for _, element := range elements {
+			names[element.Mid] = element.Name
+		}
+
+		inPlant := plantFilter(c)
+
+		buf := bytes.Buffer{}
+		writer := csv.NewWriter(&buf)
+		writer.Comma = ';'
+
+		writer.Write([]string{"mid", "name", "street", "zip", "city", "country"})
+
+		for _, row := range addresses {
+			// only confirmed sponsorships get a certificate
+			if name, ok := names[row.Mid]; !ok || !inPlant(row.Mid) {
+				continue
+			} else if address, err := decryptPostalAddress(row); err != nil {
+				logger.Error().Msgf("can't decrypt postal-address of %q: %v", row.Mid, err)
+
+				response.Warnings = append(response.Warnings, "address of "+row.Mid+" can't be decrypted")
+			} else {
+				writer.Write([]string{row.Mid, name, address.Street, address.Zip, address.City, address.Country})
+			}
+		}
+
+		writer.Flush()
+
+		if err := writer.Error(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't write address-export: %v", err)
+		} else {
+			c.Attachment("addresses.csv")
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+			c.SendString(buf.String())
+
+			response.Status = fiber.StatusOK
+		}
+	}
+
+	return response
+}
+
+// handles post-requests marking the addresses of printed certificates as sent,
+// so they're left out of the next export
+func postExportAddresses(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := MidsBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ mids []string }"`)
+	} else if inPlant := plantFilter(c); slices.ContainsFunc(body.Mids, func(mid string) bool { return !inPlant(mid) }) {
+		response.Status = fiber.StatusForbidden
+		response.Message = "element belongs to a plant you aren't assigned to"
+
+		logger.Info().Msg("can't mark addresses as sent: element of another plant")
+	} else {
+		exported := time.Now().Format(time.DateTime)
+
+		for _, mid := range body.Mids {
+			if err := store.Update("postaladdresses", struct{ Exported string }{Exported: exported}, struct{ Mid string }{Mid: mid}); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't mark address of %q as sent: %v", mid, err)
+
+				return response
+			}
+		}
+
+		logger.Info().Msgf("marked %d addresses as sent", len(body.Mids))
+
+		response.Status = fiber.StatusOK
+	}
+
+	return response
+}
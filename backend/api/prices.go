@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+)
+
+// returns the expected donation for an element: the price of the element itself
+// or the one of its type (e.g. "pv" for "pv-a1"). Shares cost their fraction of the element
+func elementPrice(mid string) float64 {
+	price := config.ConfigYaml.Prices.Types[config.Mids.Type(mid)]
+
+	base, _ := certs.SplitShare(mid)
+	if elementPrice, ok := config.ConfigYaml.Prices.Elements[base]; ok {
+		price = elementPrice
+	}
+
+	if shares := elementShares(mid); shares > 0 {
+		price /= float64(shares)
+	}
+
+	return price
+}
+
+// handles get-requests for the price-list
+func getPrices(c *fiber.Ctx) responseMessage {
+	return responseMessage{
+		Data: PriceList{
+			Types:    config.ConfigYaml.Prices.Types,
+			Elements: config.ConfigYaml.Prices.Elements,
+		},
+	}
+}
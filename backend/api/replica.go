@@ -0,0 +1,16 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// reads get-requests from the read-replica. Other requests return the lists
+// after they wrote to the database, so they read from the primary to see their change
+func selectForRead[T any](c *fiber.Ctx, table string, where string, args ...any) ([]T, error) {
+	if c.Method() == fiber.MethodGet {
+		return store.SelectReplica[T](table, where, args...)
+	} else {
+		return store.Select[T](table, where, args...)
+	}
+}
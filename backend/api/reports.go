@@ -0,0 +1,123 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// follows the renumberings of a mid to the last one, regardless of wether the element still exists
+func latestMid(renamed map[string]string, mid string) string {
+	for range maxAliasDepth {
+		if next, ok := renamed[mid]; ok {
+			mid = next
+		} else {
+			break
+		}
+	}
+
+	return mid
+}
+
+// reconstructs the sponsorships confirmed before the cutoff ("YYYY-MM-DD HH:MM:SS") from the archive
+// and the deletions of the audit-log. Sponsorships confirmed before the archive existed are taken from the
+// elements, deletions before the audit-log existed aren't known
+func sponsorshipSnapshot(cutoff string, inPlant func(mid string) bool) (SponsorshipSnapshot, error) {
+	snapshot := SponsorshipSnapshot{Mids: []string{}}
+
+	archive, err := store.Select[ArchivedSponsorship]("sponsorships_archive", "confirmed < ? ORDER BY confirmed, aid", cutoff)
+	if err != nil {
+		return snapshot, err
+	}
+
+	deletions, err := store.Select[AuditDB]("audit", "action = ? AND created < ?", auditDelete, cutoff)
+	if err != nil {
+		return snapshot, err
+	}
+
+	aliases, err := store.Select[MidAlias]("midaliases", "aid > 0 ORDER BY aid")
+	if err != nil {
+		return snapshot, err
+	}
+
+	elements, err := store.Select[struct {
+		Mid       string
+		Confirmed *string
+	}]("elements", "reservation IS NULL AND confirmed < ?", cutoff)
+	if err != nil {
+		return snapshot, err
+	}
+
+	renamed := map[string]string{}
+	for _, alias := range aliases {
+		renamed[alias.Old] = alias.New
+	}
+
+	// last confirmation of each element and its expected donation, by the last mid of the element
+	confirmed := map[string]string{}
+	amounts := map[string]float64{}
+
+	for _, sponsorship := range archive {
+		mid := latestMid(renamed, sponsorship.Mid)
+
+		confirmed[mid] = sponsorship.Confirmed
+		amounts[mid] = sponsorship.Amount
+	}
+
+	for _, element := range elements {
+		if _, ok := confirmed[element.Mid]; !ok && element.Confirmed != nil {
+			confirmed[element.Mid] = *element.Confirmed
+			amounts[element.Mid] = elementPrice(element.Mid)
+		}
+	}
+
+	// a deletion after the last confirmation ends the sponsorship
+	for _, deletion := range deletions {
+		mid := latestMid(renamed, deletion.Mid)
+
+		if at, ok := confirmed[mid]; ok && deletion.Created >= at {
+			delete(confirmed, mid)
+		}
+	}
+
+	for mid := range confirmed {
+		if inPlant(mid) {
+			snapshot.Mids = append(snapshot.Mids, mid)
+			snapshot.Amount += amounts[mid]
+		}
+	}
+
+	slices.Sort(snapshot.Mids)
+
+	snapshot.Confirmed = len(snapshot.Mids)
+
+	return snapshot, nil
+}
+
+// handles get-requests for the sponsorships confirmed at the end of a day ("date" in the query,
+// "YYYY-MM-DD"), e.g. for the cutoff of the accounting at the end of the year
+func getReportsSnapshot(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	date := c.Query("date")
+
+	if day, err := time.ParseInLocation(time.DateOnly, date, time.Local); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid date %q"
+		response.Args = []any{date}
+
+		logger.Info().Msgf("can't create snapshot: invalid date %q", date)
+	} else if snapshot, err := sponsorshipSnapshot(day.AddDate(0, 0, 1).Format(time.DateTime), plantFilter(c)); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't create snapshot of %q: %v", date, err)
+	} else {
+		snapshot.Date = date
+
+		response.Data = snapshot
+	}
+
+	return response
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"github.com/patrickmn/go-cache"
+)
+
+// maximum number of resend-requests per ip-address and per mail-address inside the window
+const (
+	resendsPerIP   = 5
+	resendsPerMail = 2
+	resendWindow   = time.Hour
+)
+
+// counters of the resend-requests per ip- and mail-address
+var resendLimits = cache.New(resendWindow, 10*time.Minute)
+
+// counts a resend-request for the key, returns false if the limit is exceeded
+func allowResend(key string, limit int) bool {
+	if err := resendLimits.Add(key, 1, cache.DefaultExpiration); err == nil {
+		return true
+	} else if count, err := resendLimits.IncrementInt(key, 1); err != nil {
+		return false
+	} else {
+		return count <= limit
+	}
+}
+
+// sends all certificates issued for a mail-address to it again
+func resendCertificates(mail string) {
+	if certificates, err := store.Select[CertificateDB]("certificates", "mailhash = ?", hashMail(mail)); err != nil {
+		logger.Error().Msgf("can't get certificates for resending from database: %v", err)
+	} else {
+		for _, certificate := range certificates {
+			certData := certs.CertificateData{
+				Reservation: certs.ReservationData{
+					Mid:   certificate.Mid,
+					Name:  certificate.Name,
+					Mail:  mail,
+					Texts: reservationTexts(certificate.Mid),
+				},
+				Serial:    certificate.Serial,
+				Code:      certificate.Code,
+				Templates: certificateTemplates(certificate.Mid),
+				Shares:    elementShares(certificate.Mid),
+				Download:  certificateDownloadURL(certificateDownloadBase(nil), certificate.Mid, certificate.Serial, time.Now().Add(config.CertificateLinkExpire)),
+			}
+
+			if err := certData.Create(); err != nil {
+				logger.Error().Msgf("can't create certificate %d for resending: %v", certificate.Serial, err)
+			} else if err := certData.Send(); err != nil {
+				logger.Error().Msgf("can't resend certificate %d: %v", certificate.Serial, err)
+			} else {
+				logger.Info().Msgf("resent certificate %d", certificate.Serial)
+			}
+
+			certData.Cleanup()
+		}
+	}
+}
+
+// handles post-requests of sponsors resending their certificates. The response doesn't
+// tell wether certificates exist for the address, they're sent in the background
+func postCertificatesResend(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := ResendBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ mail string }"`)
+	} else if body.Mail == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "mail is required"
+
+		logger.Info().Msg("can't resend certificates: mail is missing")
+	} else if !allowResend("ip:"+c.IP(), resendsPerIP) || !allowResend("mail:"+hashMail(body.Mail), resendsPerMail) {
+		response.Status = fiber.StatusTooManyRequests
+		response.Message = "too many requests, try again later"
+
+		logger.Info().Msgf("rejected resending of certificates from %q: limit exceeded", c.IP())
+	} else {
+		go resendCertificates(body.Mail)
+
+		response.Status = fiber.StatusAccepted
+		response.Message = "certificates are sent, if there are any for the mail-address"
+	}
+
+	return response
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+func getReservations(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if res, err := selectForRead[ElementDB](c, "elements", "reservation IS NOT NULL"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get reserved elements from database: %v", err)
+	} else if pendingMails, err := mailPendingMids(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get queued mails from database: %v", err)
+	} else if expiries, err := expiryCounts(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get expiries from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+
+		reservations := []Reservation{}
+
+		for _, element := range res {
+			if inPlant(element.Mid) {
+				reservations = append(reservations, Reservation{
+					ElementDB:   element,
+					Amount:      elementPrice(element.Mid),
+					MailPending: pendingMails[element.Mid],
+					Expiries:    expiries[element.Mid],
+					Throttled:   isThrottledCount(expiries[element.Mid]),
+				})
+			}
+		}
+
+		response.Data = reservations
+	}
+
+	return response
+}
+
+func postReservations(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if err := checkCapability(c, capabilityIssueCertificates); err != nil {
+		response.Status = fiber.StatusForbidden
+		response.Message = "missing permission to confirm reservations"
+
+		logger.Info().Msgf("can't confirm reservation: %v", err)
+
+		// check if mid is in query
+	} else if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msg("query doesn't include valid mid")
+	} else if userData, err := store.Select[ElementDB]("elements", "mid = ?", mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't retrieve element-data for %q: %v", mid, err)
+	} else if len(userData) != 1 {
+		response.Status = fiber.StatusNotFound
+		response.Message = "no reservation found"
+
+		logger.Info().Msgf("no element-reservation for %q", mid)
+
+		// confirming again would archive the sponsorship and create its matching-donation twice
+	} else if userData[0].Reservation == nil {
+		response.Status = fiber.StatusConflict
+		response.Message = "reservation is already confirmed"
+
+		logger.Info().Msgf("can't confirm reservation for %q: it is already confirmed", mid)
+	} else if userData[0].Pending {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "reservation isn't approved yet"
+
+		logger.Info().Msgf("can't confirm reservation for %q: reservation isn't approved yet", mid)
+	} else {
+		// create the certificate and send it via e-mail
+		certData := certs.CertificateData{
+			Reservation: certs.ReservationData{
+				Mid:   mid,
+				Name:  userData[0].Name,
+				Mail:  *userData[0].Mail,
+				Texts: elementTexts(userData[0]),
+			},
+			Templates: certificateTemplates(mid),
+			Shares:    elementShares(mid),
+		}
+
+		defer certData.Cleanup()
+
+		confirmed := time.Now().Format(time.DateTime)
+
+		if err := issueLinkedCertificate(&certData, certificateDownloadBase(c)); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while issuing certificate"
+
+			logger.Error().Msgf("can't issue certificate for %q: %v", mid, err)
+		} else if err := certData.Create(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while creating certificate"
+
+			logger.Error().Msgf("can't create certificate for %q: %v", mid, err)
+		} else if err := certData.Send(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "error while sending certificate"
+
+			logger.Error().Msgf("can't send certificate for %q: %v", mid, err)
+
+			notify(roleAdmin, notificationMailFailed, mid, fmt.Sprintf("certificate for %q couldn't be sent", mid))
+		} else if err := store.Transaction(func(tx *store.Tx) error {
+			// the confirmation and its archive-entry are only written together
+			if err := tx.Update("elements", struct {
+				Reservation *string
+				Mail        *string
+				Confirmed   string
+			}{
+				Mail:      retainedMail(userData[0].Mail),
+				Confirmed: confirmed,
+			}, struct{ Mid string }{Mid: mid}); err != nil {
+				return fmt.Errorf("can't write reservation-confirm: %v", err)
+			} else if err := archiveSponsorship(tx, userData[0], getUser(c), certData.Serial, confirmed); err != nil {
+				return fmt.Errorf("can't archive sponsorship: %v", err)
+			} else {
+				return nil
+			}
+		}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't store confirmation of %q: %v", mid, err)
+		} else {
+			if err := autoApproveName(mid, userData[0].Name, *userData[0].Mail); err != nil {
+				logger.Error().Msgf("can't approve name of %q automatically: %v", mid, err)
+			}
+
+			if err := createMatching(userData[0]); err != nil {
+				logger.Error().Msgf("can't create matching-donation of %q: %v", mid, err)
+			}
+
+			cachedElements.Delete("status")
+
+			recordAudit(c, auditConfirm, mid)
+
+			response = getReservations(c)
+		}
+	}
+
+	return response
+}
+
+// returns the mail-address to keep after the confirmation. It is only needed
+// for the thank-you-mail, otherwise it is removed
+func retainedMail(mail *string) *string {
+	if config.ConfigYaml.ThankYou.Enabled {
+		return mail
+	} else {
+		return nil
+	}
+}
+
+func deleteReservations(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	// check for mid in query
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msg("query doesn't include valid mid")
+	} else if isDryRun(c) {
+		response = dryRunResponse(elementDeletion(mid, true))
+	} else {
+		if err := store.Delete("elements", struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("error while removing reservation for element %q from database: %v", mid, err)
+		} else if err := store.Delete("mailqueue", struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't remove queued mails of element %q: %v", mid, err)
+		} else if err := revokeCertificates(mid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't revoke certificates of element %q: %v", mid, err)
+		} else {
+			cachedElements.Delete("status")
+
+			recordAudit(c, auditDelete, mid)
+
+			response = getReservations(c)
+		}
+	}
+
+	return response
+}
+
+func patchReservations(c *fiber.Ctx) responseMessage {
+	body := NameBody{}
+
+	// the mid can be in the query or the body
+	ids, response := bindRequest(c, "mid", &body)
+
+	if response.Status == 0 {
+		// update the database with the new name
+		store.Update("elements", body, struct{ Mid string }{Mid: ids.Mid})
+
+		cachedElements.Delete("status")
+
+		response = getReservations(c)
+	}
+
+	return response
+}
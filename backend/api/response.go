@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// general message for REST-responses
+type responseMessage struct {
+	Status int
+	// english message, translated for the client. Placeholders are filled with the args
+	Message string
+	Args    []any
+	Data    any
+	// non-fatal problems while handling the request, sent to the client in the warnings-header
+	// and in the body, if it is a JSON-object
+	Warnings []string
+}
+
+// header the warnings of a response are sent in, as JSON-array
+const warningsHeader = "X-Warnings"
+
+// key of the warnings in the bodies that are JSON-objects
+const warningsKey = "warnings"
+
+// adds the warnings to the data, if it is sent as JSON-object. Other bodies, e.g. lists, keep
+// their shape, their warnings are only in the header
+func withWarnings(data any, warnings []string) any {
+	if len(warnings) == 0 {
+		return data
+	}
+
+	var object map[string]json.RawMessage
+
+	if encoded, err := json.Marshal(data); err != nil {
+		return data
+	} else if err := json.Unmarshal(encoded, &object); err != nil || object == nil {
+		return data
+	} else if encoded, err := json.Marshal(warnings); err != nil {
+		return data
+	} else {
+		object[warningsKey] = encoded
+
+		return object
+	}
+}
+
+// answer the client request with the response-message
+func (result responseMessage) send(c *fiber.Ctx) error {
+	if len(result.Warnings) > 0 {
+		if warnings, err := json.Marshal(result.Warnings); err != nil {
+			logger.Error().Msgf("can't encode warnings: %v", err)
+		} else {
+			c.Set(warningsHeader, string(warnings))
+		}
+	}
+
+	// translate the message and send its code along
+	message := result.Message
+	if message != "" {
+		c.Set(messageCodeHeader, messageCode(message))
+
+		message = translateMessage(c, message, result.Args)
+	}
+
+	// if the status-code is in the error-region, return an error
+	if result.Status >= 400 {
+		// if available, include the message
+		if message != "" {
+			return fiber.NewError(result.Status, message)
+		} else {
+			return fiber.NewError(result.Status)
+		}
+	} else {
+		// if there is data, send it as JSON
+		if result.Data != nil {
+			c.JSON(withWarnings(result.Data, result.Warnings))
+
+			// if there is a message, send it instead
+		} else if message != "" {
+			c.SendString(message)
+		}
+
+		return c.SendStatus(result.Status)
+	}
+}
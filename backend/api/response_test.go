@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// the warnings are sent in the header and, for JSON-objects, in the body
+func TestSendWarnings(t *testing.T) {
+	warnings := []string{"mail couldn't be sent"}
+
+	for _, tc := range []struct {
+		name   string
+		data   any
+		inBody bool
+	}{
+		{"object", MidCheck{Mid: "pv-a1"}, true},
+		{"list", []string{"pv-a1"}, false},
+	} {
+		app := testApp(fiber.MethodGet, "/api/test", func(c *fiber.Ctx) responseMessage {
+			return responseMessage{Data: tc.data, Warnings: warnings}
+		})
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/test", nil), -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		if header := res.Header.Get(warningsHeader); header != `["mail couldn't be sent"]` {
+			t.Errorf("%s: warnings-header is %q", tc.name, header)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+
+		var object map[string]json.RawMessage
+		json.Unmarshal(body, &object)
+
+		if _, found := object[warningsKey]; found != tc.inBody {
+			t.Errorf("%s: warnings in the body: %t, expected %t: %s", tc.name, found, tc.inBody, body)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// cache of the responses of the public endpoints by their url
+var responseCache *cache.Cache
+
+// concurrent requests for an uncached url wait for a single handler-call
+var responseRefresh singleflight.Group
+
+// caches the successful responses of a handler for "cache.responses", so traffic-spikes
+// don't reach the database. The clients are allowed to cache them for the same time
+func cacheResponse(handler func(*fiber.Ctx) responseMessage) func(*fiber.Ctx) responseMessage {
+	return func(c *fiber.Ctx) responseMessage {
+		if config.Cache.Responses <= 0 {
+			return handler(c)
+		}
+
+		key := c.OriginalURL()
+
+		if cached, found := responseCache.Get(key); found {
+			c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(config.Cache.Responses.Seconds())))
+
+			return cached.(responseMessage)
+		}
+
+		result, _, _ := responseRefresh.Do(key, func() (any, error) {
+			response := handler(c)
+
+			if response.Status < 400 && response.Data != nil {
+				responseCache.SetDefault(key, response)
+			}
+
+			return response, nil
+		})
+
+		response := result.(responseMessage)
+
+		if response.Status < 400 && response.Data != nil {
+			c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(config.Cache.Responses.Seconds())))
+		}
+
+		return response
+	}
+}
+
+// removes the cached responses of an endpoint, e.g. after its data changed
+func dropCachedResponses(path string) {
+	if responseCache == nil {
+		return
+	}
+
+	for key := range responseCache.Items() {
+		if key == path || strings.HasPrefix(key, path+"?") {
+			responseCache.Delete(key)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+)
+
+// job that is run periodically in the background
+type scheduledJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+
+	// state of the runs, guarded by the mutex
+	sync.Mutex
+	running  bool
+	lastRun  time.Time
+	duration time.Duration
+	outcome  string
+	err      error
+	nextRun  time.Time
+	runs     int
+	failures int
+}
+
+// jobs registered with the scheduler
+var scheduledJobs []*scheduledJob
+
+// registers a job to be run periodically once the scheduler is started
+func registerJob(name string, interval time.Duration, run func() error) {
+	scheduledJobs = append(scheduledJobs, &scheduledJob{
+		Name:     name,
+		Interval: interval,
+		Run:      run,
+	})
+}
+
+// returns a registered job by its name, nil if it doesn't exist
+func findJob(name string) *scheduledJob {
+	if ii := slices.IndexFunc(scheduledJobs, func(job *scheduledJob) bool { return job.Name == name }); ii < 0 {
+		return nil
+	} else {
+		return scheduledJobs[ii]
+	}
+}
+
+// runs a job once, recovering from panics so the scheduler keeps running.
+// Returns false without running it, if the job is already running
+func (job *scheduledJob) runOnce() bool {
+	job.Lock()
+	if job.running {
+		job.Unlock()
+
+		logger.Warn().Msgf("skipped job %q: it is still running", job.Name)
+
+		return false
+	}
+	job.running = true
+	job.Unlock()
+
+	logger.Debug().Msgf("running job %q", job.Name)
+
+	start := time.Now()
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panicked: %v", r)
+			}
+		}()
+
+		return job.Run()
+	}()
+
+	job.Lock()
+	defer job.Unlock()
+
+	job.running = false
+	job.lastRun = start
+	job.duration = time.Since(start)
+	job.err = err
+	job.runs++
+
+	if err != nil {
+		job.outcome = "failed"
+		job.failures++
+
+		logger.Error().Msgf("job %q failed: %v", job.Name, err)
+	} else {
+		job.outcome = "succeeded"
+	}
+
+	return true
+}
+
+// returns the state of a job
+func (job *scheduledJob) status() JobStatus {
+	job.Lock()
+	defer job.Unlock()
+
+	status := JobStatus{
+		Name:     job.Name,
+		Interval: job.Interval.Seconds(),
+		Running:  job.running,
+		Outcome:  job.outcome,
+		Runs:     job.runs,
+		Failures: job.failures,
+	}
+
+	if !job.lastRun.IsZero() {
+		status.LastRun = lib.Ptr(job.lastRun.Format(time.DateTime))
+		status.Duration = job.duration.Seconds()
+	}
+
+	if job.err != nil {
+		status.Error = lib.Ptr(job.err.Error())
+	}
+
+	if !job.nextRun.IsZero() {
+		status.NextRun = lib.Ptr(job.nextRun.Format(time.DateTime))
+	}
+
+	return status
+}
+
+// starts all registered jobs in the background
+func startScheduler() {
+	for _, job := range scheduledJobs {
+		go func() {
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			job.Lock()
+			job.nextRun = time.Now().Add(job.Interval)
+			job.Unlock()
+
+			job.runOnce()
+
+			for tick := range ticker.C {
+				job.Lock()
+				job.nextRun = tick.Add(job.Interval)
+				job.Unlock()
+
+				job.runOnce()
+			}
+		}()
+
+		logger.Info().Msgf("scheduled job %q every %v", job.Name, job.Interval)
+	}
+}
+
+// handles get-requests for the state of the background-jobs
+func getAdminJobs(c *fiber.Ctx) responseMessage {
+	jobs := make([]JobStatus, len(scheduledJobs))
+
+	for ii, job := range scheduledJobs {
+		jobs[ii] = job.status()
+	}
+
+	return responseMessage{
+		Data: jobs,
+	}
+}
+
+// handles post-requests running a background-job immediately. The request waits for the job,
+// its schedule stays unchanged
+func postAdminJobsRun(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	name := c.Query("name")
+
+	if job := findJob(name); job == nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "job doesn't exist"
+
+		logger.Info().Msgf("can't run job %q: it doesn't exist", name)
+	} else if !job.runOnce() {
+		response.Status = fiber.StatusConflict
+		response.Message = "job is already running"
+	} else {
+		logger.Info().Msgf("%q ran job %q", getUser(c).Name, name)
+
+		response.Data = job.status()
+	}
+
+	return response
+}
@@ -0,0 +1,443 @@
+// Package api implements the REST-api of the backend. Its request- and response-bodies
+// are shared with the client-package.
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/notifier"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+)
+
+// configuration of the running server
+var config backendConfig.ConfigStruct
+
+var logger zerolog.Logger
+
+// cache for database
+var dbCache *cache.Cache
+
+// REST-server of the backend
+//
+// the handlers share package-level state, so only one server can exist per process
+type Server struct {
+	app *fiber.App
+	// app with only the public endpoints, nil unless "server.admin_listen" is configured
+	publicApp *fiber.App
+}
+
+// creates the server: connects to the database, restores the persisted settings
+// and registers the endpoints and background-jobs
+func NewServer(cfg backendConfig.ConfigStruct) (*Server, error) {
+	config = cfg
+	logger = backendConfig.NewLogger(cfg).Hook(recentErrors)
+
+	logVersion()
+
+	// setup the database-connection
+	if err := store.Open(cfg, logger); err != nil {
+		return nil, err
+	}
+
+	// setup the cache
+	dbCache = cache.New(config.Cache.Expiration, config.Cache.Purge)
+	responseCache = cache.New(config.Cache.Responses, config.Cache.Purge)
+
+	certificateSlots = make(chan struct{}, certificateConcurrency())
+
+	if err := lib.SetLocale(cfg.Locale); err != nil {
+		return nil, err
+	} else if err := mailer.Init(cfg); err != nil {
+		return nil, err
+	} else if err := certs.Init(cfg); err != nil {
+		return nil, err
+	} else if err := notifier.Init(cfg, logger); err != nil {
+		return nil, err
+	}
+
+	// restore the maintenance-mode
+	if err := loadMaintenance(); err != nil {
+		logger.Error().Msgf("can't load maintenance-mode: %v", err)
+	}
+
+	// restore the features switched at runtime
+	if err := loadFeatures(); err != nil {
+		logger.Error().Msgf("can't load features: %v", err)
+	}
+
+	// restore the hidden elements
+	if err := loadHiddenElements(); err != nil {
+		logger.Error().Msgf("can't load hidden elements: %v", err)
+	}
+
+	// self-check of the rules of the mids with the configured examples
+	if problems := checkMidExamples(); len(problems) > 0 {
+		return nil, fmt.Errorf("examples of \"validate_elements.valid_elements\" don't match the rules:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+
+	if err := loadDisposableDomains(); err != nil {
+		return nil, fmt.Errorf("can't load blocklist of the mail-domains: %v", err)
+	}
+
+	// setup fiber
+	fiberConfig := fiber.Config{
+		AppName:               "johannes-pv",
+		DisableStartupMessage: true,
+	}
+
+	// behind reverse-proxies, the client-address is taken from their header
+	if len(config.ConfigYaml.Server.TrustedProxies) > 0 {
+		fiberConfig.EnableTrustedProxyCheck = true
+		fiberConfig.TrustedProxies = config.ConfigYaml.Server.TrustedProxies
+		fiberConfig.ProxyHeader = config.ConfigYaml.Server.ProxyHeader
+		fiberConfig.EnableIPValidation = true
+
+		if fiberConfig.ProxyHeader == "" {
+			fiberConfig.ProxyHeader = fiber.HeaderXForwardedFor
+		}
+	}
+
+	app := fiber.New(fiberConfig)
+
+	// handler-functions of the individual endpoints, grouped by method and address
+	type endpoints map[string]map[string]func(*fiber.Ctx) responseMessage
+
+	// route-groups with the middleware protecting them
+	routeGroups := []struct {
+		middleware []fiber.Handler
+		endpoints  endpoints
+	}{
+		// public endpoints
+		{
+			middleware: []fiber.Handler{RejectDuringMaintenance, CheckAPIKey},
+			endpoints: endpoints{
+				"GET": {
+					"elements":              getElements,
+					"public/yield":          cacheResponse(getYield),
+					"public/yield/element":  cacheResponse(getElementYield),
+					"certificates/verify":   cacheResponse(getCertificatesVerify),
+					"public/prices":         getPrices,
+					"elements/resolve":      getElementsResolve,
+					"elements/status":       getElementsStatus,
+					"elements/next-free":    requireFeature("next-free", getElementsNextFree),
+					"public/fields":         getFields,
+					"public/legal":          getLegal,
+					"public/branding":       getBranding,
+					"public/plants":         getPlants,
+					"public/feed.xml":       getFeed,
+					"public/mail/suggest":   getMailSuggestion,
+					"user/mail/verify":      getUserMailVerify,
+					"version":               getVersion,
+					"certificates/download": getCertificatesDownload,
+				},
+				"POST": {
+					"elements":            postElements,
+					"certificates/resend": postCertificatesResend,
+					"v2/elements":         requireFeature("v2-api", postElementsV2),
+					"user/password/reset": postUserPasswordReset,
+				},
+				"PATCH": {
+					"user/password/reset": patchUserPasswordReset,
+				},
+				"DELETE": {
+					"newsletter": deleteNewsletter,
+				},
+			},
+		},
+		// endpoints for logged-in users
+		{
+			middleware: []fiber.Handler{RequireUser, RestrictToPlants},
+			endpoints: endpoints{
+				"GET": {
+					"reservations":   getReservations,
+					"sponsorships":   getSponsorships,
+					"certificates":   getCertificates,
+					"stats":          getStats,
+					"notifications":  getNotifications,
+					"elements/admin": getElementsAdmin,
+				},
+				"POST": {
+					"reservations": postReservations,
+				},
+				"PATCH": {
+					"elements":            patchElements,
+					"elements/notes":      patchElementNotes,
+					"user/password":       patchUserPassword,
+					"user/mail":           patchUserMail,
+					"reservations":        patchReservations,
+					"sponsorships":        patchSponsorships,
+					"sponsorships/optout": patchSponsorshipsOptOut,
+					"notifications/read":  patchNotificationsRead,
+				},
+				"DELETE": {
+					"elements":     deleteElements,
+					"reservations": deleteReservations,
+					"sponsorships": deleteSponsorships,
+				},
+			},
+		},
+		// endpoints for the admin
+		{
+			middleware: []fiber.Handler{RequireAdmin, RestrictToPlants},
+			endpoints: endpoints{
+				"GET": {
+					"users":                getUsers,
+					"newsletter":           getNewsletter,
+					"admin/maintenance":    getMaintenance,
+					"admin/errors":         getAdminErrors,
+					"admin/logs":           getAdminLogs,
+					"admin/cache":          getAdminCache,
+					"admin/database":       getAdminDatabase,
+					"admin/consistency":    getAdminConsistency,
+					"admin/certificates":   getAdminCertificates,
+					"admin/jobs":           getAdminJobs,
+					"admin/config/schema":  getAdminConfigSchema,
+					"admin/mails":          getAdminMails,
+					"export/datev":         getExportDatev,
+					"export/addresses":     getExportAddresses,
+					"export/shortlinks":    getExportShortlinks,
+					"reports/snapshot":     getReportsSnapshot,
+					"reservations/clients": getReservationsClients,
+					"campaigns":            getCampaigns,
+					"campaigns/report":     getCampaignsReport,
+					"elements/aliases":     getElementsAliases,
+					"elements/visibility":  getElementsVisibility,
+					"elements/check":       getElementsCheck,
+					"apikeys":              getAPIKeys,
+					"apikeys/usage":        getAPIKeysUsage,
+					"consents":             getConsents,
+					"sponsors/duplicates":  getDuplicates,
+					"sponsors/merges":      getMerges,
+					"moderation/names":     getModerationNames,
+					"matchings":            requireFeature("matchings", getMatchings),
+					"admin/features":       getAdminFeatures,
+					"sponsorships/archive": getSponsorshipsArchive,
+					"certificates/preview": getCertificatesPreview,
+					"certificates/link":    getCertificatesLink,
+				},
+				"POST": {
+					"users":                    postUsers,
+					"users/admin":              postUsersAdmin,
+					"users/admin/approve":      postUsersAdminApprove,
+					"reservations/approve":     postReservationsApprove,
+					"reservations/reject":      postReservationsReject,
+					"admin/maintenance":        postMaintenance,
+					"admin/jobs/run":           postAdminJobsRun,
+					"campaigns":                postCampaigns,
+					"elements/renumber":        postElementsRenumber,
+					"elements/generate":        postElementsGenerate,
+					"apikeys":                  postAPIKeys,
+					"sponsors/merges":          postMerges,
+					"moderation/names/approve": postModerationNamesApprove,
+					"matchings/confirm":        requireFeature("matchings", postMatchingsConfirm),
+					"moderation/names/reject":  postModerationNamesReject,
+					"export/addresses":         postExportAddresses,
+				},
+				"PATCH": {
+					"admin/features":      patchAdminFeatures,
+					"users":               patchUsers,
+					"users/capabilities":  patchUsersCapabilities,
+					"elements/visibility": patchElementsVisibility,
+				},
+				"DELETE": {
+					"users":           deleteUsers,
+					"users/admin":     deleteUsersAdmin,
+					"apikeys":         deleteAPIKeys,
+					"sponsors/merges": deleteMerges,
+					"matchings":       requireFeature("matchings", deleteMatchings),
+					"admin/features":  deleteAdminFeatures,
+				},
+			},
+		},
+	}
+
+	// allow the partner-websites to access the api
+	app.Use("/api", APIKeyCORS)
+
+	// handle specific requests special
+	app.Get("/api/welcome", handleWelcome)
+	app.Post("/api/login", handleLogin)
+	app.Get("/api/logout", handleLogout)
+
+	// short-links of the elements for the printed flyers and the qr-stickers on the modules
+	app.Get("/m/:mid", handleShortLink)
+
+	// fault-injection for rehearsing incidents, empty unless built with the "debug"-tag
+	registerChaosEndpoints(app)
+
+	// reset of the elements to demo-data for the development, empty unless built with the "debug"-tag
+	registerDevEndpoints(app)
+
+	// with separate admin-addresses, the public addresses get an app with only the public endpoints
+	var publicApp *fiber.App
+
+	if len(config.AdminListen) > 0 {
+		publicApp = fiber.New(fiberConfig)
+		publicApp.Use("/api", APIKeyCORS)
+		publicApp.Get("/m/:mid", handleShortLink)
+	}
+
+	// register the endpoints of the route-groups
+	for ii, group := range routeGroups {
+		for method, handlers := range group.endpoints {
+			for address, handler := range handlers {
+				// log the request before it passes through the middleware
+				chain := []fiber.Handler{func(c *fiber.Ctx) error {
+					logger.Debug().Msgf("HTTP %s request: %q", c.Method(), c.OriginalURL())
+
+					return c.Next()
+				}}
+
+				chain = append(chain, group.middleware...)
+				chain = append(chain, func(c *fiber.Ctx) error {
+					return handler(c).send(c)
+				})
+
+				app.Add(method, "/api/"+address, chain...)
+
+				// the first route-group holds the public endpoints
+				if publicApp != nil && ii == 0 {
+					publicApp.Add(method, "/api/"+address, chain...)
+				}
+			}
+		}
+	}
+
+	// register the background-jobs
+	if config.ConfigYaml.ThankYou.Enabled {
+		registerJob("thank-you-mails", config.ThankYou.Interval, sendDueThankYouEmails)
+	}
+
+	if config.ConfigYaml.Monitoring.Provider != "" {
+		registerJob("yield-monitoring", config.Monitoring.Interval, cacheYield)
+	}
+
+	if config.ConfigYaml.Export.Path != "" {
+		registerJob("static-export", config.Export.Interval, refreshElementsExport)
+	}
+
+	registerJob("campaign-mails", time.Minute, sendQueuedCampaignMails)
+	registerJob("gift-mails", 15*time.Minute, sendDueGiftEmails)
+	registerJob("notifications", 24*time.Hour, checkNotifications)
+	registerJob("database-check", time.Minute, checkDatabase)
+	registerJob("mail-queue", time.Minute, sendQueuedMails)
+
+	if config.Cache.ConsistencyCheck > 0 {
+		registerJob("cache-consistency", config.Cache.ConsistencyCheck, checkCacheConsistency)
+	}
+
+	if config.Reservation.ClientRetention > 0 {
+		registerJob("reservation-clients", time.Hour, purgeReservationClients)
+	}
+
+	if config.Users.DeactivateAfter > 0 {
+		registerJob("user-deactivation", 24*time.Hour, deactivateInactiveUsers)
+	}
+
+	return &Server{app: app, publicApp: publicApp}, nil
+}
+
+// returns the http-handler of the server with all endpoints, e.g. to serve it through another listener
+func (s *Server) Handler() fasthttp.RequestHandler {
+	return s.app.Handler()
+}
+
+// starts the background-jobs and serves the api on the addresses. With admin-addresses, the
+// addresses only serve the public endpoints and the admin-addresses all of them. Returns
+// with the first error of any of them
+func (s *Server) Listen(addrs, adminAddrs []backendConfig.ListenAddress) error {
+	// the public endpoints have an app of their own only with admin-addresses in the config
+	if len(adminAddrs) > 0 && s.publicApp == nil {
+		return fmt.Errorf("admin-addresses are given, but %q isn't configured", "server.admin_listen")
+	}
+
+	addrs = slices.Concat(addrs, adminAddrs)
+
+	listeners := make([]net.Listener, len(addrs))
+	apps := make([]*fiber.App, len(addrs))
+
+	for ii, addr := range addrs {
+		if ln, err := listen(addr); err != nil {
+			return fmt.Errorf("can't listen on %q: %v", addr, err)
+		} else {
+			listeners[ii] = ln
+		}
+
+		if ii < len(addrs)-len(adminAddrs) && len(adminAddrs) > 0 {
+			apps[ii] = s.publicApp
+		} else {
+			apps[ii] = s.app
+		}
+	}
+
+	startScheduler()
+
+	// tell systemd the service is ready, now that all addresses are open
+	if err := lib.SdNotify("READY=1"); err != nil {
+		logger.Error().Msgf("can't notify systemd: %v", err)
+	}
+
+	go runWatchdog()
+
+	errs := make(chan error, len(listeners))
+
+	for ii, ln := range listeners {
+		if apps[ii] == s.publicApp {
+			logger.Info().Msgf("serving the public api on %q", addrs[ii])
+		} else {
+			logger.Info().Msgf("serving the api on %q", addrs[ii])
+		}
+
+		go func() {
+			errs <- apps[ii].Listener(ln)
+		}()
+	}
+
+	return <-errs
+}
+
+// opens a listen-address. A unix-socket left over from a previous run is replaced
+func listen(addr backendConfig.ListenAddress) (net.Listener, error) {
+	if addr.Network != "unix" {
+		return net.Listen(addr.Network, addr.Address)
+	}
+
+	if err := os.Remove(addr.Address); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	} else if ln, err := net.Listen("unix", addr.Address); err != nil {
+		return nil, err
+	} else if err := os.Chmod(addr.Address, config.SocketMode); err != nil {
+		ln.Close()
+
+		return nil, err
+	} else {
+		return ln, nil
+	}
+}
+
+// signals systemd that the service is alive, twice per watchdog-interval
+func runWatchdog() {
+	interval := lib.WatchdogInterval()
+	if interval <= 0 {
+		return
+	}
+
+	for range time.Tick(interval / 2) {
+		if err := lib.SdNotify("WATCHDOG=1"); err != nil {
+			logger.Error().Msgf("can't notify the watchdog of systemd: %v", err)
+		}
+	}
+}
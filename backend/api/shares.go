@@ -0,0 +1,57 @@
+package api
+
+import (
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// returns the number of shares an element is split into, zero for whole elements
+func elementShares(mid string) int {
+	mid, _ = certs.SplitShare(mid)
+
+	if parts, ok := config.Mids.Parse(mid); !ok {
+		return 0
+	} else {
+		return config.ValidateElements.ValidElements[parts.Descriptor].Shares
+	}
+}
+
+// returns the availability of the shares of all elements split into shares.
+// Pending reservations occupy their share as well
+func shareAvailability(taken map[string]string, occupied ...[]string) map[string]ShareAvailability {
+	availability := map[string]ShareAvailability{}
+
+	for descriptor, rng := range config.ValidateElements.ValidElements {
+		if rng.Shares == 0 {
+			continue
+		}
+
+		for n := rng.From; n <= rng.To; n++ {
+			availability[backendConfig.FormatMid(descriptor, n, rng.Digits)] = ShareAvailability{
+				Total: rng.Shares,
+				Free:  rng.Shares,
+			}
+		}
+	}
+
+	occupy := func(mid string) {
+		if base, share := certs.SplitShare(mid); share > 0 {
+			if shares, ok := availability[base]; ok {
+				shares.Free--
+				availability[base] = shares
+			}
+		}
+	}
+
+	for mid := range taken {
+		occupy(mid)
+	}
+
+	for _, mids := range occupied {
+		for _, mid := range mids {
+			occupy(mid)
+		}
+	}
+
+	return availability
+}
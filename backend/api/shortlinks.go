@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/url"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+)
+
+// returns the address the short-links start with. Without a configured one it is derived from the request
+func shortLinkBase(c *fiber.Ctx) string {
+	if config.ConfigYaml.ShortLinks.BaseURL != "" {
+		return config.ConfigYaml.ShortLinks.BaseURL
+	} else {
+		return c.BaseURL()
+	}
+}
+
+// creates the short-link of an element
+func shortLinkURL(base, mid string) string {
+	return base + "/m/" + url.PathEscape(mid)
+}
+
+// creates the address of the website with the element preselected, without one for an empty mid
+func shortLinkTarget(mid string) string {
+	target, err := url.Parse(config.ConfigYaml.ShortLinks.FrontendURL)
+
+	// the url is checked while loading the config
+	if err != nil || mid == "" {
+		return config.ConfigYaml.ShortLinks.FrontendURL
+	}
+
+	query := target.Query()
+	query.Set("mid", mid)
+	target.RawQuery = query.Encode()
+
+	return target.String()
+}
+
+// wether the mid of a short-link is a valid element. Elements split into shares have a single
+// short-link without a share
+func isShortLinkMid(mid string) bool {
+	if valid, err := isValidMid(mid); err == nil && valid {
+		return true
+	} else if _, share := certs.SplitShare(mid); share == 0 {
+		valid, err := isValidMid(mid + certs.ShareSeparator + "1")
+
+		return err == nil && valid
+	} else {
+		return false
+	}
+}
+
+// handles the short-links of the elements by redirecting to the website with the element preselected.
+// Renumbered elements are resolved, unknown ones lead to the website without a preselection, so
+// scanning an outdated sticker doesn't end in an error-page
+func handleShortLink(c *fiber.Ctx) error {
+	logger.Debug().Msgf("HTTP %s request: %q", c.Method(), c.OriginalURL())
+
+	if config.ConfigYaml.ShortLinks.FrontendURL == "" {
+		return responseMessage{
+			Status:  fiber.StatusNotFound,
+			Message: "short-links aren't enabled",
+		}.send(c)
+	}
+
+	mid, err := url.PathUnescape(c.Params("mid"))
+	if err != nil {
+		mid = ""
+	}
+
+	if current, err := resolveMid(mid); err != nil {
+		logger.Error().Msgf("can't resolve mid %q of short-link: %v", mid, err)
+
+		mid = ""
+	} else if !isShortLinkMid(current) || isHidden(current) {
+		logger.Info().Msgf("short-link of invalid mid %q redirects to the website", mid)
+
+		mid = ""
+	} else {
+		mid = current
+	}
+
+	return c.Redirect(shortLinkTarget(mid), fiber.StatusFound)
+}
+
+// handles get-requests exporting the short-links of the elements as csv, e.g. for the qr-stickers on
+// the modules and the printed flyers. The shares of an element get the link of their element
+func getExportShortlinks(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if config.ConfigYaml.ShortLinks.FrontendURL == "" {
+		response.Status = fiber.StatusNotFound
+		response.Message = "short-links aren't enabled"
+
+		logger.Info().Msg("can't export short-links: they aren't enabled")
+
+		return response
+	}
+
+	base := shortLinkBase(c)
+	inPlant := plantFilter(c)
+
+	buf := bytes.Buffer{}
+	writer := csv.NewWriter(&buf)
+	writer.Comma = ';'
+
+	writer.Write([]string{"mid", "type", "url"})
+
+	mids := []string{}
+	for _, mid := range allMids() {
+		if element, _ := certs.SplitShare(mid); !slices.Contains(mids, element) && inPlant(element) && !isHidden(element) {
+			mids = append(mids, element)
+		}
+	}
+
+	for _, mid := range mids {
+		writer.Write([]string{mid, config.Mids.Type(mid), shortLinkURL(base, mid)})
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't write short-link-export: %v", err)
+	} else {
+		c.Attachment("shortlinks.csv")
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.SendString(buf.String())
+
+		response.Status = fiber.StatusOK
+	}
+
+	return response
+}
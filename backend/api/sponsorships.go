@@ -0,0 +1,74 @@
+package api
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+func getSponsorships(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if res, err := selectForRead[ElementDBNoReservation](c, "elements", "reservation IS NULL"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get sponsored elements from database: %v", err)
+	} else {
+		inPlant := plantFilter(c)
+
+		response.Data = slices.DeleteFunc(res, func(element ElementDBNoReservation) bool { return !inPlant(element.Mid) })
+	}
+
+	return response
+}
+
+func deleteSponsorships(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	// check for mid in query
+	if mid := c.Query("mid"); mid == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid mid"
+
+		logger.Info().Msg("query doesn't include valid mid")
+	} else if isDryRun(c) {
+		response = dryRunResponse(elementDeletion(mid, false))
+	} else {
+		if err := store.Delete("elements", struct{ Mid string }{Mid: mid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("error while removing sponsorship for element %q from database: %v", mid, err)
+		} else if err := revokeCertificates(mid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't revoke certificates of element %q: %v", mid, err)
+		} else {
+			cachedElements.Delete("status")
+
+			recordAudit(c, auditDelete, mid)
+
+			response = getSponsorships(c)
+		}
+	}
+
+	return response
+}
+
+func patchSponsorships(c *fiber.Ctx) responseMessage {
+	body := NameBody{}
+
+	// the mid can be in the query or the body
+	ids, response := bindRequest(c, "mid", &body)
+
+	if response.Status == 0 {
+		// update the database with the new name
+		store.Update("elements", body, struct{ Mid string }{Mid: ids.Mid})
+
+		cachedElements.Delete("status")
+
+		response = getSponsorships(c)
+	}
+
+	return response
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// name under which elements without a source are counted
+const unknownSource = "unknown"
+
+func getStats(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	if res, err := selectForRead[ElementDB](c, "elements", "*"); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get elements from database: %v", err)
+	} else {
+		stats := Stats{
+			Sources: make(map[string]SourceStats),
+			Plants:  make(map[string]SourceStats),
+		}
+
+		inPlant := plantFilter(c)
+
+		for _, element := range res {
+			if !inPlant(element.Mid) {
+				continue
+			}
+
+			source := unknownSource
+
+			if element.Source != nil {
+				source = *element.Source
+			}
+
+			sourceStats := stats.Sources[source]
+			plantStats := stats.Plants[plantOf(element.Mid)]
+
+			if element.Reservation != nil {
+				stats.Reserved++
+				sourceStats.Reserved++
+				plantStats.Reserved++
+			} else {
+				stats.Sponsored++
+				sourceStats.Sponsored++
+				plantStats.Sponsored++
+			}
+
+			stats.Sources[source] = sourceStats
+			stats.Plants[plantOf(element.Mid)] = plantStats
+		}
+
+		if matchings, err := selectForRead[Matching](c, "matchings", "maid > 0"); err != nil {
+			logger.Error().Msgf("can't get matchings from database: %v", err)
+		} else {
+			for _, matching := range matchings {
+				if !inPlant(matching.Mid) {
+					continue
+				} else if matching.Confirmed == nil {
+					stats.Matchings.Pending++
+					stats.Matchings.PendingAmount += matching.Amount
+				} else {
+					stats.Matchings.Confirmed++
+					stats.Matchings.ConfirmedAmount += matching.Amount
+				}
+			}
+		}
+
+		response.Data = stats
+
+		logger.Debug().Msg("retrieved stats")
+	}
+
+	return response
+}
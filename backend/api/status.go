@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// states of a reservation as shown to the sponsor
+const (
+	statusPendingApproval = "pending_approval"
+	statusPendingPayment  = "pending_payment"
+	statusConfirmed       = "confirmed"
+	statusExpired         = "expired"
+)
+
+// creates the token authorizing the status-requests of a reservation, as
+// "<reservation-time>-<signature>"
+func reservationStatusToken(mid string, reserved time.Time) string {
+	timestamp := strconv.FormatInt(reserved.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(config.ClientSession.JwtSignature))
+	mac.Write([]byte("status:" + mid + ":" + timestamp))
+
+	return timestamp + "-" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// creates the status-link of a reservation
+func reservationStatusURL(mid string, reserved time.Time) string {
+	query := url.Values{
+		"mid":   {mid},
+		"token": {reservationStatusToken(mid, reserved)},
+	}
+
+	return config.ConfigYaml.Reservation.StatusURL + "?" + query.Encode()
+}
+
+// checks the status-token of a reservation, returns the reservation-time
+func parseReservationStatusToken(mid, token string) (time.Time, error) {
+	if timestamp, _, found := strings.Cut(token, "-"); !found {
+		return time.Time{}, fmt.Errorf("malformed token")
+	} else if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return time.Time{}, fmt.Errorf("malformed token")
+	} else if reserved := time.Unix(unix, 0); !hmac.Equal([]byte(token), []byte(reservationStatusToken(mid, reserved))) {
+		return time.Time{}, fmt.Errorf("invalid token")
+	} else {
+		return reserved, nil
+	}
+}
+
+// handles get-requests of sponsors for the status of their reservation
+func getElementsStatus(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	mid := c.Query("mid")
+
+	if reserved, err := parseReservationStatusToken(mid, c.Query("token")); err != nil {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid status-link"
+
+		logger.Info().Msgf("invalid status-link for %q: %v", mid, err)
+	} else if current, err := resolveMid(mid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't resolve mid %q: %v", mid, err)
+	} else if elements, err := store.Select[struct {
+		Reservation *string
+		Pending     bool
+		Confirmed   *string
+	}]("elements", "mid = ?", current); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't get element %q from database: %v", current, err)
+	} else {
+		expiration := reserved.Add(config.Reservation.Expiration)
+
+		status := ReservationStatus{
+			Mid:      current,
+			Status:   statusExpired,
+			Reserved: reserved.Format(time.DateTime),
+		}
+
+		if len(elements) == 1 {
+			if element := elements[0]; element.Reservation != nil {
+				// the element might be reserved again by someone else after the expiration
+				if *element.Reservation == status.Reserved {
+					status.Expires = lib.Ptr(expiration.Format(time.DateTime))
+
+					if element.Pending {
+						status.Status = statusPendingApproval
+					} else {
+						status.Status = statusPendingPayment
+					}
+				}
+			} else if confirmed := element.Confirmed; confirmed != nil {
+				// a confirmation after the expiration belongs to a later reservation
+				if confirmedAt, err := time.ParseInLocation(time.DateTime, *confirmed, time.Local); err == nil && confirmedAt.Before(expiration) {
+					status.Status = statusConfirmed
+					status.Confirmed = confirmed
+				}
+			}
+		}
+
+		response.Data = status
+	}
+
+	return response
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// default and maximum number of suggested elements
+const (
+	suggestCount    = 1
+	suggestMaxCount = 50
+)
+
+// handles get-requests for the next free elements, optionally of an element-type ("type",
+// the prefix of the mids, e.g. "pv") and a plant ("plant"). "count" sets the number of elements
+func getElementsNextFree(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	elementType := c.Query("type")
+	count := c.QueryInt("count", suggestCount)
+
+	elements, found := cachedElements.Get("status")
+
+	if count < 1 || count > suggestMaxCount {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "count has to be between 1 and %d"
+		response.Args = []any{suggestMaxCount}
+
+		logger.Info().Msgf("can't suggest elements: invalid count %d", count)
+	} else if !found {
+		if err := cacheElements(); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msgf("can't get elements from database: %v", err)
+		} else if elements, found = cachedElements.Get("status"); !found {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't get elements"
+
+			logger.Error().Msg(`can't get "elements" from cache`)
+		}
+	}
+
+	// if the status is still unset, there was no error
+	if response.Status == 0 {
+		inPlant := plantFilter(c)
+		now := time.Now()
+
+		free := []string{}
+
+		for _, mid := range allMids() {
+			if len(free) >= count {
+				break
+			}
+
+			if elementType != "" && config.Mids.Type(mid) != elementType {
+				continue
+			} else if !inPlant(mid) || isHidden(mid) || elementEmbargo(mid, now) != nil {
+				continue
+			} else if _, taken := elements.Taken[mid]; taken {
+				continue
+			} else if slices.Contains(elements.Reserved, mid) || slices.Contains(elements.Pending, mid) {
+				continue
+			}
+
+			free = append(free, mid)
+		}
+
+		response.Data = free
+	}
+
+	return response
+}
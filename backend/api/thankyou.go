@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// data available in the thank-you-templates
+type ThankYouTemplateData struct {
+	certs.SponsorshipTemplateData
+	// yearly yield of the plant in kWh, either measured or estimated
+	PlantYield float64
+	// yield attributed to the element in kWh
+	ElementYield ElementYield
+	// months since the confirmation of the sponsorship
+	Months int
+}
+
+// sends the thank-you-mail for a sponsorship
+func sendThankYouEmail(element ElementDBNoReservation, confirmed time.Time) error {
+	templateData := ThankYouTemplateData{
+		PlantYield: config.ConfigYaml.ThankYou.PlantYield,
+		Months:     int(time.Since(confirmed).Hours() / 24 / 30),
+	}
+	templateData.SponsorshipTemplateData.Populate(element.Mid, element.Name, elementShares(element.Mid))
+
+	// prefer the measured yield over the estimation
+	if yield, found := getCachedYield(); found {
+		templateData.PlantYield = yield.Year
+		templateData.ElementYield = attributeYield(yield, element.Mid)
+	} else {
+		templateData.ElementYield = attributeYield(Yield{Year: templateData.PlantYield}, element.Mid)
+	}
+
+	return mailer.SendTemplate(*element.Mail, "thank_you_mail", templateData)
+}
+
+// sends the thank-you-mails for all sponsorships confirmed longer than the configured delay
+func sendDueThankYouEmails() error {
+	due := time.Now().Add(-config.ThankYou.Delay).Format(time.DateTime)
+
+	if elements, err := store.Select[ElementDBNoReservation]("elements", "reservation IS NULL AND mail IS NOT NULL AND optout = FALSE AND thankyou IS NULL AND confirmed < ?", due); err != nil {
+		return err
+	} else {
+		for _, element := range elements {
+			confirmed, err := time.Parse(time.DateTime, *element.Confirmed)
+			if err != nil {
+				logger.Warn().Msgf("can't parse confirmation-date of %q: %v", element.Mid, err)
+
+				continue
+			}
+
+			if err := sendThankYouEmail(element, confirmed); err != nil {
+				logger.Error().Msgf("can't send thank-you-mail for %q: %v", element.Mid, err)
+
+				notify(roleAdmin, notificationMailFailed, element.Mid, fmt.Sprintf("thank-you-mail for %q couldn't be sent", element.Mid))
+
+				continue
+			}
+
+			// the mail-address isn't needed anymore after the thank-you-mail
+			if err := store.Update("elements", struct {
+				Thankyou string
+				Mail     *string
+			}{Thankyou: time.Now().Format(time.DateTime)}, struct{ Mid string }{Mid: element.Mid}); err != nil {
+				logger.Error().Msgf("can't mark thank-you-mail for %q as sent: %v", element.Mid, err)
+			} else {
+				logger.Info().Msgf("sent thank-you-mail for %q", element.Mid)
+			}
+		}
+
+		return nil
+	}
+}
+
+// handles patch-requests to opt a sponsorship out of the thank-you-mail
+func patchSponsorshipsOptOut(c *fiber.Ctx) responseMessage {
+	body := OptOutBody{}
+
+	ids, response := bindRequest(c, "mid", &body)
+	mid := ids.Mid
+
+	if response.Status != 0 {
+		return response
+	} else if err := store.Update("elements", struct{ Optout bool }{Optout: body.OptOut}, struct{ Mid string }{Mid: mid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't write opt-out for %q to database: %v", mid, err)
+	} else {
+		logger.Debug().Msgf("set thank-you-opt-out for %q to %v", mid, body.OptOut)
+
+		response = getSponsorships(c)
+	}
+
+	return response
+}
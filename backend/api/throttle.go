@@ -0,0 +1,74 @@
+package api
+
+import (
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// expired reservation of an element
+type ExpiryDB struct {
+	Mid         string
+	Reservation string
+}
+
+// records the reservations that expired before the date, so repeated expiries of an
+// element can be throttled. Recording the same reservation again has no effect
+func recordExpiries(expirationDate string) error {
+	if config.ConfigYaml.Reservation.ThrottleExpiries <= 0 {
+		return nil
+	} else if expired, err := store.Select[ExpiryDB]("elements", "reservation IS NOT NULL AND reservation < ?", expirationDate); err != nil {
+		return err
+	} else {
+		for _, expiry := range expired {
+			if _, err := store.Exec("INSERT IGNORE INTO expiries (mid, reservation) VALUES (?, ?)", expiry.Mid, expiry.Reservation); err != nil {
+				return err
+			}
+		}
+
+		// expiries outside of the window aren't needed anymore
+		_, err := store.DeleteBefore("expiries", "expired", time.Now().Add(-config.Reservation.ThrottleWindow).Format(time.DateTime))
+
+		return err
+	}
+}
+
+// retrieves the number of expired reservations within the throttle-window per element
+func expiryCounts() (map[string]int, error) {
+	counts := make(map[string]int)
+
+	if config.ConfigYaml.Reservation.ThrottleExpiries <= 0 {
+		return counts, nil
+	} else if expiries, err := store.Select[ExpiryDB]("expiries", "expired >= ?", time.Now().Add(-config.Reservation.ThrottleWindow).Format(time.DateTime)); err != nil {
+		return nil, err
+	} else {
+		for _, expiry := range expiries {
+			counts[expiry.Mid]++
+		}
+
+		return counts, nil
+	}
+}
+
+// wether the next reservation of an element has to be approved, as its reservations expired too often
+func isThrottled(mid string) (bool, error) {
+	if counts, err := expiryCounts(); err != nil {
+		return false, err
+	} else {
+		return isThrottledCount(counts[mid]), nil
+	}
+}
+
+// wether the number of expiries of an element reaches the threshold
+func isThrottledCount(count int) bool {
+	threshold := config.ConfigYaml.Reservation.ThrottleExpiries
+
+	return threshold > 0 && count >= threshold
+}
+
+// resets the expiries of an element, e.g. after an admin approved its reservation
+func resetExpiries(mid string) error {
+	_, err := store.Exec("DELETE FROM expiries WHERE mid = ?", mid)
+
+	return err
+}
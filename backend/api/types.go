@@ -0,0 +1,869 @@
+package api
+
+import "encoding/json"
+
+// information about an element in the database
+type ElementDB struct {
+	Mid         string  `json:"mid"`
+	Name        string  `json:"name"`
+	Reservation *string `json:"reservation"`
+	Mail        *string `json:"mail"`
+	Source      *string `json:"source"`
+	// internal notes of the volunteers, not publicly visible
+	Notes *string `json:"notes"`
+	// wether the reservation awaits the approval of an admin
+	Pending bool `json:"pending"`
+	// values of the custom fields of the reservation-form
+	Fields json.RawMessage `json:"fields"`
+	// free-text dedication printed on the certificate
+	Dedication *string `json:"dedication"`
+	// buyer of a gift, the name is the one of the recipient
+	Buyer        *string `json:"buyer"`
+	Giftmail     *string `json:"gift_mail"`
+	Giftdelivery *string `json:"gift_delivery"`
+	// name approved for the public display by the moderation
+	Approvedname *string `json:"approved_name"`
+	// employer of the sponsor doubling the donation
+	Matchingemployer  *string `json:"matching_employer"`
+	Matchingreference *string `json:"matching_reference"`
+}
+
+// reservation with the expected donation
+type Reservation struct {
+	ElementDB
+	Amount float64 `json:"amount"`
+	// wether the reservation-mail is queued, as the mail-server wasn't available
+	MailPending bool `json:"mail_pending"`
+	// number of the expired reservations of the element within the throttle-window
+	Expiries int `json:"expiries"`
+	// wether the element needs an approval because of repeated expiries
+	Throttled bool `json:"throttled"`
+}
+
+type ElementDBNoReservation struct {
+	Mid    string  `json:"mid"`
+	Name   string  `json:"name"`
+	Mail   *string `json:"mail"`
+	Source *string `json:"source"`
+	// date of the confirmation of the sponsorship
+	Confirmed *string `json:"confirmed"`
+	// date the thank-you-mail was sent
+	Thankyou *string `json:"thank_you"`
+	// wether the sponsor doesn't want to receive the thank-you-mail
+	Optout bool `json:"optout"`
+	// values of the custom fields of the reservation-form
+	Fields json.RawMessage `json:"fields"`
+	// free-text dedication printed on the certificate
+	Dedication *string `json:"dedication"`
+	// internal notes of the volunteers, not publicly visible
+	Notes *string `json:"notes"`
+	// buyer of a gift, the name is the one of the recipient
+	Buyer        *string `json:"buyer"`
+	Giftmail     *string `json:"gift_mail"`
+	Giftdelivery *string `json:"gift_delivery"`
+	// name approved for the public display by the moderation
+	Approvedname *string `json:"approved_name"`
+}
+
+// client-data of the reserved elements
+type ClientStatus struct {
+	Taken    map[string]string `json:"taken"`
+	Reserved []string          `json:"reserved"`
+	// availability of the elements split into shares, the shares themselves are
+	// listed as "<mid>_<share>" in taken and reserved
+	Shares map[string]ShareAvailability `json:"shares"`
+	// elements that can't be reserved currently, because of an embargo
+	Locked []LockedElements `json:"locked"`
+	// end of the reservations of the reserved elements
+	Expires map[string]string `json:"expires"`
+	// elements hidden from the public, e.g. because they aren't installed yet
+	Hidden []string `json:"hidden"`
+}
+
+// range of elements locked by an embargo
+type LockedElements struct {
+	// descriptor of the elements
+	Elements string `json:"elements"`
+	From     int    `json:"from"`
+	To       int    `json:"to"`
+	// minimum digits of the numbers in the mids, padded with zeros
+	Digits int `json:"digits"`
+	// end of the embargo, null for an open end
+	Until *string `json:"until"`
+}
+
+// availability of the shares of an element
+type ShareAvailability struct {
+	Total int `json:"total"`
+	Free  int `json:"free"`
+}
+
+// changes of the elements since a cursor
+type ElementsDiff struct {
+	// cursor of the current state, to be used for the next request
+	Cursor string `json:"cursor"`
+	// wether the diff contains the complete state, because the cursor was unknown
+	Complete bool              `json:"complete"`
+	Taken    map[string]string `json:"taken"`
+	Reserved []string          `json:"reserved"`
+	// elements that are neither taken nor reserved anymore
+	Free []string `json:"free"`
+	// end of the reservations of the reserved elements in the diff
+	Expires map[string]string `json:"expires"`
+}
+
+// body of a reservation-request
+type ReservationBody struct {
+	Name   string `json:"name"`
+	Mail   string `json:"mail"`
+	Source string `json:"source"`
+	// wether the sponsor wants to receive the newsletter
+	Newsletter bool `json:"newsletter"`
+	// makes the reservation a gift: the certificate is made out to the recipient
+	Gift *GiftBody `json:"gift"`
+	// values of the custom fields of the reservation-form
+	Fields map[string]any `json:"fields"`
+	// free-text dedication printed on the certificate, if enabled in "certificates.fields"
+	Dedication string `json:"dedication"`
+	// accepted versions of the legal documents by their kind
+	Legal map[string]string `json:"legal"`
+	// postal-address of the sponsor, required for a printed certificate
+	Postal *PostalBody `json:"postal"`
+	// wether the sponsor wants the certificate printed and sent by post
+	Printed bool `json:"printed"`
+	// wether the sponsor confirmed the mail-addresses despite a suggested correction
+	ConfirmMail bool `json:"confirm_mail"`
+	// employer of the sponsor doubling the donation
+	Matching *MatchingBody `json:"matching"`
+}
+
+// matching-part of a reservation-request
+type MatchingBody struct {
+	Employer string `json:"employer"`
+	// reference of the matching-program of the employer, e.g. an application-number
+	Reference string `json:"reference"`
+}
+
+// donation of an employer matching the one of a sponsorship
+type Matching struct {
+	Maid      int     `json:"maid"`
+	Mid       string  `json:"mid"`
+	Employer  string  `json:"employer"`
+	Reference *string `json:"reference"`
+	// expected amount, the paid one after the confirmation
+	Amount  float64 `json:"amount"`
+	Created string  `json:"created"`
+	// date of the payment by the employer, nil while it is pending
+	Confirmed *string `json:"confirmed"`
+	// user who confirmed the payment
+	Uid *int `json:"uid"`
+}
+
+// feature that can be switched per deployment
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	// where the state is set: "runtime", "config" or "default"
+	Source string `json:"source"`
+}
+
+// body of a request switching a feature at runtime
+type FeatureBody struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// body of a request confirming a matching-donation
+type MatchingConfirmBody struct {
+	// paid amount, defaults to the expected one
+	Amount *float64 `json:"amount"`
+}
+
+// suggested correction of a mail-address with a probable typo in its domain
+type MailSuggestion struct {
+	Mail string `json:"mail"`
+	// empty, if the domain looks correct
+	Suggestion string `json:"suggestion"`
+}
+
+// postal-address of a reservation-request
+type PostalBody struct {
+	Street  string `json:"street"`
+	Zip     string `json:"zip"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+// body of a request for several elements
+type MidsBody struct {
+	Mids []string `json:"mids"`
+}
+
+// body of a reservation-request of the v2-api, which includes the element
+type ReservationBodyV2 struct {
+	Mid string `json:"mid"`
+	ReservationBody
+}
+
+// gift-part of a reservation-request
+type GiftBody struct {
+	Recipient string `json:"recipient"`
+	// mail-address of the recipient, who receives the certificate on the delivery-date
+	Mail string `json:"mail"`
+	// date ("YYYY-MM-DD") or time ("YYYY-MM-DD HH:MM:SS") of the gift-mail, empty to send it with the confirmation
+	Delivery string `json:"delivery"`
+}
+
+// state of a reservation as shown to the sponsor
+type ReservationStatus struct {
+	Mid string `json:"mid"`
+	// one of "pending_approval", "pending_payment", "confirmed" or "expired"
+	Status   string `json:"status"`
+	Reserved string `json:"reserved"`
+	// end of the reservation, if it is still pending
+	Expires   *string `json:"expires"`
+	Confirmed *string `json:"confirmed"`
+}
+
+// body of a request showing or hiding elements from the public
+type VisibilityBody struct {
+	Mids    []string `json:"mids"`
+	Visible bool     `json:"visible"`
+}
+
+// body of a request generating the elements of a range, e.g. "pv-a" from 1 to 240
+type GenerateElementsBody struct {
+	Prefix string `json:"prefix"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// elements of a generated range by their state
+type GeneratedElements struct {
+	// elements with a reservation or sponsorship
+	Existing []string `json:"existing"`
+	// elements available for reservations
+	Free []string `json:"free"`
+	// elements not covered by the valid elements of the config
+	Conflicts []string `json:"conflicts"`
+}
+
+// body of a request changing the name of an element
+type NameBody struct {
+	Name string `json:"name"`
+}
+
+// name of a sponsor awaiting the moderation before it is shown publicly
+type NameModeration struct {
+	Mid       string  `json:"mid"`
+	Name      string  `json:"name"`
+	Confirmed *string `json:"confirmed"`
+}
+
+// body of a request changing the notes of an element
+type NotesBody struct {
+	Notes string `json:"notes"`
+}
+
+// body of a request rejecting a reservation
+type RejectionBody struct {
+	Reason string `json:"reason"`
+}
+
+// body of a request resending the certificates of a mail-address
+type ResendBody struct {
+	Mail string `json:"mail"`
+}
+
+// body of a request changing a password
+type PasswordBody struct {
+	Password string `json:"password"`
+}
+
+// body of a request of a user changing their own password
+type UserPasswordBody struct {
+	PasswordBody
+	// current password of the user
+	Current string `json:"current"`
+}
+
+// body of a request of a user changing their own mail-address
+type UserMailBody struct {
+	Mail string `json:"mail"`
+}
+
+// body of a request for a password-reset-link
+type PasswordResetRequestBody struct {
+	Name string `json:"name"`
+}
+
+// body of a request setting a new password with a reset-link, the uid is
+// sent in the query or the body
+type PasswordResetBody struct {
+	PasswordBody
+	Token string `json:"token"`
+}
+
+// body of a request opting a sponsorship out of the thank-you-mail
+type OptOutBody struct {
+	OptOut bool `json:"optout"`
+}
+
+// number of reservations and sponsorships of a single source
+type SourceStats struct {
+	Reserved  int `json:"reserved"`
+	Sponsored int `json:"sponsored"`
+}
+
+// statistics about the elements
+type Stats struct {
+	Reserved  int                    `json:"reserved"`
+	Sponsored int                    `json:"sponsored"`
+	Sources   map[string]SourceStats `json:"sources"`
+	// numbers per plant, elements without plant are counted under ""
+	Plants map[string]SourceStats `json:"plants"`
+	// donations of employers matching the sponsorships
+	Matchings MatchingStats `json:"matchings"`
+}
+
+// numbers and amounts of the matching-donations
+type MatchingStats struct {
+	Pending         int     `json:"pending"`
+	PendingAmount   float64 `json:"pending_amount"`
+	Confirmed       int     `json:"confirmed"`
+	ConfirmedAmount float64 `json:"confirmed_amount"`
+}
+
+// request for adding a user
+type AddUserBody struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	// mail-address of the user, a verification-link is sent to it
+	Mail string `json:"mail"`
+	// wether the user may issue certificates and confirm payments
+	IssueCertificates bool `json:"issue_certificates"`
+	// grant the admin-role, pending until another admin confirms it with "users.admin_approval"
+	Admin bool `json:"admin"`
+}
+
+// body of a request changing the capabilities of a user
+type CapabilitiesBody struct {
+	IssueCertificates bool `json:"issue_certificates"`
+}
+
+// user as returned by the users-endpoint
+type User struct {
+	Uid  int     `json:"uid"`
+	Name string  `json:"name"`
+	Mail *string `json:"mail"`
+	// time the mail-address was verified, nil while it isn't
+	Mailverified *string `json:"mail_verified"`
+	// time of the last login and of the last authorized request
+	Lastlogin  *string `json:"last_login"`
+	Lastaction *string `json:"last_action"`
+	// wether the account was deactivated for inactivity
+	Deactivated bool `json:"deactivated"`
+	// wether the user may issue certificates and confirm payments
+	Issuecertificates bool `json:"issue_certificates"`
+	// wether the user has the admin-role
+	Admin bool `json:"admin"`
+	// time and requesting admin of a grant of the admin-role awaiting confirmation, nil if there is none.
+	// For an admin, it is a revocation of the role
+	Adminrequested   *string `json:"admin_requested"`
+	Adminrequestedby *int    `json:"admin_requested_by"`
+}
+
+// actions of a user aggregated from the audit-log
+type UserStatistics struct {
+	Confirmations int `json:"confirmations"`
+	Deletions     int `json:"deletions"`
+	// time of the last recorded action
+	Lastchange *string `json:"last_change"`
+}
+
+// user with the statistics of their actions
+type UserOverview struct {
+	User
+	UserStatistics
+}
+
+// body from a login-request
+type LoginBody struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// data of the logged-in-status
+type UserLogin struct {
+	Uid      int    `json:"uid"`
+	Name     string `json:"name"`
+	LoggedIn bool   `json:"logged_in"`
+}
+
+// yield of a single string or inverter of the plant
+type StringYield struct {
+	Total float64 `json:"total"`
+	Year  float64 `json:"year"`
+}
+
+// yield of the plant in kWh
+type Yield struct {
+	Total   float64                `json:"total"`
+	Year    float64                `json:"year"`
+	Strings map[string]StringYield `json:"strings"`
+	// date of the last update from the monitoring
+	Updated string `json:"updated"`
+}
+
+// share of the yield attributed to a single element in kWh
+type ElementYield struct {
+	Mid   string  `json:"mid"`
+	Total float64 `json:"total"`
+	Year  float64 `json:"year"`
+}
+
+// expected donations in euros per element-type and per element
+type PriceList struct {
+	Types    map[string]float64 `json:"types"`
+	Elements map[string]float64 `json:"elements"`
+}
+
+// selection of the sponsors receiving a campaign
+type CampaignFilter struct {
+	// only elements starting with the prefix, e.g. "pv-a"
+	Prefix string `json:"prefix"`
+	// only sponsorships confirmed in the range, as "YYYY-MM-DD HH:MM:SS"
+	ConfirmedAfter  string `json:"confirmed_after"`
+	ConfirmedBefore string `json:"confirmed_before"`
+	// wether sponsors who opted out of the thank-you-mails are included
+	IncludeOptOut bool `json:"include_optout"`
+}
+
+// body of a request creating a mail-campaign. Subject and body are templates
+// with the fields "Name" and "Elements" of the sponsor
+type CampaignBody struct {
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	Filter  CampaignFilter `json:"filter"`
+}
+
+// recipient of a campaign with the state of the delivery
+type CampaignRecipient struct {
+	Mail     string `json:"mail"`
+	Name     string `json:"name"`
+	Elements string `json:"elements"`
+	// one of "queued", "sent" or "failed"
+	Status string  `json:"status"`
+	Error  *string `json:"error"`
+	Sent   *string `json:"sent"`
+}
+
+// mail-campaign with the summary of the deliveries
+type Campaign struct {
+	Cid     int    `json:"cid"`
+	Subject string `json:"subject"`
+	Created string `json:"created"`
+	Queued  int    `json:"queued"`
+	Sent    int    `json:"sent"`
+	Failed  int    `json:"failed"`
+}
+
+// delivery-report of a mail-campaign
+type CampaignReport struct {
+	Campaign
+	Recipients []CampaignRecipient `json:"recipients"`
+}
+
+// notification of a user about an event
+type Notification struct {
+	Nid     int     `json:"nid"`
+	Kind    string  `json:"kind"`
+	Mid     string  `json:"mid"`
+	Message string  `json:"message"`
+	Created string  `json:"created"`
+	Readat  *string `json:"read_at"`
+}
+
+// renumbering of an element
+type MidAlias struct {
+	Aid     int    `json:"aid"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Renamed string `json:"renamed"`
+	// user who renumbered the element
+	Uid int `json:"uid"`
+}
+
+// current mid of a (possibly renumbered) element
+type ElementAlias struct {
+	Mid string `json:"mid"`
+}
+
+// body of a request creating an api-key for a partner-website
+type APIKeyBody struct {
+	Name string `json:"name"`
+	// origins the key may be used from, empty to allow all
+	Origins []string `json:"origins"`
+	// maximum number of requests per day, zero for unlimited
+	Quota int `json:"quota"`
+}
+
+// newly created api-key, the key is only returned on creation
+type NewAPIKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// api-key with its total usage
+type APIKey struct {
+	Kid          int      `json:"kid"`
+	Name         string   `json:"name"`
+	Origins      []string `json:"origins"`
+	Quota        int      `json:"quota"`
+	Created      string   `json:"created"`
+	Revoked      *string  `json:"revoked"`
+	Requests     int      `json:"requests"`
+	Reservations int      `json:"reservations"`
+}
+
+// usage of an api-key on a day
+type APIKeyUsage struct {
+	Day          string `json:"day"`
+	Requests     int    `json:"requests"`
+	Reservations int    `json:"reservations"`
+}
+
+// consent of a sponsor to a version of a legal document
+type ConsentDB struct {
+	Mail     string `json:"mail"`
+	Mid      string `json:"mid"`
+	Kind     string `json:"kind"`
+	Version  string `json:"version"`
+	Accepted string `json:"accepted"`
+	Ip       string `json:"ip"`
+}
+
+// confirmed sponsorship as it was at the time of the confirmation
+type ArchivedSponsorship struct {
+	Aid  int     `json:"aid"`
+	Mid  string  `json:"mid"`
+	Name string  `json:"name"`
+	Mail *string `json:"mail"`
+	// source of the reservation
+	Source *string `json:"source"`
+	// date of the original reservation
+	Reservation *string `json:"reservation"`
+	Confirmed   string  `json:"confirmed"`
+	// user who confirmed the sponsorship, the name is kept in case the user is deleted
+	Uid      int             `json:"uid"`
+	Username string          `json:"user_name"`
+	Amount   float64         `json:"amount"`
+	Fields   json.RawMessage `json:"fields"`
+	Buyer    *string         `json:"buyer"`
+	Giftmail *string         `json:"gift_mail"`
+	// serial-number of the certificate sent with the confirmation
+	Serial int `json:"serial"`
+}
+
+// sponsorships confirmed at the end of a day
+type SponsorshipSnapshot struct {
+	Date      string `json:"date"`
+	Confirmed int    `json:"confirmed"`
+	// sum of the expected donations of the confirmed elements
+	Amount float64 `json:"amount"`
+	// confirmed elements by their current mid
+	Mids []string `json:"mids"`
+}
+
+// client of a public reservation, recorded for investigating abuse
+type ReservationClient struct {
+	Mid         string `json:"mid"`
+	Reservation string `json:"reservation"`
+	Ip          string `json:"ip"`
+	Useragent   string `json:"useragent"`
+}
+
+// mail queued while the mail-server wasn't available
+type QueuedMail struct {
+	Qid       int     `json:"qid"`
+	Mid       string  `json:"mid"`
+	Recipient string  `json:"recipient"`
+	Subject   string  `json:"subject"`
+	Queued    string  `json:"queued"`
+	Attempts  int     `json:"attempts"`
+	Lasterror *string `json:"last_error"`
+}
+
+// elements probably belonging to the same sponsor
+type DuplicateGroup struct {
+	// "mail" for identical mail-addresses, "name" for similar names
+	Reasons  []string    `json:"reasons"`
+	Elements []ElementDB `json:"elements"`
+}
+
+// body of a request merging duplicate sponsors
+type MergeBody struct {
+	// element whose name and mail-address are kept
+	Canonical string   `json:"canonical"`
+	Mids      []string `json:"mids"`
+}
+
+// contact of an element before a merge
+type MergedContact struct {
+	Mid  string  `json:"mid"`
+	Name string  `json:"name"`
+	Mail *string `json:"mail"`
+}
+
+// merge of duplicate sponsors, it can be undone within the undo-window
+type Merge struct {
+	Mgid      int    `json:"mgid"`
+	Canonical string `json:"canonical"`
+	Merged    string `json:"merged"`
+	// user who merged the sponsors
+	Uid      int             `json:"uid"`
+	Undone   *string         `json:"undone"`
+	Contacts []MergedContact `json:"contacts"`
+}
+
+// issued certificate in the database
+type CertificateDB struct {
+	Serial int
+	Code   string
+	Mid    string
+	Name   string
+	Issued string
+}
+
+// version of the running build
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	Go        string `json:"go"`
+}
+
+// rows of a table a request would change
+type AffectedRows struct {
+	Table string `json:"table"`
+	// "delete", "update" or "insert"
+	Action string `json:"action"`
+	// cascade the rows are changed by, empty for the rows the request targets directly
+	Cause string `json:"cause,omitempty"`
+	Rows  int    `json:"rows"`
+	// primary keys of the rows, empty for inserted ones
+	Keys []string `json:"keys"`
+}
+
+// result of a request with "?dry_run=true", nothing was changed
+type DryRun struct {
+	DryRun   bool           `json:"dry_run"`
+	Affected []AffectedRows `json:"affected"`
+}
+
+// usage of a namespace of the cache since the start
+type CacheMetrics struct {
+	Namespace string `json:"namespace"`
+	// expiration of the values, "-1ns" if they don't expire
+	Expiration string `json:"expiration"`
+	Items      int    `json:"items"`
+	Hits       int64  `json:"hits"`
+	Misses     int64  `json:"misses"`
+	Sets       int64  `json:"sets"`
+	Deletes    int64  `json:"deletes"`
+}
+
+// results of the comparisons of the cached elements with the database since the start
+type ConsistencyMetrics struct {
+	Checks      int64 `json:"checks"`
+	Divergences int64 `json:"divergences"`
+	// time of the last check and of the last divergence, nil if there was none
+	LastCheck      *string `json:"last_check"`
+	LastDivergence *string `json:"last_divergence"`
+	// elements whose cached state differed from the database at the last divergence
+	Mids []string `json:"mids"`
+}
+
+// usage of the renderers of the certificates on request since the start
+type CertificateMetrics struct {
+	Concurrency int   `json:"concurrency"`
+	QueueDepth  int64 `json:"queue_depth"`
+	// certificates currently rendered and requests waiting for a renderer
+	Active int   `json:"active"`
+	Queued int64 `json:"queued"`
+	// rendered certificates and requests rejected because of a full queue
+	Generated int64 `json:"generated"`
+	Rejected  int64 `json:"rejected"`
+	MaxQueued int64 `json:"max_queued"`
+	// longest wait for a renderer in seconds
+	MaxWait       float64 `json:"max_wait"`
+	LastRejection *string `json:"last_rejection"`
+}
+
+// state of a background-job
+type JobStatus struct {
+	Name string `json:"name"`
+	// interval of the scheduled runs in seconds
+	Interval float64 `json:"interval"`
+	Running  bool    `json:"running"`
+	// start of the last run and its duration in seconds
+	LastRun  *string `json:"last_run"`
+	Duration float64 `json:"duration"`
+	// "succeeded" or "failed", empty if the job didn't run yet
+	Outcome string  `json:"outcome"`
+	Error   *string `json:"error"`
+	// next scheduled run, nil if the scheduler isn't started
+	NextRun  *string `json:"next_run"`
+	Runs     int     `json:"runs"`
+	Failures int     `json:"failures"`
+}
+
+// complete entry of an element in the database
+type ElementDBAdmin struct {
+	Mid         string  `json:"mid"`
+	Name        string  `json:"name"`
+	Mail        *string `json:"mail"`
+	Source      *string `json:"source"`
+	Reservation *string `json:"reservation"`
+	Confirmed   *string `json:"confirmed"`
+	Thankyou    *string `json:"thank_you"`
+	Optout      bool    `json:"optout"`
+	// internal notes of the volunteers, not publicly visible
+	Notes   *string `json:"notes"`
+	Pending bool    `json:"pending"`
+	// values of the custom fields of the reservation-form
+	Fields json.RawMessage `json:"fields"`
+	// buyer of a gift, the name is the one of the recipient
+	Buyer        *string `json:"buyer"`
+	Giftmail     *string `json:"gift_mail"`
+	Giftdelivery *string `json:"gift_delivery"`
+}
+
+// element in the overview of all elements
+type AdminElement struct {
+	Mid string `json:"mid"`
+	// "free", "reserved", "pending" (awaiting approval) or "taken"
+	State string `json:"state"`
+	// expected donation
+	Amount float64 `json:"amount"`
+	// wether the element is hidden from the public
+	Hidden bool `json:"hidden"`
+	// expiration of the reservation, nil if the element isn't reserved
+	Expires *string `json:"expires"`
+	// entry of the element in the database, nil for free elements
+	Element *ElementDBAdmin `json:"element"`
+}
+
+// page of the overview of all elements
+type AdminElements struct {
+	// number of elements matching the filters
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PerPage  int            `json:"per_page"`
+	Elements []AdminElement `json:"elements"`
+}
+
+// evaluation of a mid against the rules of the valid elements
+type MidCheck struct {
+	Mid        string `json:"mid"`
+	Valid      bool   `json:"valid"`
+	Descriptor string `json:"descriptor"`
+	Number     int    `json:"number"`
+	// share of the element, zero for whole elements
+	Share int `json:"share"`
+	// explanation, why the mid is valid or not
+	Reason string `json:"reason"`
+}
+
+// faults injected into a debug-build
+type ChaosState struct {
+	// the database fails every query
+	DatabaseDown bool `json:"database_down"`
+	// delay before connecting to the mail-server
+	MailDelay string `json:"mail_delay"`
+	// the cache returns empty values instead of the cached ones
+	CachePoisoned bool `json:"cache_poisoned"`
+	// time the faults are reset automatically, empty if they don't expire
+	Expires string `json:"expires"`
+}
+
+// result of the reset of the development-data of a dev-build
+type DevReset struct {
+	// removed rows by their table
+	Removed map[string]int64 `json:"removed"`
+	// seeded demo-elements by their state
+	Sponsored int `json:"sponsored"`
+	Reserved  int `json:"reserved"`
+	Pending   int `json:"pending"`
+}
+
+// faults to inject into a debug-build
+type ChaosBody struct {
+	DatabaseDown  bool   `json:"database_down"`
+	MailDelay     string `json:"mail_delay"`
+	CachePoisoned bool   `json:"cache_poisoned"`
+	// time after which the faults are reset automatically, empty to keep them
+	Duration string `json:"duration"`
+}
+
+// lock-conflicts of the database-writes since the start of the server
+type DatabaseMetrics struct {
+	Deadlocks    int64 `json:"deadlocks"`
+	LockTimeouts int64 `json:"lock_timeouts"`
+	// retried writes
+	Retries int64 `json:"retries"`
+	// writes that still failed after all retries
+	Failed int64 `json:"failed"`
+}
+
+// paths of the logfiles
+type LogFiles struct {
+	Current string   `json:"current"`
+	Backups []string `json:"backups"`
+}
+
+// signed download-link of a certificate
+type CertificateLink struct {
+	URL     string `json:"url"`
+	Expires string `json:"expires"`
+}
+
+// result of the verification of a certificate
+type CertificateVerification struct {
+	Serial   string `json:"serial"`
+	Mid      string `json:"mid"`
+	Element  string `json:"element"`
+	Issued   string `json:"issued"`
+	Initials string `json:"initials"`
+	// current mid of the element, if it was renumbered since the issuance
+	CurrentMid string `json:"current_mid"`
+}
+
+// newsletter-subscription in the database
+type NewsletterDB struct {
+	Mail string `json:"mail"`
+	Name string `json:"name"`
+	// date of the consent
+	Consent string `json:"consent"`
+	// ip-address the consent was given from
+	Ip string `json:"ip"`
+}
+
+// error-messages of the same kind logged recently
+type ErrorSummary struct {
+	// message with the quoted values and numbers replaced by placeholders
+	Message string `json:"message"`
+	// last message of the kind as it was logged
+	Example   string `json:"example"`
+	Level     string `json:"level"`
+	Count     int    `json:"count"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// state of the maintenance-mode
+type Maintenance struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// time in which a grant of the admin-role has to be confirmed by another admin
+const adminApprovalWindow = 24 * time.Hour
+
+// grants the admin-role to a user. With "users.admin_approval", the grant is only requested
+// and the other admins are notified. Returns wether the grant is pending. For an admin, a pending
+// revocation of the role is withdrawn instead
+func grantAdmin(requester UserDB, user UserDB) (bool, error) {
+	if user.hasRole(roleAdmin) {
+		return false, clearAdminRequest(user.Uid)
+	} else if !config.ConfigYaml.Users.AdminApproval {
+		return false, store.Update("users", struct{ Admin bool }{Admin: true}, struct{ Uid int }{Uid: user.Uid})
+	}
+
+	if err := requestAdminChange(requester, user); err != nil {
+		return false, err
+	}
+
+	notify(roleAdmin, notificationAdminApproval, "", fmt.Sprintf("%q requested the admin-role for %q, another admin has to confirm it within %s", requester.Name, user.Name, adminApprovalWindow))
+
+	return true, nil
+}
+
+// revokes the admin-role of a user. With "users.admin_approval", the revocation is only requested
+// and the other admins are notified, so a single admin can't lock out all the others. Returns
+// wether the revocation is pending. For a non-admin, a pending grant of the role is rejected instead
+func revokeAdmin(requester UserDB, user UserDB) (bool, error) {
+	if !user.hasRole(roleAdmin) || !config.ConfigYaml.Users.AdminApproval {
+		return false, clearAdmin(user.Uid)
+	}
+
+	if err := requestAdminChange(requester, user); err != nil {
+		return false, err
+	}
+
+	notify(roleAdmin, notificationAdminApproval, "", fmt.Sprintf("%q requested to revoke the admin-role of %q, another admin has to confirm it within %s", requester.Name, user.Name, adminApprovalWindow))
+
+	return true, nil
+}
+
+// stores a change of the admin-role of a user awaiting the confirmation of another admin. For
+// an admin it is a revocation, otherwise a grant
+func requestAdminChange(requester UserDB, user UserDB) error {
+	return store.Update("users", struct {
+		Adminrequested   string
+		Adminrequestedby int
+	}{Adminrequested: time.Now().Format(time.DateTime), Adminrequestedby: requester.Uid}, struct{ Uid int }{Uid: user.Uid})
+}
+
+// removes a pending change of the admin-role of a user, the role itself stays as it is
+func clearAdminRequest(uid int) error {
+	_, err := store.Exec("UPDATE users SET adminrequested = NULL, adminrequestedby = NULL WHERE uid = ?", uid)
+
+	return err
+}
+
+// removes the admin-role and a pending grant of it from a user
+func clearAdmin(uid int) error {
+	_, err := store.Exec("UPDATE users SET admin = FALSE, adminrequested = NULL, adminrequestedby = NULL WHERE uid = ?", uid)
+
+	return err
+}
+
+// reads a single user from the database, nil if it doesn't exist
+func selectUser(uid int) (*UserDB, error) {
+	if users, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", uid); err != nil {
+		return nil, err
+	} else if len(users) != 1 {
+		return nil, nil
+	} else {
+		return &users[0], nil
+	}
+}
+
+// handles post-requests granting the admin-role to a user
+func postUsersAdmin(c *fiber.Ctx) responseMessage {
+	ids, response := bindRequest(c, "uid", nil)
+
+	if response.Status != 0 {
+		return response
+	} else if user, err := selectUser(ids.Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if user == nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "user doesn't exist"
+
+		logger.Info().Msgf("can't grant admin-role: user with uid %q doesn't exist", ids.Uid)
+	} else if pending, err := grantAdmin(getUser(c), *user); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't grant admin-role to user with uid = %q: %v", ids.Uid, err)
+	} else {
+		if pending {
+			logger.Info().Msgf("%q requested the admin-role for %q", getUser(c).Name, user.Name)
+		} else {
+			logger.Info().Msgf("%q granted the admin-role to %q", getUser(c).Name, user.Name)
+		}
+
+		response = getUsers(c)
+
+		if pending {
+			response.Status = fiber.StatusAccepted
+			response.Message = "admin-role has to be confirmed by another admin"
+		}
+	}
+
+	return response
+}
+
+// handles post-requests of an admin confirming the pending grant of the admin-role to a user or
+// the pending revocation of it
+func postUsersAdminApprove(c *fiber.Ctx) responseMessage {
+	ids, response := bindRequest(c, "uid", nil)
+
+	approver := getUser(c)
+
+	if response.Status != 0 {
+		return response
+	} else if user, err := selectUser(ids.Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if user == nil || user.Adminrequested == nil || user.Adminrequestedby == nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "no pending admin-role"
+
+		logger.Info().Msgf("can't confirm admin-role: user with uid %q has no pending grant", ids.Uid)
+	} else if *user.Adminrequestedby == approver.Uid {
+		response.Status = fiber.StatusForbidden
+		response.Message = "admin-role has to be confirmed by another admin"
+
+		logger.Info().Msgf("%q can't confirm the admin-role of %q: requested it", approver.Name, user.Name)
+	} else if requested, err := time.ParseInLocation(time.DateTime, *user.Adminrequested, time.Local); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't parse request of the admin-role of %q: %v", user.Name, err)
+	} else if time.Since(requested) > adminApprovalWindow {
+		response.Status = fiber.StatusGone
+		response.Message = "request of the admin-role expired"
+
+		logger.Info().Msgf("can't confirm the admin-role of %q: requested at %s", user.Name, *user.Adminrequested)
+
+		if err := clearAdminRequest(user.Uid); err != nil {
+			logger.Error().Msgf("can't remove expired request of the admin-role of %q: %v", user.Name, err)
+		}
+	} else if _, err := store.Exec("UPDATE users SET admin = ?, adminrequested = NULL, adminrequestedby = NULL WHERE uid = ?", !user.Admin, user.Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store admin-role of %q: %v", user.Name, err)
+	} else {
+		if user.Admin {
+			logger.Info().Msgf("%q confirmed the revocation of the admin-role of %q, requested by uid = %q", approver.Name, user.Name, *user.Adminrequestedby)
+		} else {
+			logger.Info().Msgf("%q confirmed the admin-role of %q, requested by uid = %q", approver.Name, user.Name, *user.Adminrequestedby)
+		}
+
+		response = getUsers(c)
+	}
+
+	return response
+}
+
+// handles delete-requests revoking the admin-role of a user or rejecting a pending grant. With
+// "users.admin_approval", revoking the role of an admin has to be confirmed by another admin
+func deleteUsersAdmin(c *fiber.Ctx) responseMessage {
+	ids, response := bindRequest(c, "uid", nil)
+
+	if response.Status != 0 {
+		return response
+	} else if user, err := selectUser(ids.Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if user == nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "user doesn't exist"
+
+		logger.Info().Msgf("can't revoke admin-role: user with uid %q doesn't exist", ids.Uid)
+	} else if pending, err := revokeAdmin(getUser(c), *user); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't revoke admin-role of user with uid = %q: %v", ids.Uid, err)
+	} else {
+		if pending {
+			logger.Info().Msgf("%q requested to revoke the admin-role of %q", getUser(c).Name, user.Name)
+		} else {
+			logger.Info().Msgf("%q revoked the admin-role of %q", getUser(c).Name, user.Name)
+		}
+
+		response = getUsers(c)
+
+		if pending {
+			response.Status = fiber.StatusAccepted
+			response.Message = "revocation of the admin-role has to be confirmed by another admin"
+		}
+	}
+
+	return response
+}
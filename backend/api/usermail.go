@@ -0,0 +1,253 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// creates the token verifying the mail-address of a user. It is bound to the address,
+// so changing it again invalidates the previous links
+func userMailToken(uid int, mail string) string {
+	mac := hmac.New(sha256.New, []byte(config.ClientSession.JwtSignature))
+	mac.Write([]byte("usermail:" + strconv.Itoa(uid) + ":" + mail))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sends the verification-link to the mail-address of a user
+func sendUserMailVerification(c *fiber.Ctx, uid int, name, mail string) error {
+	query := url.Values{
+		"uid":   {strconv.Itoa(uid)},
+		"token": {userMailToken(uid, mail)},
+	}
+
+	link := c.BaseURL() + "/api/user/mail/verify?" + query.Encode()
+
+	return mailer.Send(mail, "Verify your mail-address", fmt.Sprintf("Hello %s,\n\nplease verify the mail-address of your account by opening the following link:\n\n%s\n", name, link))
+}
+
+// stores a new, unverified mail-address of a user and sends the verification-link to it.
+// A failed mail is returned as warning, the address is stored nonetheless
+func setUserMail(c *fiber.Ctx, uid int, name, mail string) ([]string, error) {
+	if err := store.Update("users", struct {
+		Mail         *string
+		Mailverified *string
+	}{Mail: &mail}, struct{ Uid int }{Uid: uid}); err != nil {
+		return nil, err
+	} else if err := sendUserMailVerification(c, uid, name, mail); err != nil {
+		logger.Error().Msgf("can't send verification-mail to user with uid = %q: %v", uid, err)
+
+		return []string{"verification-mail couldn't be sent"}, nil
+	} else {
+		return nil, nil
+	}
+}
+
+// handles patch-requests of users changing their own mail-address
+func patchUserMail(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := UserMailBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ mail string }"`)
+	} else if err := validateMail(body.Mail); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message, response.Args = errorMessage(err)
+
+		logger.Info().Msgf("can't change mail-address: %v", err)
+	} else if user := getUser(c); user.Mail != nil && *user.Mail == body.Mail {
+		response.Status = fiber.StatusOK
+	} else if warnings, err := setUserMail(c, user.Uid, user.Name, body.Mail); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store mail-address of user with uid = %q: %v", user.Uid, err)
+	} else {
+		response.Status = fiber.StatusOK
+		response.Message = "verification-link was sent to the mail-address"
+		response.Warnings = warnings
+
+		logger.Info().Msgf("changed mail-address of user %q", user.Name)
+	}
+
+	return response
+}
+
+// handles get-requests of the verification-links of the mail-addresses of the users
+func getUserMailVerify(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	uid := c.QueryInt("uid", -1)
+
+	if users, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if len(users) != 1 || users[0].Mail == nil || !hmac.Equal([]byte(c.Query("token")), []byte(userMailToken(uid, *users[0].Mail))) {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid verification-link"
+
+		logger.Info().Msgf("invalid verification-link for user with uid = %q", uid)
+	} else if users[0].Mailverified != nil {
+		response.Status = fiber.StatusOK
+		response.Message = "mail-address is verified"
+	} else if err := store.Update("users", struct{ Mailverified string }{Mailverified: time.Now().Format(time.DateTime)}, struct{ Uid int }{Uid: uid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't store verification of user with uid = %q: %v", uid, err)
+	} else {
+		response.Status = fiber.StatusOK
+		response.Message = "mail-address is verified"
+
+		logger.Info().Msgf("verified mail-address of user %q", users[0].Name)
+	}
+
+	return response
+}
+
+// returns the verified mail-address of a user, empty if there is none
+func (user UserDB) verifiedMail() string {
+	if user.Mail == nil || user.Mailverified == nil {
+		return ""
+	} else {
+		return *user.Mail
+	}
+}
+
+// creates the token of a password-reset-link, as "<expiry>-<signature>". It is bound
+// to the token-id, so it becomes invalid once the password is changed
+func passwordResetToken(user UserDB, expires time.Time) string {
+	timestamp := strconv.FormatInt(expires.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(config.ClientSession.JwtSignature))
+	mac.Write([]byte("reset:" + strconv.Itoa(user.Uid) + ":" + strconv.Itoa(user.Tid) + ":" + user.verifiedMail() + ":" + timestamp))
+
+	return timestamp + "-" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// checks the token of a password-reset-link of the user
+func checkPasswordResetToken(user UserDB, token string) error {
+	if timestamp, _, found := strings.Cut(token, "-"); !found {
+		return fmt.Errorf("malformed token")
+	} else if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return fmt.Errorf("malformed token")
+	} else if expires := time.Unix(unix, 0); !hmac.Equal([]byte(token), []byte(passwordResetToken(user, expires))) {
+		return fmt.Errorf("invalid token")
+	} else if time.Now().After(expires) {
+		return fmt.Errorf("token expired at %s", expires.Format(time.DateTime))
+	} else {
+		return nil
+	}
+}
+
+// handles post-requests for a password-reset-link. The answer doesn't tell, wether the
+// user exists or has a verified mail-address
+func postUserPasswordReset(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := PasswordResetRequestBody{}
+
+	if config.ConfigYaml.Users.ResetURL == "" {
+		response.Status = fiber.StatusNotFound
+		response.Message = "password-resets aren't enabled"
+
+		logger.Info().Msg("can't reset password: resets aren't enabled")
+	} else if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ name string }"`)
+	} else if users, err := store.Select[UserDB]("users", "name = ? LIMIT 1", body.Name); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else {
+		response.Status = fiber.StatusOK
+		response.Message = "reset-link is sent, if the user has a verified mail-address"
+
+		if len(users) != 1 || users[0].verifiedMail() == "" {
+			logger.Info().Msgf("no password-reset for %q: unknown user or no verified mail-address", body.Name)
+		} else if err := sendPasswordResetLink(users[0]); err != nil {
+			logger.Error().Msgf("can't send password-reset-link to user %q: %v", users[0].Name, err)
+		} else {
+			logger.Info().Msgf("sent password-reset-link to user %q", users[0].Name)
+		}
+	}
+
+	return response
+}
+
+// sends a link for setting a new password to the verified mail-address of a user
+func sendPasswordResetLink(user UserDB) error {
+	query := url.Values{
+		"uid":   {strconv.Itoa(user.Uid)},
+		"token": {passwordResetToken(user, time.Now().Add(config.Users.ResetExpire))},
+	}
+
+	link := config.ConfigYaml.Users.ResetURL + "?" + query.Encode()
+
+	return mailer.Send(user.verifiedMail(), "Reset your password", fmt.Sprintf("Hello %s,\n\nyou can set a new password for your account with the following link within %s:\n\n%s\n\nIf you didn't request the reset, you can ignore this mail.\n", user.Name, config.Users.ResetExpire, link))
+}
+
+// handles patch-requests setting a new password with the token of a reset-link
+func patchUserPasswordReset(c *fiber.Ctx) responseMessage {
+	body := PasswordResetBody{}
+
+	ids, response := bindRequest(c, "uid", &body)
+
+	if response.Status != 0 {
+		return response
+	} else if users, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", ids.Uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if len(users) != 1 {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid reset-link"
+
+		logger.Info().Msgf("can't reset password: user with uid = %q doesn't exist", ids.Uid)
+	} else if err := checkPasswordResetToken(users[0], body.Token); err != nil {
+		response.Status = fiber.StatusForbidden
+		response.Message = "invalid reset-link"
+
+		logger.Info().Msgf("can't reset password of user %q: %v", users[0].Name, err)
+	} else if !validatePassword(body.Password) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid password"
+
+		logger.Info().Msg("invalid password")
+	} else if response = changePassword(ids.Uid, body.Password); response.Status == fiber.StatusOK {
+		logger.Info().Msgf("reset password of user %q", users[0].Name)
+	}
+
+	return response
+}
+
+// mails a notification to the users with a verified mail-address, if its kind is configured for it
+func mailNotification(users []UserDB, kind, message string) {
+	if !slices.Contains(config.ConfigYaml.Users.MailNotifications, kind) {
+		return
+	}
+
+	for _, user := range users {
+		if mail := user.verifiedMail(); mail != "" {
+			if err := mailer.Send(mail, "Notification: "+kind, message); err != nil {
+				logger.Error().Msgf("can't mail notification to user %q: %v", user.Name, err)
+			}
+		}
+	}
+}
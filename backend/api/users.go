@@ -0,0 +1,396 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// user-entry in the database
+type UserDB struct {
+	Uid      int     `json:"uid"`
+	Name     string  `json:"name"`
+	Password []byte  `json:"password"`
+	Tid      int     `json:"tid"`
+	Mail     *string `json:"mail"`
+	// time the mail-address was verified, nil while it isn't
+	Mailverified *string `json:"mail_verified"`
+	// wether the account was deactivated for inactivity
+	Deactivated bool `json:"deactivated"`
+	// wether the user may issue certificates and confirm payments
+	Issuecertificates bool `json:"issue_certificates"`
+	// wether the user has the admin-role
+	Admin bool `json:"admin"`
+	// pending grant of the admin-role awaiting the confirmation of another admin, for an admin a
+	// pending revocation of it
+	Adminrequested   *string `json:"admin_requested"`
+	Adminrequestedby *int    `json:"admin_requested_by"`
+}
+
+// name of the admin created by the setup, no other user can take it
+const reservedUserName = "admin"
+
+// capabilities of the users beyond their role
+const (
+	capabilityIssueCertificates = "issue_certificates"
+)
+
+// checks wether the user has a capability, the admin has all of them
+func (user UserDB) can(capability string) bool {
+	if user.hasRole(roleAdmin) {
+		return true
+	}
+
+	switch capability {
+	case capabilityIssueCertificates:
+		return user.Issuecertificates
+	default:
+		return false
+	}
+}
+
+// returns an error if the user of the request lacks the capability
+func checkCapability(c *fiber.Ctx, capability string) error {
+	if user := getUser(c); !user.can(capability) {
+		return fmt.Errorf("user with uid = %q lacks the capability %q", user.Uid, capability)
+	} else {
+		return nil
+	}
+}
+
+// hashes a password with the configured algorithm
+func hashPassword(password string) ([]byte, error) {
+	return config.Users.PasswordHasher.Hash([]byte(password))
+}
+
+// compares a stored hash with a password, regardless of the algorithm it was created with
+func comparePassword(hash []byte, password string) error {
+	return lib.HasherOf(hash).Compare(hash, []byte(password))
+}
+
+// re-hashes the password of a user after a successful login, if it wasn't hashed with the
+// configured algorithm and parameters. The old hash keeps working, so a failure is only logged
+func migratePasswordHash(user UserDB, password string) {
+	if config.Users.PasswordHasher.Current(user.Password) {
+		return
+	}
+
+	if hash, err := hashPassword(password); err != nil {
+		logger.Error().Msgf("can't re-hash password of user with uid = %q: %v", user.Uid, err)
+	} else if err := store.Update("users", struct{ Password []byte }{Password: hash}, struct{ Uid int }{Uid: user.Uid}); err != nil {
+		logger.Error().Msgf("can't store re-hashed password of user with uid = %q: %v", user.Uid, err)
+	} else {
+		logger.Info().Msgf("re-hashed password of user with uid = %q", user.Uid)
+	}
+}
+
+// handles get-request for the users
+func getUsers(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	// retrieve all users
+	if users, err := selectForRead[User](c, "users", ""); err != nil {
+		response.Status = fiber.StatusInternalServerError
+		response.Message = "can't get users from database"
+
+		logger.Error().Msgf("can't get users from database: %v", err)
+	} else if statistics, err := userStatistics(); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't aggregate the audit-log: %v", err)
+	} else {
+		overviews := make([]UserOverview, len(users))
+		for ii, user := range users {
+			overviews[ii] = UserOverview{
+				User:           user,
+				UserStatistics: statistics[user.Uid],
+			}
+		}
+
+		response.Data = overviews
+
+		logger.Debug().Msg("retrieved users from database")
+	}
+
+	return response
+}
+
+// validates a password against the password-rules
+func validatePassword(password string) bool {
+	return len(password) >= 12 && len(password) <= 64
+}
+
+// handles post-request to add a new user to the database
+func postUsers(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+	body := AddUserBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ name string; password string; mail string }"`)
+	} else if strings.EqualFold(body.Name, reservedUserName) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "user-name is reserved"
+
+		logger.Info().Msgf("can't add user %q: name is reserved", body.Name)
+	} else if body.Mail == "" {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "mail is required"
+
+		logger.Info().Msgf("can't add user %q: no mail-address", body.Name)
+	} else if err := validateMail(body.Mail); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message, response.Args = errorMessage(err)
+
+		logger.Info().Msgf("can't add user %q: %v", body.Name, err)
+	} else {
+		if dbUsers, err := store.Select[UserDB]("users", "name = ? LIMIT 1", body.Name); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't read users from database: %v", err)
+		} else if len(dbUsers) != 0 {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "user already exists"
+
+			logger.Info().Msgf("can't add user: user with name %q already exists", body.Name)
+		} else {
+			// everything is valid
+			if hashedPassword, err := hashPassword(body.Password); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't hash password: %v", err)
+			} else {
+				if err := store.Insert("users", struct {
+					Name              string
+					Password          []byte
+					Mail              string
+					Issuecertificates bool
+				}{Name: body.Name, Password: hashedPassword, Mail: body.Mail, Issuecertificates: body.IssueCertificates}); err != nil {
+					response.Status = fiber.StatusInternalServerError
+					response.Message = "can't add user to database"
+
+					logger.Error().Msgf("can't add user to database: %v", err)
+				} else if dbUsers, err := store.Select[UserDB]("users", "name = ? LIMIT 1", body.Name); err != nil || len(dbUsers) != 1 {
+					response.Status = fiber.StatusInternalServerError
+
+					logger.Error().Msgf("can't read added user %q from database: %v", body.Name, err)
+				} else {
+					response = getUsers(c)
+
+					if err := sendUserMailVerification(c, dbUsers[0].Uid, body.Name, body.Mail); err != nil {
+						response.Warnings = append(response.Warnings, "verification-mail couldn't be sent")
+
+						logger.Error().Msgf("can't send verification-mail to user %q: %v", body.Name, err)
+					}
+
+					if body.Admin {
+						if pending, err := grantAdmin(getUser(c), dbUsers[0]); err != nil {
+							response.Warnings = append(response.Warnings, "admin-role couldn't be granted")
+
+							logger.Error().Msgf("can't grant admin-role to user %q: %v", body.Name, err)
+						} else if pending {
+							response.Warnings = append(response.Warnings, "admin-role has to be confirmed by another admin")
+						}
+					}
+
+					logger.Debug().Msgf("added user %q", body.Name)
+				}
+			}
+		}
+	}
+
+	return response
+}
+
+// change the password in the database
+func changePassword(uid int, password string) responseMessage {
+	response := responseMessage{}
+
+	// hash the new password
+	if hashedPassword, err := hashPassword(password); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't hash password: %v", err)
+	} else {
+		// increase the token-id of the user to make the current-token invalid
+		if err := incTokenId(uid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't increase the tid: %v", err)
+		} else {
+			// update the databse with the new password
+			if err := store.Update("users", struct{ Password []byte }{Password: hashedPassword}, struct{ Uid int }{Uid: uid}); err != nil {
+				response.Status = fiber.StatusInternalServerError
+				response.Message = "can't update password"
+
+				logger.Error().Msgf("can't update password: %v", err)
+			} else {
+				logger.Debug().Msgf("updated password for user %q", uid)
+
+				response.Status = fiber.StatusOK
+			}
+		}
+	}
+
+	return response
+}
+
+// handles patch-request to change a useres password. With "users.admin_approval", the passwords
+// of admins are only reset with a reset-link to their own mail-address
+func patchUsers(c *fiber.Ctx) responseMessage {
+	body := PasswordBody{}
+
+	// the uid can be in the query or the body
+	ids, response := bindRequest(c, "uid", &body)
+	uid := ids.Uid
+
+	if response.Status == 0 {
+		// check, wether the user exists
+		if dbUsers, err := store.Select[UserDB]("users", "uid = ? LIMIT 1", uid); err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't read users from database: %v", err)
+		} else if len(dbUsers) != 1 {
+			response.Status = fiber.StatusBadRequest
+			response.Message = "user doesn't exist"
+
+			logger.Info().Msgf("can't modify user: user with uid %q doesn't exist", uid)
+		} else if target := dbUsers[0]; config.ConfigYaml.Users.AdminApproval && (target.hasRole(roleAdmin) || target.Adminrequested != nil) {
+			// an admin knowing the password of another admin could confirm their own grants
+			if config.ConfigYaml.Users.ResetURL == "" || target.verifiedMail() == "" {
+				response.Status = fiber.StatusForbidden
+				response.Message = "password of an admin can only be reset by themselves"
+
+				logger.Info().Msgf("%q can't reset the password of admin %q: no reset-link can be sent", getUser(c).Name, target.Name)
+			} else if err := sendPasswordResetLink(target); err != nil {
+				response.Status = fiber.StatusInternalServerError
+
+				logger.Error().Msgf("can't send password-reset-link to user %q: %v", target.Name, err)
+			} else {
+				logger.Info().Msgf("%q requested a password-reset of admin %q, sent reset-link", getUser(c).Name, target.Name)
+
+				response = getUsers(c)
+
+				if response.Status == 0 {
+					response.Status = fiber.StatusAccepted
+					response.Message = "reset-link was sent to the admin"
+				}
+			}
+		} else {
+			// everything is valid
+
+			if response = changePassword(uid, body.Password); response.Status == fiber.StatusOK {
+				// setting a new password reactivates the account
+				if err := reactivateUser(uid); err != nil {
+					response.Status = fiber.StatusInternalServerError
+
+					logger.Error().Msgf("can't reactivate user with uid = %q: %v", uid, err)
+				} else {
+					response = getUsers(c)
+				}
+			}
+		}
+	}
+
+	return response
+}
+
+// handles patch-requests changing the capabilities of a user
+func patchUsersCapabilities(c *fiber.Ctx) responseMessage {
+	body := CapabilitiesBody{}
+
+	ids, response := bindRequest(c, "uid", &body)
+	uid := ids.Uid
+
+	if response.Status != 0 {
+		return response
+	} else if err := store.Update("users", struct{ Issuecertificates bool }{Issuecertificates: body.IssueCertificates}, struct{ Uid int }{Uid: uid}); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't update capabilities of user with uid = %q: %v", uid, err)
+	} else {
+		logger.Info().Msgf("set capabilities of user with uid = %q to %+v", uid, body)
+
+		response = getUsers(c)
+	}
+
+	return response
+}
+
+// handle delete-request for removing a user. Admins have to lose their role first
+func deleteUsers(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+
+	// check wether there is a valid uid
+	if uid := c.QueryInt("uid", -1); uid < 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "query doesn't include valid uid"
+
+		logger.Info().Msg("query doesn't include valid uid")
+	} else if user, err := selectUser(uid); err != nil {
+		response.Status = fiber.StatusInternalServerError
+
+		logger.Error().Msgf("can't read users from database: %v", err)
+	} else if user != nil && (user.Name == reservedUserName || user.hasRole(roleAdmin)) {
+		// otherwise a single admin could remove all the others
+		response.Status = fiber.StatusForbidden
+		response.Message = "admins can't be deleted"
+
+		logger.Info().Msgf("%q can't delete admin %q", getUser(c).Name, user.Name)
+	} else if isDryRun(c) {
+		users, err := affectedKeys("users", "uid = ?", func(row struct{ Uid int }) string { return strconv.Itoa(row.Uid) }, uid)
+
+		response = dryRunResponse(appendAffected([]AffectedRows{}, "users", "delete", "", users), err)
+	} else {
+		// delete the user from the database
+		if err := store.Delete("users", struct{ Uid int }{Uid: uid}); err != nil {
+			response.Status = fiber.StatusInternalServerError
+			response.Message = "can't delete user"
+
+			logger.Error().Msgf("can't delete user with uid = %q: %v", uid, err)
+		} else {
+			logger.Debug().Msgf("deleted user with uid = %q", uid)
+
+			response = getUsers(c)
+		}
+	}
+
+	return response
+}
+
+// handles patch-requests to change the users password
+func patchUserPassword(c *fiber.Ctx) responseMessage {
+	response := responseMessage{}
+
+	// parse the body
+	var body UserPasswordBody
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ password string; current string }"`)
+	} else if user := getUser(c); comparePassword(user.Password, body.Current) != nil {
+		// a hijacked session mustn't be able to take over the account
+		response.Status = fiber.StatusForbidden
+		response.Message = "wrong current password"
+
+		logger.Info().Msgf("can't change password of user %q: wrong current password", user.Name)
+	} else if !validatePassword(body.Password) {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid password"
+
+		logger.Info().Msg("invalid password")
+	} else {
+		// everything is valid
+
+		return changePassword(user.Uid, body.Password)
+	}
+
+	return response
+}
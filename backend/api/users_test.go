@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"maps"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// answers the statements on the users from the users
+func usersHandler(users map[int]*UserDB) func(string, []driver.Value) (fakeResult, error) {
+	row := func(user *UserDB) map[string]driver.Value {
+		var requested, requester driver.Value
+		if user.Adminrequested != nil {
+			requested = *user.Adminrequested
+		}
+		if user.Adminrequestedby != nil {
+			requester = int64(*user.Adminrequestedby)
+		}
+
+		return map[string]driver.Value{
+			"uid":               int64(user.Uid),
+			"name":              user.Name,
+			"password":          user.Password,
+			"tid":               int64(user.Tid),
+			"deactivated":       user.Deactivated,
+			"issuecertificates": user.Issuecertificates,
+			"admin":             user.Admin,
+			"adminrequested":    requested,
+			"adminrequestedby":  requester,
+		}
+	}
+
+	return func(query string, args []driver.Value) (fakeResult, error) {
+		var user *UserDB
+
+		// the uid is always the last argument
+		if len(args) > 0 {
+			if uid, err := strconv.Atoi(fmt.Sprint(args[len(args)-1])); err == nil {
+				user = users[uid]
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(query, "SELECT ") && strings.HasSuffix(query, " FROM users"):
+			rows := []map[string]driver.Value{}
+
+			for _, uid := range slices.Sorted(maps.Keys(users)) {
+				rows = append(rows, row(users[uid]))
+			}
+
+			return selectResult(query, rows...), nil
+		case strings.HasPrefix(query, "SELECT ") && strings.HasSuffix(query, " FROM users WHERE uid = ? LIMIT 1"):
+			if user == nil {
+				return selectResult(query), nil
+			}
+
+			return selectResult(query, row(user)), nil
+		case strings.HasPrefix(query, "SELECT ") && strings.HasSuffix(query, " FROM audit"):
+			return selectResult(query), nil
+		case user == nil:
+			return fakeResult{}, fmt.Errorf("unexpected statement: %s", query)
+		case query == "UPDATE users SET tid = tid + 1 WHERE uid = ?":
+			user.Tid++
+		case query == "UPDATE users SET password = ? WHERE uid = ?":
+			user.Password = args[0].([]byte)
+		case query == "UPDATE users SET deactivated = ?, lastaction = ? WHERE uid = ?":
+			user.Deactivated = false
+		case query == "UPDATE users SET adminrequested = ?, adminrequestedby = ? WHERE uid = ?":
+			requested, requester := args[0].(string), int(args[1].(int64))
+			user.Adminrequested, user.Adminrequestedby = &requested, &requester
+		case query == "UPDATE users SET admin = ?, adminrequested = NULL, adminrequestedby = NULL WHERE uid = ?":
+			user.Admin, user.Adminrequested, user.Adminrequestedby = args[0].(bool), nil, nil
+		case query == "DELETE FROM users WHERE uid = ?":
+			delete(users, user.Uid)
+		default:
+			return fakeResult{}, fmt.Errorf("unexpected statement: %s", query)
+		}
+
+		return fakeResult{affected: 1}, nil
+	}
+}
+
+// creates the users-table with two admins and a volunteer
+func testUsers(t *testing.T) map[int]*UserDB {
+	t.Helper()
+
+	users := map[int]*UserDB{
+		1: {Uid: 1, Name: "admin", Admin: true},
+		2: {Uid: 2, Name: "volunteer"},
+		3: {Uid: 3, Name: "second-admin", Admin: true},
+	}
+
+	for _, user := range users {
+		if hash, err := testConfig().Users.PasswordHasher.Hash([]byte(user.Name + "-password")); err != nil {
+			t.Fatalf("can't hash password: %v", err)
+		} else {
+			user.Password = hash
+		}
+	}
+
+	return users
+}
+
+// sends a request with a JSON-body to the app as the logged-in user
+func requestAs(t *testing.T, method string, user UserDB, handler func(*fiber.Ctx) responseMessage, target, body string) int {
+	t.Helper()
+
+	path, _, _ := strings.Cut(target, "?")
+
+	app := testApp(method, path, handler, func(c *fiber.Ctx) error {
+		c.Locals(localsUser, user)
+
+		return c.Next()
+	})
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	return res.StatusCode
+}
+
+// a session without the current password can't take over the account
+func TestPatchUserPasswordWrongCurrent(t *testing.T) {
+	users := testUsers(t)
+	useFakeDB(t, testConfig(), usersHandler(users))
+
+	user := *users[2]
+
+	status := requestAs(t, fiber.MethodPatch, user, patchUserPassword, "/api/user/password", `{"current": "wrong-password", "password": "new-volunteer-password"}`)
+
+	if status != fiber.StatusForbidden {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusForbidden)
+	}
+
+	if comparePassword(users[2].Password, "volunteer-password") != nil {
+		t.Error("password was changed")
+	}
+
+	if users[2].Tid != user.Tid {
+		t.Error("tid was increased")
+	}
+}
+
+// with the current password, the password is changed and the existing sessions end
+func TestPatchUserPassword(t *testing.T) {
+	users := testUsers(t)
+	useFakeDB(t, testConfig(), usersHandler(users))
+
+	user := *users[2]
+
+	status := requestAs(t, fiber.MethodPatch, user, patchUserPassword, "/api/user/password", `{"current": "volunteer-password", "password": "new-volunteer-password"}`)
+
+	if status != fiber.StatusOK {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusOK)
+	}
+
+	if comparePassword(users[2].Password, "new-volunteer-password") != nil {
+		t.Error("password wasn't changed")
+	}
+
+	if users[2].Tid != user.Tid+1 {
+		t.Errorf("tid is %d, expected %d", users[2].Tid, user.Tid+1)
+	}
+}
+
+// an admin resets the password of another user without knowing the current one
+func TestPatchUsersResetPassword(t *testing.T) {
+	users := testUsers(t)
+	useFakeDB(t, testConfig(), usersHandler(users))
+
+	users[2].Deactivated = true
+	tid := users[2].Tid
+
+	status := requestAs(t, fiber.MethodPatch, *users[1], patchUsers, "/api/users?uid=2", `{"password": "reset-volunteer-password"}`)
+
+	if status != fiber.StatusOK {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusOK)
+	}
+
+	if comparePassword(users[2].Password, "reset-volunteer-password") != nil {
+		t.Error("password wasn't reset")
+	}
+
+	if users[2].Tid != tid+1 {
+		t.Errorf("tid is %d, expected %d", users[2].Tid, tid+1)
+	}
+
+	if users[2].Deactivated {
+		t.Error("user wasn't reactivated")
+	}
+}
+
+// with the four-eyes principle, an admin can't take over the account of another admin or of a
+// user with a pending grant to confirm their own grants
+func TestPatchUsersResetAdminPassword(t *testing.T) {
+	requested := "2024-01-01 00:00:00"
+
+	for _, uid := range []int{2, 3} {
+		users := testUsers(t)
+		users[2].Adminrequested = &requested
+
+		cfg := testConfig()
+		cfg.ConfigYaml.Users.AdminApproval = true
+
+		useFakeDB(t, cfg, usersHandler(users))
+
+		password := users[uid].Password
+		tid := users[uid].Tid
+
+		status := requestAs(t, fiber.MethodPatch, *users[1], patchUsers, fmt.Sprintf("/api/users?uid=%d", uid), `{"password": "reset-admin-password"}`)
+
+		if status != fiber.StatusForbidden {
+			t.Errorf("status for %q is %d, expected %d", users[uid].Name, status, fiber.StatusForbidden)
+		}
+
+		if !bytes.Equal(users[uid].Password, password) || users[uid].Tid != tid {
+			t.Errorf("password of %q was reset", users[uid].Name)
+		}
+	}
+}
+
+// the name doesn't make a user an admin, so it can't be taken by another user
+func TestPostUsersReservedName(t *testing.T) {
+	users := testUsers(t)
+	fake := useFakeDB(t, testConfig(), usersHandler(users))
+
+	if (UserDB{Name: "admin"}).hasRole(roleAdmin) {
+		t.Error("user without the admin-flag has the admin-role")
+	}
+
+	status := requestAs(t, fiber.MethodPost, *users[1], postUsers, "/api/users", `{"name": "Admin", "password": "second-admin-password", "mail": "admin@example.com"}`)
+
+	if status != fiber.StatusBadRequest {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusBadRequest)
+	}
+
+	if count := fake.count("INSERT "); count != 0 {
+		t.Errorf("user was inserted %d times", count)
+	}
+}
+
+// an admin can't remove the other admins, only users without the admin-role
+func TestDeleteUsersAdmin(t *testing.T) {
+	for _, uid := range []int{1, 3} {
+		users := testUsers(t)
+		useFakeDB(t, testConfig(), usersHandler(users))
+
+		status := requestAs(t, fiber.MethodDelete, *users[3], deleteUsers, fmt.Sprintf("/api/users?uid=%d", uid), "")
+
+		if status != fiber.StatusForbidden {
+			t.Errorf("status for %q is %d, expected %d", users[uid].Name, status, fiber.StatusForbidden)
+		}
+
+		if _, found := users[uid]; !found {
+			t.Errorf("admin %q was deleted", users[uid].Name)
+		}
+	}
+
+	users := testUsers(t)
+	useFakeDB(t, testConfig(), usersHandler(users))
+
+	if status := requestAs(t, fiber.MethodDelete, *users[1], deleteUsers, "/api/users?uid=2", ""); status != fiber.StatusOK {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusOK)
+	}
+
+	if _, found := users[2]; found {
+		t.Error("volunteer wasn't deleted")
+	}
+}
+
+// with the four-eyes principle, revoking the admin-role has to be confirmed by another admin
+func TestDeleteUsersAdminApproval(t *testing.T) {
+	users := testUsers(t)
+
+	cfg := testConfig()
+	cfg.ConfigYaml.Users.AdminApproval = true
+
+	useFakeDB(t, cfg, usersHandler(users))
+
+	if status := requestAs(t, fiber.MethodDelete, *users[1], deleteUsersAdmin, "/api/users/admin?uid=3", ""); status != fiber.StatusAccepted {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusAccepted)
+	}
+
+	if !users[3].Admin || users[3].Adminrequested == nil {
+		t.Fatal("revocation isn't pending")
+	}
+
+	if status := requestAs(t, fiber.MethodPost, *users[1], postUsersAdminApprove, "/api/users/admin/approve?uid=3", ""); status != fiber.StatusForbidden {
+		t.Errorf("requester confirmed the revocation with status %d", status)
+	}
+
+	if status := requestAs(t, fiber.MethodPost, *users[3], postUsersAdminApprove, "/api/users/admin/approve?uid=3", ""); status != fiber.StatusOK {
+		t.Errorf("status is %d, expected %d", status, fiber.StatusOK)
+	}
+
+	if users[3].Admin || users[3].Adminrequested != nil {
+		t.Error("admin-role wasn't revoked")
+	}
+}
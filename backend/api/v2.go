@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maximum lengths of the text-values of a reservation
+const (
+	maxReservationName   = 100
+	maxReservationSource = 64
+)
+
+// parses a JSON-body strictly: unknown fields, trailing data and empty bodies are rejected
+func parseStrictBody(body []byte, result any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(result); errors.Is(err, io.EOF) {
+		return fmt.Errorf("body is empty")
+	} else if err != nil {
+		return err
+	} else if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("body contains data after the JSON-object")
+	} else {
+		return nil
+	}
+}
+
+// checks that a mail-address is a plain and valid address
+func validateMail(address string) error {
+	if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+		return messageErrorf("invalid mail-address %q", address)
+	} else {
+		return nil
+	}
+}
+
+// checks the values of a reservation-request of the v2-api
+func validateReservationBody(body ReservationBodyV2) error {
+	if ok, err := isValidMid(body.Mid); err != nil || !ok {
+		return messageErrorf("invalid mid %q", body.Mid)
+	} else if err := validateMail(body.Mail); err != nil {
+		return err
+	} else if utf8.RuneCountInString(body.Name) > maxReservationName {
+		return messageErrorf("name is longer than %d characters", maxReservationName)
+	} else if utf8.RuneCountInString(body.Source) > maxReservationSource {
+		return messageErrorf("source is longer than %d characters", maxReservationSource)
+	} else if body.Gift != nil {
+		if utf8.RuneCountInString(body.Gift.Recipient) > maxReservationName {
+			return messageErrorf("recipient is longer than %d characters", maxReservationName)
+		} else if body.Gift.Mail != "" {
+			return validateMail(body.Gift.Mail)
+		}
+	}
+
+	return nil
+}
+
+// handles post-requests for reserving new elements with the strict body of the v2-api
+func postElementsV2(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := ReservationBodyV2{}
+
+	if err := parseStrictBody(c.Body(), &body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body: %v"
+		response.Args = []any{err}
+
+		logger.Info().Msgf("can't reserve element: invalid message-body: %v", err)
+	} else {
+		body.Name = strings.TrimSpace(body.Name)
+		body.Mail = strings.TrimSpace(body.Mail)
+
+		if err := validateReservationBody(body); err != nil {
+			response.Status = fiber.StatusBadRequest
+			response.Message, response.Args = errorMessage(err)
+
+			logger.Info().Msgf("can't reserve element %q: %v", body.Mid, err)
+		} else {
+			response = reserveElement(c, body.Mid, body.ReservationBody)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"runtime"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+)
+
+// returns the version of the running build
+func buildVersion() Version {
+	return Version{
+		Version:   lib.Version,
+		Commit:    lib.Commit,
+		BuildTime: lib.BuildTime,
+		Go:        runtime.Version(),
+	}
+}
+
+// logs the version of the build on the start
+func logVersion() {
+	version := buildVersion()
+
+	logger.Info().
+		Str("version", version.Version).
+		Str("commit", version.Commit).
+		Str("build_time", version.BuildTime).
+		Str("go", version.Go).
+		Msg("starting backend")
+}
+
+// handles get-requests for the version of the running build
+func getVersion(c *fiber.Ctx) responseMessage {
+	return responseMessage{
+		Data: buildVersion(),
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/johannesbuehl/johannes-pv/backend/certs"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+)
+
+// elements hidden from the public, e.g. because they aren't installed yet
+var hiddenElements = struct {
+	sync.RWMutex
+	mids []string
+}{}
+
+// loads the hidden elements from the database
+func loadHiddenElements() error {
+	hiddenElements.Lock()
+	defer hiddenElements.Unlock()
+
+	_, err := store.LoadSetting("hidden-elements", &hiddenElements.mids)
+
+	return err
+}
+
+// wether an element (or the element of a share) is hidden from the public
+func isHidden(mid string) bool {
+	mid, _ = certs.SplitShare(mid)
+
+	hiddenElements.RLock()
+	defer hiddenElements.RUnlock()
+
+	return slices.Contains(hiddenElements.mids, mid)
+}
+
+// returns the hidden elements
+func getHiddenMids() []string {
+	hiddenElements.RLock()
+	defer hiddenElements.RUnlock()
+
+	mids := slices.Clone(hiddenElements.mids)
+	if mids == nil {
+		mids = []string{}
+	}
+
+	return mids
+}
+
+// wether a mid names a whole element, elements split into shares are named without the share
+func isValidElement(mid string) bool {
+	if _, share := certs.SplitShare(mid); share > 0 {
+		return false
+	} else if elementShares(mid) > 0 {
+		mid = fmt.Sprintf("%s%s1", mid, certs.ShareSeparator)
+	}
+
+	ok, err := isValidMid(mid)
+
+	return err == nil && ok
+}
+
+// handles get-requests for the elements hidden from the public
+func getElementsVisibility(c *fiber.Ctx) responseMessage {
+	return responseMessage{
+		Data: getHiddenMids(),
+	}
+}
+
+// handles patch-requests for showing or hiding elements from the public
+func patchElementsVisibility(c *fiber.Ctx) responseMessage {
+	var response responseMessage
+
+	body := VisibilityBody{}
+
+	if err := c.BodyParser(&body); err != nil {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid message-body"
+
+		logger.Warn().Msg(`body can't be parsed as "struct{ mids []string; visible bool }"`)
+	} else if invalid := slices.IndexFunc(body.Mids, func(mid string) bool { return !isValidElement(mid) }); invalid >= 0 {
+		response.Status = fiber.StatusBadRequest
+		response.Message = "invalid element %q"
+		response.Args = []any{body.Mids[invalid]}
+
+		logger.Info().Msgf("can't change visibility: invalid element %q", body.Mids[invalid])
+	} else {
+		hiddenElements.Lock()
+
+		mids := slices.Clone(hiddenElements.mids)
+
+		for _, mid := range body.Mids {
+			if body.Visible {
+				mids = slices.DeleteFunc(mids, func(hidden string) bool { return hidden == mid })
+			} else if !slices.Contains(mids, mid) {
+				mids = append(mids, mid)
+			}
+		}
+
+		slices.Sort(mids)
+
+		err := store.StoreSetting("hidden-elements", mids)
+		if err == nil {
+			hiddenElements.mids = mids
+		}
+
+		hiddenElements.Unlock()
+
+		if err != nil {
+			response.Status = fiber.StatusInternalServerError
+
+			logger.Error().Msgf("can't store hidden elements: %v", err)
+		} else {
+			// the public state of the elements has to be recreated
+			cachedElements.Delete("status")
+
+			logger.Info().Msgf("%s elements %v", map[bool]string{true: "showed", false: "hid"}[body.Visible], body.Mids)
+
+			response = getElementsVisibility(c)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,203 @@
+// Package certs creates the sponsorship-certificates.
+package certs
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/mailer"
+)
+
+// sponsor and element a certificate is created for
+type ReservationData struct {
+	Mail string
+	Mid  string
+	Name string
+	// further texts of the reservation by their field, the dedication and the values of the
+	// custom fields. Only the ones in "certificates.fields" are printed
+	Texts map[string]string
+}
+
+type CertificateData struct {
+	Reservation ReservationData
+	// serial-number and verification-code printed on the certificate
+	Serial int
+	Code   string
+	// directory of the svg-templates inside "templates", empty for the default ones
+	Templates string
+	// number of shares the element is split into, zero for whole elements
+	Shares int
+	// signed link for downloading the certificate without a login, empty for none
+	Download     string
+	TemplateData SponsorshipTemplateData
+	PDFFile      string
+}
+
+type SponsorshipTemplateData struct {
+	Element string
+	Article string
+	Date    string
+	Name    string
+	// expected donation for the element, formatted for the mails
+	Amount string
+	// wether the reservation awaits the approval of an admin
+	Pending bool
+	// recipient of a gift, the name is the one of the buyer then
+	Recipient string
+	// unsubscribe-link of the newsletter, if the sponsor subscribed it
+	Unsubscribe string
+	// link to the status of the reservation
+	Status string
+	// serial-number and verification-code of the certificate
+	Serial string
+	Code   string
+	// sponsored share of the element (e.g. "1/4"), empty for whole elements
+	Share string
+	// signed link for downloading the certificate without a login
+	Download string
+	// texts of the reservation printed on the certificate besides the name, by their field
+	Texts map[string]string
+}
+
+func (data *SponsorshipTemplateData) Populate(mid, name string, shares int) {
+	// the formatting of a time can't fail
+	date, _ := lib.FormatDate(time.Now())
+
+	*data = SponsorshipTemplateData{
+		Name:    name,
+		Element: ElementName(mid, shares),
+		Article: ElementArticle(mid),
+		Date:    date,
+		Share:   ShareFraction(mid, shares),
+	}
+}
+
+func (data *CertificateData) Create() error {
+	// populate the template-data
+	data.TemplateData.Populate(data.Reservation.Mid, data.Reservation.Name, data.Shares)
+	data.TemplateData.Serial = FormatSerial(data.Serial)
+	data.TemplateData.Code = FormatCode(data.Code)
+	data.TemplateData.Download = data.Download
+
+	// only the configured texts of the reservation are printed
+	data.TemplateData.Texts = map[string]string{}
+
+	for _, field := range certificateFields {
+		if field == "name" {
+			continue
+		} else if text := data.Reservation.Texts[field]; text != "" {
+			data.TemplateData.Texts[field] = text
+		}
+	}
+
+	if !slices.Contains(certificateFields, "name") {
+		data.TemplateData.Name = ""
+	}
+
+	data.PDFFile = fmt.Sprintf("templates/certificate.%s.pdf", data.Reservation.Mid)
+
+	return renderer.Render(data, data.PDFFile)
+}
+
+// sends the certificate to the sponsor
+func (data CertificateData) Send() error {
+	return mailer.SendTemplate(data.Reservation.Mail, "certificate_mail", data.TemplateData, data.PDFFile)
+}
+
+// removes the created pdf-file
+func (data *CertificateData) Cleanup() error {
+	if data.PDFFile != "" {
+		return os.Remove(data.PDFFile)
+	} else {
+		return nil
+	}
+}
+
+// types of the elements by the prefix of their mids
+var elementTypes = config.DefaultElementTypes
+
+// scheme the mids are split into their parts with
+var midScheme config.MidScheme
+
+// texts of the reservation printed on the certificates, in their order
+var certificateFields = []string{"name"}
+
+// returns the type of an element by the prefix of its mid
+func TypeOf(mid string) config.ElementType {
+	mid, _ = SplitShare(mid)
+
+	return elementTypes[midScheme.Type(mid)]
+}
+
+func ElementType(mid string) string {
+	return TypeOf(mid).Name
+}
+
+func ElementArticle(mid string) string {
+	return TypeOf(mid).Article
+}
+
+func ElementID(mid string) string {
+	mid, _ = SplitShare(mid)
+
+	return midScheme.ID(mid)
+}
+
+// separator between the mid of an element and the number of a share of it, e.g. "bs-1_2"
+const ShareSeparator = "_"
+
+// splits the mid of a share into the mid of the element and the number of the share.
+// Whole elements have the share zero
+func SplitShare(mid string) (string, int) {
+	if ii := strings.LastIndex(mid, ShareSeparator); ii < 0 {
+		return mid, 0
+	} else if share, err := strconv.Atoi(mid[ii+1:]); err != nil || share < 1 {
+		return mid, 0
+	} else {
+		return mid[:ii], share
+	}
+}
+
+// formats the share of an element as fraction (e.g. "1/4"), empty for whole elements
+func ShareFraction(mid string, shares int) string {
+	if _, share := SplitShare(mid); share == 0 || shares == 0 {
+		return ""
+	} else {
+		return fmt.Sprintf("%d/%d", share, shares)
+	}
+}
+
+// returns the human-readable name of an element, e.g. "PV-Modul A1" or
+// "Batteriespeicher 1 (Anteil 1/4)"
+func ElementName(mid string, shares int) string {
+	name := fmt.Sprintf("%s %s", ElementType(mid), ElementID(mid))
+
+	if share := ShareFraction(mid, shares); share != "" {
+		name += fmt.Sprintf(" (Anteil %s)", share)
+	}
+
+	return name
+}
+
+// formats the serial-number as printed on the certificate
+func FormatSerial(serial int) string {
+	return fmt.Sprintf("%06d", serial)
+}
+
+// formats the verification-code in groups of four characters for better readability
+func FormatCode(code string) string {
+	groups := []string{}
+
+	for len(code) > 4 {
+		groups = append(groups, code[:4])
+		code = code[4:]
+	}
+
+	return strings.Join(append(groups, code), "-")
+}
@@ -0,0 +1,159 @@
+package certs
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+)
+
+// renders the certificate into a pdf-file
+type PDFRenderer interface {
+	Render(data *CertificateData, pdfFile string) error
+}
+
+// available renderers by their name in the config
+var renderers = map[string]PDFRenderer{
+	"inkscape": inkscapeRenderer{},
+	"fpdf":     fpdfRenderer{},
+}
+
+// renderer used for the certificates
+var renderer PDFRenderer = inkscapeRenderer{}
+
+// selects the renderer of the certificates
+func Init(cfg config.ConfigStruct) error {
+	if len(cfg.ElementTypes) > 0 {
+		elementTypes = cfg.ElementTypes
+	}
+
+	midScheme = cfg.Mids
+
+	if len(cfg.Certificates.Fields) > 0 {
+		certificateFields = cfg.Certificates.Fields
+	}
+
+	name := cfg.Certificates.Renderer
+
+	if name == "" {
+		name = "inkscape"
+	}
+
+	if r, ok := renderers[name]; !ok {
+		return fmt.Errorf("unknown certificate-renderer %q", name)
+	} else {
+		renderer = r
+
+		return nil
+	}
+}
+
+// fills the svg-templates and converts them with inkscape
+type inkscapeRenderer struct{}
+
+func (inkscapeRenderer) Render(data *CertificateData, pdfFile string) error {
+	// choose the svg-template wether a name is given or not
+	var templateName string
+
+	if data.TemplateData.Name == "" {
+		templateName = "template_without_name.svg"
+	} else {
+		templateName = "template_with_name.svg"
+	}
+
+	// create temporary svg file
+	if svgFile, err := os.CreateTemp("templates", "certificate.*.svg"); err != nil {
+		return err
+	} else {
+		defer os.Remove(svgFile.Name())
+		defer svgFile.Close()
+
+		if svgString, err := lib.ParseTemplate(path.Join("templates", data.Templates, templateName), data.TemplateData); err != nil {
+			return err
+		} else {
+			// write the svg-template
+			svgFile.WriteString(svgString)
+
+			actionString := fmt.Sprintf(`--actions=export-filename:%s; export-area-page; export-do`, pdfFile)
+
+			// create a pdf from the svg-file
+			command := exec.Command("inkscape/AppRun", actionString, svgFile.Name())
+
+			return command.Run()
+		}
+	}
+}
+
+// lays the certificate out in pure go, without external programs. An image
+// "background.png" in the template-directory is used as background of the page
+type fpdfRenderer struct{}
+
+func (fpdfRenderer) Render(data *CertificateData, pdfFile string) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	width, height := pdf.GetPageSize()
+
+	if background := path.Join("templates", data.Templates, "background.png"); fileExists(background) {
+		pdf.ImageOptions(background, 0, 0, width, height, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	// the core-fonts only support cp1252, which covers the german umlauts
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	line := func(size float64, style, text string, h float64) {
+		pdf.SetFont("Helvetica", style, size)
+		pdf.CellFormat(0, h, tr(text), "", 1, "C", false, 0, "")
+	}
+
+	template := data.TemplateData
+
+	pdf.SetY(45)
+	line(36, "B", "Urkunde", 20)
+	pdf.Ln(10)
+
+	if template.Name != "" {
+		line(16, "", "Hiermit bestätigen wir, dass", 10)
+		line(24, "B", template.Name, 16)
+		line(16, "", fmt.Sprintf("die Patenschaft für %s %s", template.Article, template.Element), 10)
+	} else {
+		line(16, "", fmt.Sprintf("Patenschaft für %s %s", template.Article, template.Element), 10)
+	}
+
+	line(16, "", "übernommen hat.", 10)
+
+	// further texts of the reservation in their configured order, the dedication in italics
+	for _, field := range certificateFields {
+		if text, ok := template.Texts[field]; !ok {
+			continue
+		} else if field == "dedication" {
+			pdf.Ln(4)
+			pdf.SetFont("Helvetica", "I", 14)
+			pdf.MultiCell(0, 7, tr(text), "", "C", false)
+		} else {
+			line(14, "", text, 8)
+		}
+	}
+
+	pdf.Ln(10)
+	line(12, "", template.Date, 8)
+
+	// serial-number and verification-code at the bottom of the page
+	pdf.SetY(height - 30)
+	line(9, "", fmt.Sprintf("Urkunde Nr. %s · Prüfcode %s", template.Serial, template.Code), 6)
+
+	return pdf.OutputFileAndClose(pdfFile)
+}
+
+// wether a file exists
+func fileExists(pth string) bool {
+	_, err := os.Stat(pth)
+
+	return err == nil
+}
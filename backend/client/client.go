@@ -0,0 +1,753 @@
+// Package client provides typed access to the REST-api of the backend.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/johannesbuehl/johannes-pv/backend/api"
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// client for the REST-api. The session-cookie of a login is kept for all
+// following requests
+type Client struct {
+	BaseURL    *url.URL
+	HTTPClient *http.Client
+	// called with the non-fatal warnings a response includes
+	OnWarnings func(endpoint string, warnings []string)
+	// language of the response-messages (e.g. "de"), english if empty
+	Language string
+}
+
+// error returned for responses with an error status-code
+type StatusError struct {
+	Status int
+	// language-independent code and the translated text of the message
+	Code    string
+	Message string
+}
+
+func (err StatusError) Error() string {
+	if err.Message != "" {
+		return fmt.Sprintf("HTTP %d: %s", err.Status, err.Message)
+	} else {
+		return fmt.Sprintf("HTTP %d", err.Status)
+	}
+}
+
+// creates a new client for the server at baseURL (e.g. "https://example.org")
+func New(baseURL string) (*Client, error) {
+	if u, err := url.Parse(baseURL); err != nil {
+		return nil, err
+	} else if jar, err := cookiejar.New(nil); err != nil {
+		return nil, err
+	} else {
+		return &Client{
+			BaseURL: u,
+			HTTPClient: &http.Client{
+				Jar: jar,
+			},
+		}, nil
+	}
+}
+
+// sends a request to an api-endpoint and returns the raw response-body
+func (c *Client) request(method, endpoint string, query url.Values, body any) ([]byte, error) {
+	u := c.BaseURL.JoinPath("api", endpoint)
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+
+	if body != nil {
+		if buf, err := json.Marshal(body); err != nil {
+			return nil, err
+		} else {
+			reqBody = bytes.NewReader(buf)
+		}
+	}
+
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.Language != "" {
+		req.Header.Set("Accept-Language", c.Language)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if header := res.Header.Get("X-Warnings"); header != "" && c.OnWarnings != nil {
+		var warnings []string
+
+		if err := json.Unmarshal([]byte(header), &warnings); err == nil {
+			c.OnWarnings(endpoint, warnings)
+		}
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 400 {
+		return nil, StatusError{
+			Status:  res.StatusCode,
+			Code:    res.Header.Get("X-Message-Code"),
+			Message: strings.TrimSpace(string(resBody)),
+		}
+	}
+
+	return resBody, nil
+}
+
+// sends a request to an api-endpoint and decodes the JSON-response into T
+func requestJSON[T any](c *Client, method, endpoint string, query url.Values, body any) (T, error) {
+	var result T
+
+	if resBody, err := c.request(method, endpoint, query, body); err != nil {
+		return result, err
+	} else if len(resBody) == 0 {
+		return result, nil
+	} else {
+		err := json.Unmarshal(resBody, &result)
+
+		return result, err
+	}
+}
+
+func midQuery(mid string) url.Values {
+	return url.Values{"mid": {mid}}
+}
+
+func uidQuery(uid int) url.Values {
+	return url.Values{"uid": {strconv.Itoa(uid)}}
+}
+
+// logs in a user and stores the session-cookie
+func (c *Client) Login(user, password string) (api.UserLogin, error) {
+	return requestJSON[api.UserLogin](c, http.MethodPost, "login", nil, api.LoginBody{User: user, Password: password})
+}
+
+// removes the session-cookie
+func (c *Client) Logout() (api.UserLogin, error) {
+	return requestJSON[api.UserLogin](c, http.MethodGet, "logout", nil, nil)
+}
+
+// retrieves the logged-in-status of the current session
+func (c *Client) Welcome() (api.UserLogin, error) {
+	return requestJSON[api.UserLogin](c, http.MethodGet, "welcome", nil, nil)
+}
+
+// retrieves the public state of the elements
+func (c *Client) GetElements() (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodGet, "elements", nil, nil)
+}
+
+// retrieves the public state of the elements of a plant
+func (c *Client) GetPlantElements(plant string) (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodGet, "elements", url.Values{"plant": {plant}}, nil)
+}
+
+// retrieves the status of a reservation with the token of the status-link
+func (c *Client) GetReservationStatus(mid, token string) (api.ReservationStatus, error) {
+	return requestJSON[api.ReservationStatus](c, http.MethodGet, "elements/status", url.Values{"mid": {mid}, "token": {token}}, nil)
+}
+
+// retrieves the changes of the elements since a cursor of a previous call. An
+// empty cursor returns the complete state
+func (c *Client) GetElementChanges(since string) (api.ElementsDiff, error) {
+	// the server only returns a diff for a non-empty cursor, unknown ones yield the complete state
+	if since == "" {
+		since = "0"
+	}
+
+	return requestJSON[api.ElementsDiff](c, http.MethodGet, "elements", url.Values{"since": {since}}, nil)
+}
+
+// reserves an element
+func (c *Client) ReserveElement(mid string, body api.ReservationBody) (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodPost, "elements", midQuery(mid), body)
+}
+
+// reserves an element through the v2-api, which validates the body strictly
+func (c *Client) ReserveElementV2(body api.ReservationBodyV2) (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodPost, "v2/elements", nil, body)
+}
+
+// changes the name of an element
+func (c *Client) UpdateElement(mid, name string) (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodPatch, "elements", midQuery(mid), api.NameBody{Name: name})
+}
+
+// removes an element
+func (c *Client) DeleteElement(mid string) (api.ClientStatus, error) {
+	return requestJSON[api.ClientStatus](c, http.MethodDelete, "elements", midQuery(mid), nil)
+}
+
+// sets the internal notes of an element, empty notes remove them
+func (c *Client) SetElementNotes(mid, notes string) (api.ElementDB, error) {
+	return requestJSON[api.ElementDB](c, http.MethodPatch, "elements/notes", midQuery(mid), api.NotesBody{Notes: notes})
+}
+
+// resolves a (possibly renumbered) mid to the current one
+func (c *Client) ResolveElement(mid string) (api.ElementAlias, error) {
+	return requestJSON[api.ElementAlias](c, http.MethodGet, "elements/resolve", midQuery(mid), nil)
+}
+
+// lists the renumberings of the elements
+func (c *Client) ListElementAliases() ([]api.MidAlias, error) {
+	return requestJSON[[]api.MidAlias](c, http.MethodGet, "elements/aliases", nil, nil)
+}
+
+// renumbers an element, the old mid resolves to the new one afterwards
+func (c *Client) RenumberElement(mid, to string) ([]api.MidAlias, error) {
+	return requestJSON[[]api.MidAlias](c, http.MethodPost, "elements/renumber", url.Values{"mid": {mid}, "to": {to}}, nil)
+}
+
+// lists the elements hidden from the public
+func (c *Client) ListHiddenElements() ([]string, error) {
+	return requestJSON[[]string](c, http.MethodGet, "elements/visibility", nil, nil)
+}
+
+// shows or hides elements from the public
+func (c *Client) SetElementsVisibility(mids []string, visible bool) ([]string, error) {
+	return requestJSON[[]string](c, http.MethodPatch, "elements/visibility", nil, api.VisibilityBody{Mids: mids, Visible: visible})
+}
+
+// generates the elements of a range and reports which of them exist, are free or conflict
+// with the valid elements of the config
+func (c *Client) GenerateElements(prefix string, from, to int) (api.GeneratedElements, error) {
+	return requestJSON[api.GeneratedElements](c, http.MethodPost, "elements/generate", nil, api.GenerateElementsBody{Prefix: prefix, From: from, To: to})
+}
+
+// lists all the open reservations
+func (c *Client) ListReservations() ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodGet, "reservations", nil, nil)
+}
+
+// confirms a reservation and sends the certificate to the sponsor
+func (c *Client) ConfirmReservation(mid string) ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodPost, "reservations", midQuery(mid), nil)
+}
+
+// approves a reservation awaiting approval
+func (c *Client) ApproveReservation(mid string) ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodPost, "reservations/approve", midQuery(mid), nil)
+}
+
+// rejects a reservation awaiting approval, the sponsor is informed with the reason
+func (c *Client) RejectReservation(mid, reason string) ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodPost, "reservations/reject", midQuery(mid), api.RejectionBody{Reason: reason})
+}
+
+// changes the name of a reservation
+func (c *Client) UpdateReservation(mid, name string) ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodPatch, "reservations", midQuery(mid), api.NameBody{Name: name})
+}
+
+// removes a reservation
+func (c *Client) DeleteReservation(mid string) ([]api.Reservation, error) {
+	return requestJSON[[]api.Reservation](c, http.MethodDelete, "reservations", midQuery(mid), nil)
+}
+
+// lists all the confirmed sponsorships
+func (c *Client) ListSponsorships() ([]api.ElementDBNoReservation, error) {
+	return requestJSON[[]api.ElementDBNoReservation](c, http.MethodGet, "sponsorships", nil, nil)
+}
+
+// changes the name of a sponsorship
+func (c *Client) UpdateSponsorship(mid, name string) ([]api.ElementDBNoReservation, error) {
+	return requestJSON[[]api.ElementDBNoReservation](c, http.MethodPatch, "sponsorships", midQuery(mid), api.NameBody{Name: name})
+}
+
+// opts a sponsorship in or out of the thank-you-mail
+func (c *Client) SetSponsorshipOptOut(mid string, optOut bool) ([]api.ElementDBNoReservation, error) {
+	return requestJSON[[]api.ElementDBNoReservation](c, http.MethodPatch, "sponsorships/optout", midQuery(mid), api.OptOutBody{OptOut: optOut})
+}
+
+// lists the rows a destructive request (e.g. DELETE "sponsorships") would change, without
+// executing it
+func (c *Client) DryRun(method, endpoint string, query url.Values, body any) (api.DryRun, error) {
+	dryQuery := url.Values{"dry_run": {"true"}}
+	for key, values := range query {
+		dryQuery[key] = values
+	}
+
+	return requestJSON[api.DryRun](c, method, endpoint, dryQuery, body)
+}
+
+// removes a sponsorship
+func (c *Client) DeleteSponsorship(mid string) ([]api.ElementDBNoReservation, error) {
+	return requestJSON[[]api.ElementDBNoReservation](c, http.MethodDelete, "sponsorships", midQuery(mid), nil)
+}
+
+// downloads the certificate of an element as pdf
+func (c *Client) GetCertificate(mid string) ([]byte, error) {
+	return c.request(http.MethodGet, "certificates", midQuery(mid), nil)
+}
+
+// verifies a certificate by the verification-code printed on it
+func (c *Client) VerifyCertificate(code string) (api.CertificateVerification, error) {
+	return requestJSON[api.CertificateVerification](c, http.MethodGet, "certificates/verify", url.Values{"code": {code}}, nil)
+}
+
+// creates a signed link for downloading the certificate of an element without a login
+func (c *Client) GetCertificateLink(mid string) (api.CertificateLink, error) {
+	return requestJSON[api.CertificateLink](c, http.MethodGet, "certificates/link", midQuery(mid), nil)
+}
+
+// downloads a certificate with a signed link, the token is the one of the link
+func (c *Client) DownloadCertificate(mid, token string) ([]byte, error) {
+	return c.request(http.MethodGet, "certificates/download", url.Values{"mid": {mid}, "token": {token}}, nil)
+}
+
+// lists the notifications of the user, optionally only the unread ones
+func (c *Client) ListNotifications(unread bool) ([]api.Notification, error) {
+	return requestJSON[[]api.Notification](c, http.MethodGet, "notifications", url.Values{"unread": {strconv.FormatBool(unread)}}, nil)
+}
+
+// marks a notification as read, a negative nid marks all notifications of the user
+func (c *Client) MarkNotificationsRead(nid int) ([]api.Notification, error) {
+	query := url.Values{}
+
+	if nid >= 0 {
+		query.Set("nid", strconv.Itoa(nid))
+	}
+
+	return requestJSON[[]api.Notification](c, http.MethodPatch, "notifications/read", query, nil)
+}
+
+// lists the api-keys of the partner-websites with their usage
+func (c *Client) ListAPIKeys() ([]api.APIKey, error) {
+	return requestJSON[[]api.APIKey](c, http.MethodGet, "apikeys", nil, nil)
+}
+
+// retrieves the daily usage of an api-key
+func (c *Client) GetAPIKeyUsage(kid int) ([]api.APIKeyUsage, error) {
+	return requestJSON[[]api.APIKeyUsage](c, http.MethodGet, "apikeys/usage", url.Values{"kid": {strconv.Itoa(kid)}}, nil)
+}
+
+// creates an api-key for a partner-website
+func (c *Client) CreateAPIKey(body api.APIKeyBody) (api.NewAPIKey, error) {
+	return requestJSON[api.NewAPIKey](c, http.MethodPost, "apikeys", nil, body)
+}
+
+// revokes an api-key
+func (c *Client) RevokeAPIKey(kid int) ([]api.APIKey, error) {
+	return requestJSON[[]api.APIKey](c, http.MethodDelete, "apikeys", url.Values{"kid": {strconv.Itoa(kid)}}, nil)
+}
+
+// lists the mail-campaigns
+func (c *Client) ListCampaigns() ([]api.Campaign, error) {
+	return requestJSON[[]api.Campaign](c, http.MethodGet, "campaigns", nil, nil)
+}
+
+// retrieves the delivery-report of a mail-campaign
+func (c *Client) GetCampaignReport(cid int) (api.CampaignReport, error) {
+	return requestJSON[api.CampaignReport](c, http.MethodGet, "campaigns/report", url.Values{"cid": {strconv.Itoa(cid)}}, nil)
+}
+
+// creates a mail-campaign, the mails are queued and sent throttled
+func (c *Client) CreateCampaign(body api.CampaignBody) (api.CampaignReport, error) {
+	return requestJSON[api.CampaignReport](c, http.MethodPost, "campaigns", nil, body)
+}
+
+// retrieves the custom fields of the reservation-form
+func (c *Client) GetFields() ([]config.CustomField, error) {
+	return requestJSON[[]config.CustomField](c, http.MethodGet, "public/fields", nil, nil)
+}
+
+// lists the mails queued while the mail-server isn't available
+func (c *Client) ListQueuedMails() ([]api.QueuedMail, error) {
+	return requestJSON[[]api.QueuedMail](c, http.MethodGet, "admin/mails", nil, nil)
+}
+
+// lists the error-messages logged recently, optionally only the ones seen since a time ("YYYY-MM-DD HH:MM:SS")
+func (c *Client) ListErrors(since string) ([]api.ErrorSummary, error) {
+	var query url.Values
+	if since != "" {
+		query = url.Values{"since": {since}}
+	}
+
+	return requestJSON[[]api.ErrorSummary](c, http.MethodGet, "admin/errors", query, nil)
+}
+
+// exports the postal-addresses of the printed certificates as csv, with all set including
+// the ones already marked as sent
+func (c *Client) ExportAddresses(all bool) ([]byte, error) {
+	return c.request(http.MethodGet, "export/addresses", url.Values{"all": {strconv.FormatBool(all)}}, nil)
+}
+
+// exports the short-links of the elements as csv, e.g. for printing the qr-stickers of a plant
+// (all plants if plant is empty)
+func (c *Client) ExportShortlinks(plant string) ([]byte, error) {
+	query := url.Values{}
+	if plant != "" {
+		query.Set("plant", plant)
+	}
+
+	return c.request(http.MethodGet, "export/shortlinks", query, nil)
+}
+
+// marks the postal-addresses of the elements as sent
+func (c *Client) MarkAddressesSent(mids []string) error {
+	_, err := c.request(http.MethodPost, "export/addresses", nil, api.MidsBody{Mids: mids})
+
+	return err
+}
+
+// retrieves the atom-feed of the recently confirmed sponsorships
+func (c *Client) GetFeed() ([]byte, error) {
+	return c.request(http.MethodGet, "public/feed.xml", nil, nil)
+}
+
+// retrieves the version of the running backend
+func (c *Client) GetVersion() (api.Version, error) {
+	return requestJSON[api.Version](c, http.MethodGet, "version", nil, nil)
+}
+
+// retrieves the next free elements, optionally of an element-type (prefix of the mids, e.g. "pv")
+func (c *Client) GetNextFreeElements(elementType string, count int) ([]string, error) {
+	query := url.Values{"count": {strconv.Itoa(count)}}
+	if elementType != "" {
+		query.Set("type", elementType)
+	}
+
+	return requestJSON[[]string](c, http.MethodGet, "elements/next-free", query, nil)
+}
+
+// retrieves a page (starting at 1) of the overview of all elements, optionally only the ones in a state
+func (c *Client) GetElementsOverview(page, perPage int, state string) (api.AdminElements, error) {
+	query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {strconv.Itoa(perPage)}}
+	if state != "" {
+		query.Set("state", state)
+	}
+
+	return requestJSON[api.AdminElements](c, http.MethodGet, "elements/admin", query, nil)
+}
+
+// evaluates a mid against the rules of the valid elements
+func (c *Client) CheckMid(mid string) (api.MidCheck, error) {
+	return requestJSON[api.MidCheck](c, http.MethodGet, "elements/check", url.Values{"mid": {mid}}, nil)
+}
+
+// injects faults into a debug-build of the backend
+func (c *Client) InjectFaults(body api.ChaosBody) (api.ChaosState, error) {
+	return requestJSON[api.ChaosState](c, http.MethodPost, "admin/chaos", nil, body)
+}
+
+// resets the faults injected into a debug-build of the backend
+func (c *Client) ResetFaults() (api.ChaosState, error) {
+	return requestJSON[api.ChaosState](c, http.MethodDelete, "admin/chaos", nil, nil)
+}
+
+// replaces the elements and their reservations of a dev-build of the backend with demo-data
+func (c *Client) ResetDevData() (api.DevReset, error) {
+	return requestJSON[api.DevReset](c, http.MethodPost, "dev/reset", nil, nil)
+}
+
+// retrieves the lock-conflicts of the database-writes
+func (c *Client) GetDatabaseMetrics() (api.DatabaseMetrics, error) {
+	return requestJSON[api.DatabaseMetrics](c, http.MethodGet, "admin/database", nil, nil)
+}
+
+// retrieves the options of the config-file with their defaults and documentation
+func (c *Client) GetConfigSchema() ([]config.SchemaEntry, error) {
+	return requestJSON[[]config.SchemaEntry](c, http.MethodGet, "admin/config/schema", nil, nil)
+}
+
+// retrieves the usage of the renderers of the certificates
+func (c *Client) GetCertificateMetrics() (api.CertificateMetrics, error) {
+	return requestJSON[api.CertificateMetrics](c, http.MethodGet, "admin/certificates", nil, nil)
+}
+
+// retrieves the results of the comparisons of the cached elements with the database
+func (c *Client) GetConsistencyMetrics() (api.ConsistencyMetrics, error) {
+	return requestJSON[api.ConsistencyMetrics](c, http.MethodGet, "admin/consistency", nil, nil)
+}
+
+// retrieves the state of the background-jobs
+func (c *Client) ListJobs() ([]api.JobStatus, error) {
+	return requestJSON[[]api.JobStatus](c, http.MethodGet, "admin/jobs", nil, nil)
+}
+
+// runs a background-job immediately and returns its state afterwards
+func (c *Client) RunJob(name string) (api.JobStatus, error) {
+	return requestJSON[api.JobStatus](c, http.MethodPost, "admin/jobs/run", url.Values{"name": {name}}, nil)
+}
+
+// retrieves the usage of the cache-namespaces
+func (c *Client) GetCacheMetrics() ([]api.CacheMetrics, error) {
+	return requestJSON[[]api.CacheMetrics](c, http.MethodGet, "admin/cache", nil, nil)
+}
+
+// retrieves the paths of the current and the rotated logfiles
+func (c *Client) GetLogFiles() (api.LogFiles, error) {
+	return requestJSON[api.LogFiles](c, http.MethodGet, "admin/logs", nil, nil)
+}
+
+// lists the groups of elements probably belonging to the same sponsor
+func (c *Client) ListDuplicates() ([]api.DuplicateGroup, error) {
+	return requestJSON[[]api.DuplicateGroup](c, http.MethodGet, "sponsors/duplicates", nil, nil)
+}
+
+// lists the merges of sponsors, newest first
+func (c *Client) ListMerges() ([]api.Merge, error) {
+	return requestJSON[[]api.Merge](c, http.MethodGet, "sponsors/merges", nil, nil)
+}
+
+// merges the contacts of the elements into the one of the canonical element
+func (c *Client) MergeSponsors(canonical string, mids []string) ([]api.Merge, error) {
+	return requestJSON[[]api.Merge](c, http.MethodPost, "sponsors/merges", nil, api.MergeBody{Canonical: canonical, Mids: mids})
+}
+
+// undoes a merge of sponsors within the undo-window
+func (c *Client) UndoMerge(mgid int) ([]api.Merge, error) {
+	return requestJSON[[]api.Merge](c, http.MethodDelete, "sponsors/merges", url.Values{"mgid": {strconv.Itoa(mgid)}}, nil)
+}
+
+// lists the features with their states
+func (c *Client) ListFeatures() ([]api.Feature, error) {
+	return requestJSON[[]api.Feature](c, http.MethodGet, "admin/features", nil, nil)
+}
+
+// switches a feature at runtime, until it is reset to the config
+func (c *Client) SetFeature(name string, enabled bool) ([]api.Feature, error) {
+	return requestJSON[[]api.Feature](c, http.MethodPatch, "admin/features", nil, api.FeatureBody{Name: name, Enabled: enabled})
+}
+
+// resets a feature switched at runtime to the config
+func (c *Client) ResetFeature(name string) ([]api.Feature, error) {
+	return requestJSON[[]api.Feature](c, http.MethodDelete, "admin/features", url.Values{"name": {name}}, nil)
+}
+
+// lists the matching-donations of the employers, optionally only the "pending" or "confirmed" ones
+func (c *Client) ListMatchings(state string) ([]api.Matching, error) {
+	var query url.Values
+	if state != "" {
+		query = url.Values{"state": {state}}
+	}
+
+	return requestJSON[[]api.Matching](c, http.MethodGet, "matchings", query, nil)
+}
+
+// confirms the payment of a matching-donation, amount nil for the expected one
+func (c *Client) ConfirmMatching(maid int, amount *float64) ([]api.Matching, error) {
+	return requestJSON[[]api.Matching](c, http.MethodPost, "matchings/confirm", url.Values{"maid": {strconv.Itoa(maid)}}, api.MatchingConfirmBody{Amount: amount})
+}
+
+// removes a pending matching-donation
+func (c *Client) DeleteMatching(maid int) ([]api.Matching, error) {
+	return requestJSON[[]api.Matching](c, http.MethodDelete, "matchings", url.Values{"maid": {strconv.Itoa(maid)}}, nil)
+}
+
+// lists the names of the sponsors awaiting the moderation
+func (c *Client) ListModerationNames() ([]api.NameModeration, error) {
+	return requestJSON[[]api.NameModeration](c, http.MethodGet, "moderation/names", nil, nil)
+}
+
+// approves the name of a sponsor for the public display, it has to match the current one
+func (c *Client) ApproveName(mid, name string) ([]api.NameModeration, error) {
+	return requestJSON[[]api.NameModeration](c, http.MethodPost, "moderation/names/approve", midQuery(mid), api.NameBody{Name: name})
+}
+
+// rejects the name of a sponsor, the sponsorship is shown publicly without it
+func (c *Client) RejectName(mid, name string) ([]api.NameModeration, error) {
+	return requestJSON[[]api.NameModeration](c, http.MethodPost, "moderation/names/reject", midQuery(mid), api.NameBody{Name: name})
+}
+
+// retrieves a suggested correction of a typo in the domain of a mail-address
+func (c *Client) SuggestMail(mail string) (api.MailSuggestion, error) {
+	return requestJSON[api.MailSuggestion](c, http.MethodGet, "public/mail/suggest", url.Values{"mail": {mail}}, nil)
+}
+
+// retrieves the plants with their elements
+func (c *Client) GetPlants() ([]config.Plant, error) {
+	return requestJSON[[]config.Plant](c, http.MethodGet, "public/plants", nil, nil)
+}
+
+// retrieves the branding of the deployment
+func (c *Client) GetBranding() (config.Branding, error) {
+	return requestJSON[config.Branding](c, http.MethodGet, "public/branding", nil, nil)
+}
+
+// retrieves the current versions of the legal documents
+func (c *Client) GetLegal() ([]config.LegalDocument, error) {
+	return requestJSON[[]config.LegalDocument](c, http.MethodGet, "public/legal", nil, nil)
+}
+
+// requests the certificates of a mail-address to be sent to it again
+func (c *Client) ResendCertificates(mail string) error {
+	_, err := c.request(http.MethodPost, "certificates/resend", nil, api.ResendBody{Mail: mail})
+
+	return err
+}
+
+// lists the consents stored for a mail-address
+func (c *Client) ListConsents(mail string) ([]api.ConsentDB, error) {
+	return requestJSON[[]api.ConsentDB](c, http.MethodGet, "consents", url.Values{"mail": {mail}}, nil)
+}
+
+// lists the archived sponsorships, optionally of a single element
+func (c *Client) ListArchivedSponsorships(mid string) ([]api.ArchivedSponsorship, error) {
+	query := url.Values{}
+	if mid != "" {
+		query.Set("mid", mid)
+	}
+
+	return requestJSON[[]api.ArchivedSponsorship](c, http.MethodGet, "sponsorships/archive", query, nil)
+}
+
+// retrieves the expected donations of the elements
+func (c *Client) GetPrices() (api.PriceList, error) {
+	return requestJSON[api.PriceList](c, http.MethodGet, "public/prices", nil, nil)
+}
+
+// renders a certificate with arbitrary data without issuing it
+func (c *Client) PreviewCertificate(mid, name string) ([]byte, error) {
+	return c.request(http.MethodGet, "certificates/preview", url.Values{"mid": {mid}, "name": {name}}, nil)
+}
+
+// retrieves the yield of the plant
+func (c *Client) GetYield() (api.Yield, error) {
+	return requestJSON[api.Yield](c, http.MethodGet, "public/yield", nil, nil)
+}
+
+// retrieves the share of the yield attributed to an element
+func (c *Client) GetElementYield(mid string) (api.ElementYield, error) {
+	return requestJSON[api.ElementYield](c, http.MethodGet, "public/yield/element", midQuery(mid), nil)
+}
+
+// downloads the donations confirmed in a year as DATEV-"Buchungsstapel"
+func (c *Client) ExportDatev(year int) ([]byte, error) {
+	return c.request(http.MethodGet, "export/datev", url.Values{"year": {strconv.Itoa(year)}}, nil)
+}
+
+// retrieves the sponsorships confirmed at the end of a day ("YYYY-MM-DD")
+func (c *Client) GetSnapshot(date string) (api.SponsorshipSnapshot, error) {
+	return requestJSON[api.SponsorshipSnapshot](c, http.MethodGet, "reports/snapshot", url.Values{"date": {date}}, nil)
+}
+
+// lists the recorded clients of the public reservations, of a single element if mid isn't empty
+func (c *Client) ListReservationClients(mid string) ([]api.ReservationClient, error) {
+	query := url.Values{}
+	if mid != "" {
+		query.Set("mid", mid)
+	}
+
+	return requestJSON[[]api.ReservationClient](c, http.MethodGet, "reservations/clients", query, nil)
+}
+
+// lists all newsletter-subscriptions
+func (c *Client) ListNewsletter() ([]api.NewsletterDB, error) {
+	return requestJSON[[]api.NewsletterDB](c, http.MethodGet, "newsletter", nil, nil)
+}
+
+// unsubscribes a mail-address from the newsletter with the token of the unsubscribe-link
+func (c *Client) Unsubscribe(mail, token string) error {
+	_, err := c.request(http.MethodDelete, "newsletter", url.Values{"mail": {mail}, "token": {token}}, nil)
+
+	return err
+}
+
+// retrieves the state of the maintenance-mode
+func (c *Client) GetMaintenance() (api.Maintenance, error) {
+	return requestJSON[api.Maintenance](c, http.MethodGet, "admin/maintenance", nil, nil)
+}
+
+// enables or disables the maintenance-mode
+func (c *Client) SetMaintenance(maintenance api.Maintenance) (api.Maintenance, error) {
+	return requestJSON[api.Maintenance](c, http.MethodPost, "admin/maintenance", nil, maintenance)
+}
+
+// retrieves the statistics about the elements
+func (c *Client) GetStats() (api.Stats, error) {
+	return requestJSON[api.Stats](c, http.MethodGet, "stats", nil, nil)
+}
+
+// lists all users
+func (c *Client) ListUsers() ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodGet, "users", nil, nil)
+}
+
+// adds a new user, a verification-link is sent to the mail-address
+func (c *Client) AddUser(name, password, mail string) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodPost, "users", nil, api.AddUserBody{Name: name, Password: password, Mail: mail})
+}
+
+// grants the admin-role to a user, with "users.admin_approval" it has to be confirmed by another admin
+func (c *Client) GrantAdmin(uid int) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodPost, "users/admin", uidQuery(uid), nil)
+}
+
+// confirms the admin-role requested for a user by another admin
+func (c *Client) ApproveAdmin(uid int) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodPost, "users/admin/approve", uidQuery(uid), nil)
+}
+
+// revokes the admin-role of a user or rejects its pending grant. With "users.admin_approval",
+// revoking it has to be confirmed by another admin
+func (c *Client) RevokeAdmin(uid int) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodDelete, "users/admin", uidQuery(uid), nil)
+}
+
+// sets wether a user may issue certificates and confirm payments
+func (c *Client) SetUserCapabilities(uid int, body api.CapabilitiesBody) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodPatch, "users/capabilities", url.Values{"uid": {strconv.Itoa(uid)}}, body)
+}
+
+// sets the password of a user. With "users.admin_approval", admins get a reset-link instead
+func (c *Client) SetUserPassword(uid int, password string) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodPatch, "users", uidQuery(uid), api.PasswordBody{Password: password})
+}
+
+// removes a user
+func (c *Client) DeleteUser(uid int) ([]api.UserOverview, error) {
+	return requestJSON[[]api.UserOverview](c, http.MethodDelete, "users", uidQuery(uid), nil)
+}
+
+// changes the password of the logged-in user, the current one is required for it
+func (c *Client) ChangePassword(current, password string) error {
+	_, err := c.request(http.MethodPatch, "user/password", nil, api.UserPasswordBody{PasswordBody: api.PasswordBody{Password: password}, Current: current})
+
+	return err
+}
+
+// changes the mail-address of the logged-in user, a verification-link is sent to it
+func (c *Client) ChangeMail(mail string) error {
+	_, err := c.request(http.MethodPatch, "user/mail", nil, api.UserMailBody{Mail: mail})
+
+	return err
+}
+
+// requests a password-reset-link for a user, which is sent to their verified mail-address
+func (c *Client) RequestPasswordReset(name string) error {
+	_, err := c.request(http.MethodPost, "user/password/reset", nil, api.PasswordResetRequestBody{Name: name})
+
+	return err
+}
+
+// sets a new password with the uid and the token of a reset-link
+func (c *Client) ResetPassword(uid int, token, password string) error {
+	_, err := c.request(http.MethodPatch, "user/password/reset", uidQuery(uid), api.PasswordResetBody{PasswordBody: api.PasswordBody{Password: password}, Token: token})
+
+	return err
+}
@@ -0,0 +1,962 @@
+// Package config loads the configuration of the backend.
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// additional field of the reservation-form
+type CustomField struct {
+	Name  string `yaml:"name" json:"name"`
+	Label string `yaml:"label" json:"label"`
+	// one of "text", "bool", "number" or "select"
+	Type     string   `yaml:"type" json:"type"`
+	Required bool     `yaml:"required" json:"required"`
+	Options  []string `yaml:"options" json:"options"`
+	// maximum length of text-fields, defaults to 255
+	MaxLength int `yaml:"max_length" json:"max_length" default:"255"`
+}
+
+// legal document the sponsors have to consent to
+type LegalDocument struct {
+	// kind of the document, e.g. "privacy"
+	Kind string `yaml:"kind" json:"kind"`
+	// version of the current text, has to be changed with every change of the text
+	Version string `yaml:"version" json:"version"`
+	URL     string `yaml:"url" json:"url"`
+}
+
+// type of elements (e.g. pv-modules or battery-storages), selected by the prefix of the mids
+type ElementType struct {
+	// name used in the certificates and mails, e.g. "PV-Modul"
+	Name string `yaml:"name"`
+	// article of the name in the accusative, e.g. "das"
+	Article string `yaml:"article"`
+	// directory of the certificate-templates inside "templates", empty for the ones of the plant
+	Certificates string `yaml:"certificates"`
+}
+
+// default element-types, used if "element_types" is empty
+var DefaultElementTypes = map[string]ElementType{
+	"pv": {Name: "PV-Modul", Article: "das"},
+	"bs": {Name: "Batteriespeicher", Article: "den"},
+}
+
+// plant (e.g. a roof or a building) with its own elements
+type Plant struct {
+	ID   string `yaml:"id" json:"id"`
+	Name string `yaml:"name" json:"name"`
+	// descriptors of the elements of the plant, as in "validate_elements.valid_elements"
+	Elements []string `yaml:"elements" json:"elements"`
+	// directory of the certificate-templates inside "templates", empty for the default ones
+	Certificates string `yaml:"certificates" json:"-"`
+	// users managing the plant. Users who aren't assigned to any plant manage all of them
+	Users []string `yaml:"users" json:"-"`
+}
+
+// link shown in the frontend, e.g. to the imprint
+type Link struct {
+	Label string `yaml:"label" json:"label"`
+	URL   string `yaml:"url" json:"url"`
+}
+
+// branding of the deployment, so the same frontend-build can serve multiple deployments
+type Branding struct {
+	// name of the association running the campaign
+	Name    string `yaml:"name" json:"name"`
+	LogoURL string `yaml:"logo_url" json:"logo_url"`
+	// colors of the frontend by their role as hex-codes (e.g. "primary": "#0a7f3f")
+	Colors map[string]string `yaml:"colors" json:"colors"`
+	// contact-address shown to the sponsors
+	Contact string `yaml:"contact" json:"contact"`
+	// links to the imprint, the privacy-policy etc.
+	Links []Link `yaml:"links" json:"links"`
+}
+
+// time-window in which public reservations of elements are disabled
+type Embargo struct {
+	// descriptor of the elements, as in "validate_elements.valid_elements"
+	Elements string `yaml:"elements"`
+	// range of the numbers of the elements, all elements of the descriptor if both are zero
+	From int `yaml:"from"`
+	To   int `yaml:"to"`
+	// start and end of the embargo ("YYYY-MM-DD HH:MM:SS"), empty for an open end
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+// chat-channel the events of the backend are pushed to
+type NotificationChannel struct {
+	// one of "telegram", "matrix" or "ntfy"
+	Type string `yaml:"type"`
+	// kinds of the events pushed to the channel, all if empty
+	Events []string `yaml:"events"`
+	// url of the matrix-homeserver or the ntfy-server (defaults to "https://ntfy.sh")
+	URL string `yaml:"url"`
+	// bot-token for telegram, access-token for matrix and ntfy (optional)
+	Token string `yaml:"token"`
+	// chat-id for telegram, room-id for matrix or topic for ntfy
+	Target string `yaml:"target"`
+}
+
+// namespaces of the cache, whose expiration can be set individually
+var CacheNamespaces = []string{"elements", "apikeys", "yield", "activity", "mx"}
+
+// display-modes of the sponsor-names in the public feed
+var FeedNames = []string{"full", "initials", "first", "anonymous"}
+
+// display-modes of the sponsor-names of the taken elements in the public status
+var PublicNames = []string{"full", "initials", "first", "none"}
+
+// features that can be switched per deployment
+var Features = []string{"matchings", "next-free", "v2-api"}
+
+// kinds of the events pushed to the notification-channels
+var NotificationEvents = []string{"reservation", "approval", "mail-failed", "expiring", "admin-approval", "matching", "database-down"}
+
+type ConfigYaml struct {
+	LogLevel string `yaml:"log_level"`
+	// rotation of the logfile
+	Log struct {
+		// path of the logfile, defaults to "logs/backend.log"
+		Filename string `yaml:"filename" default:"logs/backend.log"`
+		// size in megabytes before the file is rotated, defaults to 100
+		MaxSize int `yaml:"max_size" default:"100"`
+		// number of rotated files to keep, 0 keeps all of them
+		MaxBackups int `yaml:"max_backups"`
+		// duration the rotated files are kept, defaults to 7 days, "0s" keeps them forever
+		MaxAge string `yaml:"max_age" default:"168h"`
+		// wether the rotated files are compressed with gzip
+		Compress bool `yaml:"compress"`
+	} `yaml:"log"`
+	// locale of the dates and numbers in the mails and certificates, "de" or "en"
+	Locale   string `yaml:"locale"`
+	Database struct {
+		Host     string `yaml:"host"`
+		User     string `yaml:"user"`
+		Password string `yaml:"password"`
+		Database string `yaml:"database"`
+		// renamed columns per table as "new-name: old-name"
+		RenamedColumns map[string]map[string]string `yaml:"renamed_columns"`
+		// base64-encoded key (32 bytes) the contact-data of the sponsors is encrypted with, empty to disable
+		EncryptionKey string `yaml:"encryption_key"`
+		// retries of a write failing because of a deadlock or lock-timeout, zero to disable
+		Retries int `yaml:"retries"`
+		// delay before the first retry, doubled for every further one
+		RetryDelay string `yaml:"retry_delay" default:"50ms"`
+		// read-replica used for the read-only queries, empty host to disable.
+		// Empty credentials are taken from the primary
+		Replica struct {
+			Host     string `yaml:"host"`
+			User     string `yaml:"user"`
+			Password string `yaml:"password"`
+			Database string `yaml:"database"`
+		} `yaml:"replica"`
+	} `yaml:"database"`
+	Cache struct {
+		Expiration string `yaml:"expiration"`
+		Purge      string `yaml:"purge"`
+		// time the responses of the public endpoints (e.g. the verification) are cached, empty to disable
+		Responses string `yaml:"responses"`
+		// expiration per namespace (e.g. "apikeys: 10m"), the others use "expiration"
+		Namespaces map[string]string `yaml:"namespaces"`
+		// interval of the comparison of the cached elements with the database, empty to disable it
+		ConsistencyCheck string `yaml:"consistency_check"`
+	} `yaml:"cache"`
+	ClientSession struct {
+		JwtSignature string `yaml:"jwt_signature"`
+		Expire       string `yaml:"expire"`
+		// sessions without activity for this long have to log in again, empty to disable
+		IdleTimeout string `yaml:"idle_timeout"`
+	} `yaml:"client_session"`
+	Server struct {
+		// port on all interfaces, used if no listen-addresses are given
+		Port int `yaml:"port"`
+		// addresses the api is served on: "host:port" (IPv6-hosts in brackets) or
+		// "unix:<path>" for a unix-socket
+		Listen []string `yaml:"listen"`
+		// addresses the endpoints requiring a login are served on, in the same format. If given,
+		// the addresses of "listen" only serve the public endpoints
+		AdminListen []string `yaml:"admin_listen"`
+		// permissions of the unix-sockets, e.g. "0660"
+		SocketMode string `yaml:"socket_mode" default:"0660"`
+		// reverse-proxies (addresses or CIDR-ranges) whose proxy-header is trusted for the client-address
+		TrustedProxies []string `yaml:"trusted_proxies"`
+		// header the trusted proxies send the client-address in, defaults to "X-Forwarded-For"
+		ProxyHeader string `yaml:"proxy_header" default:"X-Forwarded-For"`
+	} `yaml:"server"`
+	Reservation struct {
+		Expiration  string `yaml:"expiration"`
+		MaxPerMail  int    `yaml:"max_per_mail"`
+		LimitWindow string `yaml:"limit_window"`
+		// the next reservation of an element has to be approved by an admin, after its reservations
+		// expired this often within the window (e.g. as blocking attempt), zero to disable
+		ThrottleExpiries int    `yaml:"throttle_expiries"`
+		ThrottleWindow   string `yaml:"throttle_window" default:"720h"`
+		// new reservations have to be approved by an admin before they are shown publicly
+		RequireApproval bool `yaml:"require_approval"`
+		// page of the website showing the status of a reservation, linked in the reservation-mail
+		StatusURL string `yaml:"status_url"`
+		// time the client-address and the user-agent of the public reservations are kept for
+		// investigating abuse, empty to not record them
+		ClientRetention string `yaml:"client_retention"`
+	} `yaml:"reservation"`
+	Mail struct {
+		Server     string `yaml:"server"`
+		Port       int    `yaml:"port"`
+		Encryption string `yaml:"encryption"`
+		User       string `yaml:"user"`
+		Password   string `yaml:"password"`
+		// identical mails to the same recipient within this time are only sent once, "0s" to disable
+		DuplicateWindow string `yaml:"duplicate_window" default:"10m"`
+		// maximum number of mails sent per hour, zero for no limit
+		MaxPerHour int `yaml:"max_per_hour"`
+		Templates  struct {
+			ReservationSubject string `yaml:"reservation_subject"`
+			CertificateSubject string `yaml:"certificate_subject"`
+		} `yaml:"subject_templates"`
+	} `yaml:"mail"`
+	ThankYou struct {
+		Enabled bool `yaml:"enabled"`
+		// time after the confirmation, when the thank-you-mail is sent
+		Delay string `yaml:"delay" default:"4380h"`
+		// interval in which the sponsorships are checked for due thank-you-mails
+		Interval string `yaml:"interval" default:"1h"`
+		// estimated yearly yield of the plant in kWh
+		PlantYield float64 `yaml:"plant_yield"`
+	} `yaml:"thank_you"`
+	Monitoring struct {
+		// one of "fronius", "solaredge" or empty to disable the monitoring
+		Provider string `yaml:"provider"`
+		URL      string `yaml:"url"`
+		SiteID   string `yaml:"site_id"`
+		APIKey   string `yaml:"api_key"`
+		Interval string `yaml:"interval" default:"15m"`
+		// strings of the plant per element-group (e.g. "pv-a": "1")
+		Strings map[string]string `yaml:"strings"`
+	} `yaml:"monitoring"`
+	Newsletter struct {
+		// page of the website handling the unsubscribe-links
+		UnsubscribeURL string `yaml:"unsubscribe_url"`
+	} `yaml:"newsletter"`
+	Export struct {
+		// file the public state of the elements is written to on every change, empty to disable
+		Path string `yaml:"path"`
+		// interval in which the elements are checked for changes
+		Interval string `yaml:"interval" default:"1m"`
+	} `yaml:"export"`
+	// export of the confirmed donations for the accounting
+	Datev struct {
+		// number of the tax-consultant ("Beraternummer") and of the client ("Mandantennummer")
+		ConsultantNumber int `yaml:"consultant_number"`
+		ClientNumber     int `yaml:"client_number"`
+		// length of the general-ledger-accounts ("Sachkontenlänge")
+		AccountLength int `yaml:"account_length"`
+		// account receiving the donations (e.g. the bank-account), empty to disable the export
+		Account string `yaml:"account"`
+		// account the donations are booked on
+		ContraAccount string `yaml:"contra_account"`
+	} `yaml:"datev"`
+	// postal-addresses of the sponsors receiving a printed certificate
+	Postal struct {
+		Enabled bool `yaml:"enabled"`
+		// base64-encoded key (32 bytes) the addresses are encrypted with, generated by the setup
+		Key string `yaml:"key"`
+	} `yaml:"postal"`
+	// checks of the mail-addresses of new reservations
+	MailCheck struct {
+		// wether the domain has to have mx-records
+		MX bool `yaml:"mx"`
+		// file with the domains of disposable mail-providers, one per line. Empty to disable
+		Blocklist string `yaml:"blocklist"`
+		// additional blocked domains
+		BlockedDomains []string `yaml:"blocked_domains"`
+		// wether reservations with a probable typo in the mail-domain (e.g. "gmial.com") are
+		// rejected with a suggested correction, until the sponsor confirms the address
+		Suggestions bool `yaml:"suggestions"`
+		// domains the typos are corrected to, besides the common mail-providers
+		KnownDomains []string `yaml:"known_domains"`
+	} `yaml:"mail_check"`
+	// names of the sponsors of the taken elements in the public status: "full", "initials"
+	// (e.g. "Max M."), "first" or "none" to only show them as taken. Empty for "full"
+	PublicNames string `yaml:"public_names" default:"full"`
+	// names of the sponsors are only shown publicly (status and feed) after an admin approved them
+	NameModeration struct {
+		Enabled bool `yaml:"enabled"`
+		// names approved for an earlier sponsorship of the same mail-address are approved automatically
+		AutoApproveReturning bool `yaml:"auto_approve_returning"`
+	} `yaml:"name_moderation"`
+	// public atom-feed of the recently confirmed sponsorships
+	Feed struct {
+		Enabled bool   `yaml:"enabled"`
+		Title   string `yaml:"title"`
+		// shown sponsor-names: "full", "initials" (e.g. "Max M."), "first" or "anonymous"
+		Names string `yaml:"names"`
+		// name shown instead of the sponsor's with "anonymous"
+		Anonymous string `yaml:"anonymous"`
+		// number of included sponsorships
+		Entries int `yaml:"entries"`
+	} `yaml:"feed"`
+	// short-links to the elements ("/m/<mid>") for the printed flyers and the qr-stickers on the modules
+	ShortLinks struct {
+		// page of the website the short-links redirect to, the mid is added as "mid" to the query.
+		// Empty disables the short-links
+		FrontendURL string `yaml:"frontend_url"`
+		// public address of the backend the short-links start with, empty to derive it from the request
+		BaseURL string `yaml:"base_url"`
+	} `yaml:"short_links"`
+	// additional fields of the reservation-form
+	CustomFields []CustomField `yaml:"custom_fields"`
+	Branding     Branding      `yaml:"branding"`
+	// time-windows in which public reservations of elements are disabled
+	Embargoes []Embargo `yaml:"embargoes"`
+	// legal documents the sponsors have to consent to when reserving
+	Legal []LegalDocument `yaml:"legal"`
+	// plants with their elements, empty for a single plant with all elements
+	Plants []Plant `yaml:"plants"`
+	// types of the elements by the prefix of their mids (the part before the first "-")
+	ElementTypes map[string]ElementType `yaml:"element_types"`
+	Campaigns    struct {
+		// maximum number of campaign-mails sent per minute
+		MailsPerMinute int `yaml:"mails_per_minute" default:"30"`
+	} `yaml:"campaigns"`
+	Users struct {
+		// deactivate accounts without activity for this long, empty to keep them active
+		DeactivateAfter string `yaml:"deactivate_after"`
+		// page of the frontend the password-reset-links lead to, empty to disable the resets
+		ResetURL string `yaml:"reset_url"`
+		// validity of the password-reset-links
+		ResetExpire string `yaml:"reset_expire" default:"1h"`
+		// kinds of the notifications that are mailed to the users with a verified mail-address as well
+		MailNotifications []string `yaml:"mail_notifications"`
+		// granting or revoking the admin-role has to be confirmed by another admin within 24 hours
+		AdminApproval bool `yaml:"admin_approval"`
+		// hashing of the passwords, stored passwords are re-hashed with the next login
+		PasswordHashing struct {
+			// "bcrypt" or "argon2id"
+			Algorithm string `yaml:"algorithm" default:"bcrypt"`
+			// cost of bcrypt
+			BcryptCost int `yaml:"bcrypt_cost" default:"10"`
+			// memory of argon2id in KiB
+			Argon2Memory      int `yaml:"argon2_memory" default:"65536"`
+			Argon2Iterations  int `yaml:"argon2_iterations" default:"3"`
+			Argon2Parallelism int `yaml:"argon2_parallelism" default:"2"`
+		} `yaml:"password_hashing"`
+	} `yaml:"users"`
+	Certificates struct {
+		// renderer of the pdf-files: "inkscape" for the svg-templates or "fpdf" without external programs
+		Renderer string `yaml:"renderer" default:"inkscape"`
+		// endpoint of the signed download-links, empty to derive it from the request
+		DownloadURL string `yaml:"download_url"`
+		// validity of the signed download-links
+		LinkExpire string `yaml:"link_expire" default:"168h"`
+		// certificates rendered at the same time on request, each takes a renderer-process
+		MaxConcurrent int `yaml:"max_concurrent" default:"2"`
+		// requests waiting for a free renderer, further ones are rejected
+		QueueDepth int `yaml:"queue_depth" default:"10"`
+		// texts of the reservation printed on the certificates: "name", "dedication" or the names of
+		// custom text-fields. Defaults to only the name
+		Fields []string `yaml:"fields"`
+		// free-text dedication of the sponsors, collected if "fields" contains "dedication"
+		Dedication struct {
+			MaxLength int `yaml:"max_length" default:"200"`
+			// words rejected in the dedications, case-insensitive
+			BlockedWords []string `yaml:"blocked_words"`
+		} `yaml:"dedication"`
+	} `yaml:"certificates"`
+	// chat-channels the events are pushed to
+	NotificationChannels []NotificationChannel `yaml:"notification_channels"`
+	Merges               struct {
+		// time in which a merge of sponsors can be undone
+		UndoWindow string `yaml:"undo_window" default:"168h"`
+	} `yaml:"merges"`
+	// features switched on or off by their name, the others keep their default. They can be
+	// switched at runtime as well
+	Features map[string]bool `yaml:"features"`
+	Prices   struct {
+		// expected donation per element-type in euros (e.g. "pv": 100)
+		Types map[string]float64 `yaml:"types"`
+		// prices of individual elements, overriding the one of their type
+		Elements map[string]float64 `yaml:"elements"`
+	} `yaml:"prices"`
+	Maintenance struct {
+		// message returned while in maintenance-mode, if none is given when enabling it
+		Message string `yaml:"message"`
+	} `yaml:"maintenance"`
+	ValidateElements struct {
+		// regex capturing the parts of the mids, see MidScheme
+		Regex         string `yaml:"regex"`
+		ValidElements map[string]struct {
+			From int `yaml:"from"`
+			To   int `yaml:"to"`
+			// minimum digits of the numbers, shorter ones are padded with zeros (e.g. 2 for "pv-c2-07")
+			Digits int `yaml:"digits"`
+			// number of shares the elements are split into, each reservable separately. Zero for whole elements
+			Shares int `yaml:"shares"`
+			// example mids, checked against the rules on startup
+			Examples struct {
+				Valid   []string `yaml:"valid"`
+				Invalid []string `yaml:"invalid"`
+			} `yaml:"examples"`
+		} `yaml:"valid_elements"`
+	} `yaml:"validate_elements"`
+}
+
+type CacheConfig struct {
+	Expiration time.Duration
+	Purge      time.Duration
+	// zero if the responses aren't cached
+	Responses time.Duration
+	// expiration of the namespaces that don't use the default one
+	Namespaces map[string]time.Duration
+	// zero if the cached elements aren't compared with the database
+	ConsistencyCheck time.Duration
+}
+
+type ReservationConfig struct {
+	Expiration     time.Duration
+	LimitWindow    time.Duration
+	ThrottleWindow time.Duration
+	// zero if the clients of the reservations aren't recorded
+	ClientRetention time.Duration
+}
+
+type ThankYouConfig struct {
+	Delay    time.Duration
+	Interval time.Duration
+}
+
+type MonitoringConfig struct {
+	Interval time.Duration
+}
+
+type ExportConfig struct {
+	Interval time.Duration
+}
+
+type UsersConfig struct {
+	DeactivateAfter time.Duration
+	ResetExpire     time.Duration
+	// algorithm new passwords are hashed with
+	PasswordHasher lib.PasswordHasher
+}
+
+type MergesConfig struct {
+	UndoWindow time.Duration
+}
+
+// embargo with the parsed times, zero for an open end
+type EmbargoWindow struct {
+	Embargo
+	Start time.Time
+	End   time.Time
+}
+
+type ConfigStruct struct {
+	ConfigYaml
+	LogLevel zerolog.Level
+	// path of the logfile
+	LogFile string
+	// zero if the rotated logfiles are kept forever
+	LogMaxAge     time.Duration
+	SessionExpire time.Duration
+	// zero if sessions don't expire on inactivity
+	SessionIdleTimeout time.Duration
+	// zero if duplicate mails aren't suppressed
+	MailDuplicateWindow time.Duration
+	// delay before the first retry of a write after a lock-conflict
+	DatabaseRetryDelay time.Duration
+	// validity of the signed download-links of the certificates
+	CertificateLinkExpire time.Duration
+	Cache                 CacheConfig
+	Reservation           ReservationConfig
+	ThankYou              ThankYouConfig
+	Monitoring            MonitoringConfig
+	Export                ExportConfig
+	Users                 UsersConfig
+	Merges                MergesConfig
+	// key the postal-addresses are encrypted with, nil if they're disabled
+	PostalKey []byte
+	// key the contact-data in the database is encrypted with, nil if it's disabled
+	DatabaseKey []byte
+	// addresses the api is served on
+	Listen []ListenAddress
+	// addresses the endpoints requiring a login are served on, empty to serve them on "Listen"
+	AdminListen []ListenAddress
+	SocketMode  os.FileMode
+	Embargoes   []EmbargoWindow
+	Mids        MidScheme
+}
+
+type specificLevelWriter struct {
+	io.Writer
+	Level zerolog.Level
+}
+
+func (w specificLevelWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if l >= w.Level {
+		return w.Write(p)
+	} else {
+		return len(p), nil
+	}
+}
+
+type Payload struct {
+	jwt.RegisteredClaims
+	CustomClaims map[string]any
+}
+
+// creates a signed JSON webtoken with val as custom-claims
+func (config ConfigStruct) SignJWT(val any) (string, error) {
+	valMap, err := lib.StrucToMap(val)
+
+	if err != nil {
+		return "", err
+	}
+
+	payload := Payload{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.SessionExpire)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		CustomClaims: valMap,
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+
+	return t.SignedString([]byte(config.ClientSession.JwtSignature))
+}
+
+// decodes the key of the postal-addresses, if they're enabled
+func parsePostalKey(config ConfigYaml) ([]byte, error) {
+	if !config.Postal.Enabled {
+		return nil, nil
+	} else {
+		return parseKey(config.Postal.Key)
+	}
+}
+
+// decodes the key of the encrypted database-columns, nil if none is set
+func parseDatabaseKey(config ConfigYaml) ([]byte, error) {
+	if config.Database.EncryptionKey == "" {
+		return nil, nil
+	} else {
+		return parseKey(config.Database.EncryptionKey)
+	}
+}
+
+// decodes a base64-encoded AES-256-key
+func parseKey(encoded string) ([]byte, error) {
+	if key, err := base64.StdEncoding.DecodeString(encoded); err != nil {
+		return nil, err
+	} else if len(key) != 32 {
+		return nil, fmt.Errorf("key has to be 32 bytes long, is %d", len(key))
+	} else {
+		return key, nil
+	}
+}
+
+// parses octal file-permissions, returns the fallback if they're empty
+func parseOptionalFileMode(s string, fallback os.FileMode) (os.FileMode, error) {
+	if s == "" {
+		return fallback, nil
+	} else if mode, err := strconv.ParseUint(s, 8, 32); err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%q isn't an octal file-mode", s)
+	} else {
+		return os.FileMode(mode), nil
+	}
+}
+
+// parses a duration, returns the fallback if it is empty
+func parseOptionalDuration(s string, fallback time.Duration) (time.Duration, error) {
+	if s == "" {
+		return fallback, nil
+	} else {
+		return time.ParseDuration(s)
+	}
+}
+
+// parses a time of the config in the local timezone, returns the zero-time if it is empty
+func parseOptionalTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	} else {
+		return time.ParseInLocation(time.DateTime, s, time.Local)
+	}
+}
+
+// address the api is served on
+type ListenAddress struct {
+	// "tcp" or "unix"
+	Network string
+	Address string
+}
+
+func (addr ListenAddress) String() string {
+	if addr.Network == "unix" {
+		return "unix:" + addr.Address
+	} else {
+		return addr.Address
+	}
+}
+
+// parses a listen-address, "unix:<path>" or "host:port"
+func parseListenAddress(address string) (ListenAddress, error) {
+	if path, found := strings.CutPrefix(address, "unix:"); found {
+		if path == "" {
+			return ListenAddress{}, fmt.Errorf("unix-socket %q has no path", address)
+		}
+
+		return ListenAddress{Network: "unix", Address: path}, nil
+	} else if _, port, err := net.SplitHostPort(address); err != nil {
+		return ListenAddress{}, err
+	} else if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return ListenAddress{}, fmt.Errorf("invalid port in %q", address)
+	} else {
+		return ListenAddress{Network: "tcp", Address: address}, nil
+	}
+}
+
+// parses the listen-addresses, without any the port is served on all interfaces
+func parseListen(port int, addresses []string) ([]ListenAddress, error) {
+	if len(addresses) == 0 {
+		return []ListenAddress{{Network: "tcp", Address: fmt.Sprintf(":%d", port)}}, nil
+	}
+
+	return parseListenAddresses(addresses)
+}
+
+// parses a list of listen-addresses
+func parseListenAddresses(addresses []string) ([]ListenAddress, error) {
+	listen := make([]ListenAddress, len(addresses))
+
+	for ii, address := range addresses {
+		if addr, err := parseListenAddress(address); err != nil {
+			return nil, err
+		} else {
+			listen[ii] = addr
+		}
+	}
+
+	return listen, nil
+}
+
+// parses the expirations of the cache-namespaces
+func parseNamespaces(namespaces map[string]string) (map[string]time.Duration, error) {
+	expirations := make(map[string]time.Duration, len(namespaces))
+
+	for namespace, expiration := range namespaces {
+		if d, err := time.ParseDuration(expiration); err != nil {
+			return nil, fmt.Errorf("can't parse expiration of %q: %v", namespace, err)
+		} else {
+			expirations[namespace] = d
+		}
+	}
+
+	return expirations, nil
+}
+
+// parses the start and end of the embargoes
+func parseEmbargoes(embargoes []Embargo) ([]EmbargoWindow, error) {
+	windows := make([]EmbargoWindow, len(embargoes))
+
+	for ii, embargo := range embargoes {
+		windows[ii].Embargo = embargo
+
+		if start, err := parseOptionalTime(embargo.Start); err != nil {
+			return nil, fmt.Errorf("can't parse start of embargo %d: %v", ii, err)
+		} else if end, err := parseOptionalTime(embargo.End); err != nil {
+			return nil, fmt.Errorf("can't parse end of embargo %d: %v", ii, err)
+		} else {
+			windows[ii].Start = start
+			windows[ii].End = end
+		}
+	}
+
+	return windows, nil
+}
+
+// checks the custom fields for unique names and known types
+func validateCustomFields(fields []CustomField) error {
+	names := map[string]bool{}
+
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("field without name")
+		} else if names[field.Name] {
+			return fmt.Errorf("duplicate field %q", field.Name)
+		}
+
+		names[field.Name] = true
+
+		switch field.Type {
+		case "text", "bool", "number":
+		case "select":
+			if len(field.Options) == 0 {
+				return fmt.Errorf("select-field %q without options", field.Name)
+			}
+		default:
+			return fmt.Errorf("field %q has unknown type %q", field.Name, field.Type)
+		}
+	}
+
+	return nil
+}
+
+// reads the yaml-config-file without further parsing
+func LoadYaml(pth string) (ConfigYaml, error) {
+	config := ConfigYaml{}
+
+	yamlFile, err := os.ReadFile(pth)
+	if err != nil {
+		return config, fmt.Errorf("can't open config-file: %v", err)
+	}
+
+	reader := bytes.NewReader(yamlFile)
+
+	dec := yaml.NewDecoder(reader)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&config); err != nil {
+		return config, fmt.Errorf("can't parse config-file: %v", err)
+	}
+
+	// override the values from the environment
+	if err := applyEnvironment(&config); err != nil {
+		return config, fmt.Errorf("can't apply environment: %v", err)
+	}
+
+	return config, nil
+}
+
+// loads and parses the config-file
+func Load(pth string) (ConfigStruct, error) {
+	var configStruct ConfigStruct
+
+	config, err := LoadYaml(pth)
+	if err != nil {
+		return configStruct, err
+	} else if err := Validate(config); err != nil {
+		return configStruct, err
+	}
+
+	if logLevel, err := zerolog.ParseLevel(config.LogLevel); err != nil {
+		return configStruct, fmt.Errorf("can't parse log-level: %v", err)
+
+		// parse the durations
+	} else if logMaxAge, err := parseOptionalDuration(config.Log.MaxAge, 7*24*time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "log.max_age": %v`, err)
+	} else if session_expire, err := time.ParseDuration(config.ClientSession.Expire); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "client_session.expire": %v`, err)
+	} else if sessionIdleTimeout, err := parseOptionalDuration(config.ClientSession.IdleTimeout, 0); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "client_session.idle_timeout": %v`, err)
+	} else if mailDuplicateWindow, err := parseOptionalDuration(config.Mail.DuplicateWindow, 10*time.Minute); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "mail.duplicate_window": %v`, err)
+	} else if certificateLinkExpire, err := parseOptionalDuration(config.Certificates.LinkExpire, 168*time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "certificates.link_expire": %v`, err)
+	} else if cacheExpire, err := time.ParseDuration(config.Cache.Expiration); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "cache.expiration": %v`, err)
+	} else if cachePurge, err := time.ParseDuration(config.Cache.Purge); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "cache.purge": %v`, err)
+	} else if cacheResponses, err := parseOptionalDuration(config.Cache.Responses, 0); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "cache.responses": %v`, err)
+	} else if cacheNamespaces, err := parseNamespaces(config.Cache.Namespaces); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "cache.namespaces": %v`, err)
+	} else if consistencyCheck, err := parseOptionalDuration(config.Cache.ConsistencyCheck, 0); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "cache.consistency_check": %v`, err)
+	} else if reservationExpire, err := time.ParseDuration(config.Reservation.Expiration); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "reservation.expiration": %v`, err)
+	} else if limitWindow, err := parseOptionalDuration(config.Reservation.LimitWindow, reservationExpire); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "reservation.limit_window": %v`, err)
+	} else if throttleWindow, err := parseOptionalDuration(config.Reservation.ThrottleWindow, 720*time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "reservation.throttle_window": %v`, err)
+	} else if clientRetention, err := parseOptionalDuration(config.Reservation.ClientRetention, 0); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "reservation.client_retention": %v`, err)
+	} else if thankYouDelay, err := parseOptionalDuration(config.ThankYou.Delay, 4380*time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "thank_you.delay": %v`, err)
+	} else if thankYouInterval, err := parseOptionalDuration(config.ThankYou.Interval, time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "thank_you.interval": %v`, err)
+	} else if monitoringInterval, err := parseOptionalDuration(config.Monitoring.Interval, 15*time.Minute); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "monitoring.interval": %v`, err)
+	} else if exportInterval, err := parseOptionalDuration(config.Export.Interval, time.Minute); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "export.interval": %v`, err)
+	} else if err := validateCustomFields(config.CustomFields); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "custom_fields": %v`, err)
+	} else if deactivateAfter, err := parseOptionalDuration(config.Users.DeactivateAfter, 0); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "users.deactivate_after": %v`, err)
+	} else if resetExpire, err := parseOptionalDuration(config.Users.ResetExpire, time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "users.reset_expire": %v`, err)
+	} else if undoWindow, err := parseOptionalDuration(config.Merges.UndoWindow, 168*time.Hour); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "merges.undo_window": %v`, err)
+	} else if listen, err := parseListen(config.Server.Port, config.Server.Listen); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "server.listen": %v`, err)
+	} else if adminListen, err := parseListenAddresses(config.Server.AdminListen); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "server.admin_listen": %v`, err)
+	} else if socketMode, err := parseOptionalFileMode(config.Server.SocketMode, 0660); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "server.socket_mode": %v`, err)
+	} else if postalKey, err := parsePostalKey(config); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "postal.key": %v`, err)
+	} else if databaseKey, err := parseDatabaseKey(config); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "database.encryption_key": %v`, err)
+	} else if databaseRetryDelay, err := parseOptionalDuration(config.Database.RetryDelay, 50*time.Millisecond); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "database.retry_delay": %v`, err)
+	} else if embargoes, err := parseEmbargoes(config.Embargoes); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "embargoes": %v`, err)
+
+		// parse the regex
+	} else if mids, err := NewMidScheme(config.ValidateElements.Regex); err != nil {
+		return configStruct, fmt.Errorf(`error parsing "validate_elements.regex": %v`, err)
+	} else {
+		configStruct = ConfigStruct{
+			ConfigYaml:            config,
+			LogLevel:              logLevel,
+			LogFile:               logFile(config),
+			LogMaxAge:             logMaxAge,
+			SessionExpire:         session_expire,
+			SessionIdleTimeout:    sessionIdleTimeout,
+			MailDuplicateWindow:   mailDuplicateWindow,
+			DatabaseRetryDelay:    databaseRetryDelay,
+			CertificateLinkExpire: certificateLinkExpire,
+			Cache: CacheConfig{
+				Expiration:       cacheExpire,
+				Purge:            cachePurge,
+				Responses:        cacheResponses,
+				Namespaces:       cacheNamespaces,
+				ConsistencyCheck: consistencyCheck,
+			},
+			Reservation: ReservationConfig{
+				Expiration:      reservationExpire,
+				LimitWindow:     limitWindow,
+				ThrottleWindow:  throttleWindow,
+				ClientRetention: clientRetention,
+			},
+			ThankYou: ThankYouConfig{
+				Delay:    thankYouDelay,
+				Interval: thankYouInterval,
+			},
+			Monitoring: MonitoringConfig{
+				Interval: monitoringInterval,
+			},
+			Export: ExportConfig{
+				Interval: exportInterval,
+			},
+			Users: UsersConfig{
+				DeactivateAfter: deactivateAfter,
+				ResetExpire:     resetExpire,
+				PasswordHasher:  passwordHasher(config),
+			},
+			Merges: MergesConfig{
+				UndoWindow: undoWindow,
+			},
+			PostalKey:   postalKey,
+			DatabaseKey: databaseKey,
+			Listen:      listen,
+			AdminListen: adminListen,
+			SocketMode:  socketMode,
+			Embargoes:   embargoes,
+			Mids:        mids,
+		}
+
+		return configStruct, nil
+	}
+}
+
+// returns the configured hashing of the passwords, unset parameters use their defaults
+func passwordHasher(config ConfigYaml) lib.PasswordHasher {
+	hashing := config.Users.PasswordHashing
+
+	orDefault := func(value, fallback int) int {
+		if value > 0 {
+			return value
+		} else {
+			return fallback
+		}
+	}
+
+	if hashing.Algorithm == "argon2id" {
+		return lib.Argon2Hasher{
+			Memory:      uint32(orDefault(hashing.Argon2Memory, 64*1024)),
+			Iterations:  uint32(orDefault(hashing.Argon2Iterations, 3)),
+			Parallelism: uint8(orDefault(hashing.Argon2Parallelism, 2)),
+		}
+	} else {
+		return lib.BcryptHasher{Cost: orDefault(hashing.BcryptCost, 10)}
+	}
+}
+
+// returns the path of the logfile
+func logFile(config ConfigYaml) string {
+	if config.Log.Filename != "" {
+		return config.Log.Filename
+	} else {
+		return "logs/backend.log"
+	}
+}
+
+// returns the paths of the rotated logfiles, as named by lumberjack
+func (config ConfigStruct) LogBackups() ([]string, error) {
+	ext := filepath.Ext(config.LogFile)
+
+	return filepath.Glob(strings.TrimSuffix(config.LogFile, ext) + "-*" + ext + "*")
+}
+
+// creates the logger writing to the console and the logfile
+func NewLogger(config ConfigStruct) zerolog.Logger {
+	// try to set the log-level
+	zerolog.SetGlobalLevel(config.LogLevel)
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+
+	// create the console output
+	outputConsole := zerolog.ConsoleWriter{
+		Out:        os.Stdout,
+		TimeFormat: time.DateTime,
+		FormatLevel: func(i interface{}) string {
+			return strings.ToUpper(fmt.Sprintf("| %-6s|", i))
+		},
+		FormatFieldName: func(i interface{}) string {
+			return fmt.Sprintf("%s", i)
+		},
+		NoColor: true,
+	}
+
+	// create the logfile output, lumberjack counts the age in whole days
+	outputLog := &lumberjack.Logger{
+		Filename:   config.LogFile,
+		MaxSize:    config.ConfigYaml.Log.MaxSize,
+		MaxBackups: config.ConfigYaml.Log.MaxBackups,
+		MaxAge:     int(math.Ceil(config.LogMaxAge.Hours() / 24)),
+		Compress:   config.ConfigYaml.Log.Compress,
+		LocalTime:  true,
+	}
+
+	// create a multi-output-writer
+	multi := zerolog.MultiLevelWriter(
+		specificLevelWriter{
+			Writer: outputConsole,
+			Level:  config.LogLevel,
+		},
+		specificLevelWriter{
+			Writer: outputLog,
+			Level:  config.LogLevel,
+		},
+	)
+
+	// create a logger-instance
+	return zerolog.New(multi).With().Timestamp().Logger()
+}
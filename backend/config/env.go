@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// prefix of the environment-variables overriding the config-file
+const envPrefix = "PV"
+
+// directory docker mounts the secrets to
+const secretsDir = "/run/secrets"
+
+// overrides the scalar values of the config with environment-variables and secret-files.
+// The name of a value is the upper-cased path of its yaml-keys, prefixed by "PV"
+// (e.g. "PV_DATABASE_PASSWORD" for "database.password"). The precedence is, from lowest to highest:
+//   - the config-file
+//   - a docker-secret named like the lower-cased variable in "/run/secrets" (e.g. "pv_database_password")
+//   - the environment-variable itself
+//   - a file named by the environment-variable with the suffix "_FILE" (e.g. "PV_DATABASE_PASSWORD_FILE")
+func applyEnvironment(config *ConfigYaml) error {
+	return applyEnvironmentValue(reflect.ValueOf(config).Elem(), envPrefix)
+}
+
+func applyEnvironmentValue(v reflect.Value, name string) error {
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+
+		for ii := 0; ii < t.NumField(); ii++ {
+			key, _, _ := strings.Cut(t.Field(ii).Tag.Get("yaml"), ",")
+
+			if key == "" || key == "-" {
+				continue
+			}
+
+			if err := applyEnvironmentValue(v.Field(ii), name+"_"+strings.ToUpper(key)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if value, found, err := lookupEnvironment(name); err != nil {
+		return err
+	} else if !found {
+		return nil
+	} else {
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(value)
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("can't parse %q as bool: %v", name, err)
+			} else {
+				v.SetBool(b)
+			}
+		case reflect.Int, reflect.Int64:
+			if i, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return fmt.Errorf("can't parse %q as integer: %v", name, err)
+			} else {
+				v.SetInt(i)
+			}
+		case reflect.Float64:
+			if f, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("can't parse %q as number: %v", name, err)
+			} else {
+				v.SetFloat(f)
+			}
+		default:
+			return fmt.Errorf("%q can't be set from the environment", name)
+		}
+
+		return nil
+	}
+}
+
+// returns the value of a config-value from the environment, the secret-files take precedence
+func lookupEnvironment(name string) (string, bool, error) {
+	if pth, ok := os.LookupEnv(name + "_FILE"); ok {
+		if value, err := readSecret(pth); err != nil {
+			return "", false, fmt.Errorf("can't read secret-file %q of %q: %v", pth, name, err)
+		} else {
+			return value, true, nil
+		}
+	} else if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	} else if value, err := readSecret(filepath.Join(secretsDir, strings.ToLower(name))); os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("can't read docker-secret of %q: %v", name, err)
+	} else {
+		return value, true, nil
+	}
+}
+
+// reads a secret-file without the trailing newline
+func readSecret(pth string) (string, error) {
+	content, err := os.ReadFile(pth)
+
+	return strings.TrimRight(string(content), "\r\n"), err
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parts of a mid, as captured by the regex of "validate_elements"
+type MidParts struct {
+	// key of the elements in "validate_elements.valid_elements", e.g. "pv-c2-"
+	Descriptor string
+	// number of the element within its descriptor, as written in the mid (e.g. "17")
+	Number string
+	// prefix of the element-type in "element_types", e.g. "pv"
+	Type string
+	// identifier of the element shown on the certificates, e.g. "C2-17"
+	ID string
+}
+
+// scheme of the mids: a regex capturing the descriptor and the number of the elements,
+// either with the named groups "descriptor" and "number" or with the first two groups.
+// The optional named groups "type" and "id" capture the element-type and the identifier,
+// otherwise they're the parts before and after the first "-".
+// The number follows the descriptor, so a mid is "<descriptor><number>"
+type MidScheme struct {
+	regex *regexp.Regexp
+
+	descriptor, number, elementType, id int
+}
+
+// compiles the scheme of the mids from the regex of "validate_elements"
+func NewMidScheme(regex string) (MidScheme, error) {
+	compiled, err := regexp.Compile(regex)
+	if err != nil {
+		return MidScheme{}, err
+	}
+
+	scheme := MidScheme{
+		regex:       compiled,
+		descriptor:  compiled.SubexpIndex("descriptor"),
+		number:      compiled.SubexpIndex("number"),
+		elementType: compiled.SubexpIndex("type"),
+		id:          compiled.SubexpIndex("id"),
+	}
+
+	// without named groups, the first two groups capture the descriptor and the number
+	if scheme.descriptor < 0 && scheme.number < 0 {
+		scheme.descriptor, scheme.number = 1, 2
+	}
+
+	if scheme.descriptor < 0 || scheme.number < 0 {
+		return MidScheme{}, fmt.Errorf(`needs either both groups "descriptor" and "number" or none of them`)
+	} else if compiled.NumSubexp() < max(scheme.descriptor, scheme.number) {
+		return MidScheme{}, fmt.Errorf("needs two groups capturing the descriptor and the number")
+	}
+
+	return scheme, nil
+}
+
+// returns the regex of the scheme
+func (scheme MidScheme) String() string {
+	if scheme.regex == nil {
+		return ""
+	}
+
+	return scheme.regex.String()
+}
+
+// splits a mid (without a share) into its parts, false if it doesn't match the scheme
+func (scheme MidScheme) Parse(mid string) (MidParts, bool) {
+	if scheme.regex == nil {
+		return MidParts{}, false
+	}
+
+	results := scheme.regex.FindStringSubmatch(mid)
+	if results == nil {
+		return MidParts{}, false
+	}
+
+	parts := MidParts{
+		Descriptor: results[scheme.descriptor],
+		Number:     results[scheme.number],
+		Type:       TypePrefix(mid),
+		ID:         defaultID(mid),
+	}
+
+	if scheme.elementType >= 0 {
+		parts.Type = results[scheme.elementType]
+	}
+	if scheme.id >= 0 {
+		parts.ID = strings.ToUpper(results[scheme.id])
+	}
+
+	return parts, true
+}
+
+// returns the descriptor of a mid, empty if it doesn't match the scheme
+func (scheme MidScheme) Descriptor(mid string) string {
+	parts, _ := scheme.Parse(mid)
+
+	return parts.Descriptor
+}
+
+// returns the prefix of the element-type of a mid
+func (scheme MidScheme) Type(mid string) string {
+	if parts, ok := scheme.Parse(mid); ok {
+		return parts.Type
+	} else {
+		return TypePrefix(mid)
+	}
+}
+
+// returns the identifier of a mid shown on the certificates
+func (scheme MidScheme) ID(mid string) string {
+	if parts, ok := scheme.Parse(mid); ok {
+		return parts.ID
+	} else {
+		return defaultID(mid)
+	}
+}
+
+// creates the mid of the element with the number, padded with zeros to the digits
+func FormatMid(descriptor string, number, digits int) string {
+	return descriptor + fmt.Sprintf("%0*d", digits, number)
+}
+
+// wether the number of a mid is written as FormatMid does, so every element has a single mid
+func IsCanonicalNumber(number string, digits int) bool {
+	n, err := strconv.Atoi(number)
+
+	return err == nil && fmt.Sprintf("%0*d", digits, n) == number
+}
+
+// part of a mid before the first "-", the element-type of the default scheme
+func TypePrefix(mid string) string {
+	return strings.Split(mid, "-")[0]
+}
+
+// part of a mid after the first "-" in upper-case, the identifier of the default scheme
+func defaultID(mid string) string {
+	_, id, _ := strings.Cut(mid, "-")
+
+	return strings.ToUpper(id)
+}
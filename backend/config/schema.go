@@ -0,0 +1,300 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source of the config-structs, their comments document the options
+//
+//go:embed config.go
+var configSource string
+
+// option of the config-file
+type SchemaEntry struct {
+	// path of the yaml-keys, items of lists and maps are written as "[]" (e.g. "plants[].id")
+	Key string `json:"key"`
+	// one of "string", "integer", "number", "boolean", "list", "map" or "object"
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Doc     string `json:"doc"`
+}
+
+// returns the comments of the config-options by the path of their yaml-keys
+func schemaDocs() (map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	types := map[string]*ast.StructType{}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.TypeSpec); ok {
+			if structType, ok := spec.Type.(*ast.StructType); ok {
+				types[spec.Name.Name] = structType
+			}
+		}
+
+		return true
+	})
+
+	docs := map[string]string{}
+
+	var collect func(structType *ast.StructType, prefix string)
+	collect = func(structType *ast.StructType, prefix string) {
+		for _, field := range structType.Fields.List {
+			if field.Tag == nil || len(field.Names) == 0 {
+				continue
+			}
+
+			key, _, _ := strings.Cut(reflect.StructTag(strings.Trim(field.Tag.Value, "`")).Get("yaml"), ",")
+			if key == "" || key == "-" {
+				continue
+			}
+
+			key = prefix + key
+
+			if field.Doc != nil {
+				docs[key] = strings.TrimSpace(field.Doc.Text())
+			}
+
+			// follow the field into the items of lists and maps and into named structs
+			fieldType := field.Type
+			for {
+				if array, ok := fieldType.(*ast.ArrayType); ok {
+					fieldType, key = array.Elt, key+"[]"
+				} else if mapType, ok := fieldType.(*ast.MapType); ok {
+					fieldType, key = mapType.Value, key+"[]"
+				} else {
+					break
+				}
+			}
+
+			if nested, ok := fieldType.(*ast.StructType); ok {
+				collect(nested, key+".")
+			} else if ident, ok := fieldType.(*ast.Ident); ok && types[ident.Name] != nil {
+				collect(types[ident.Name], key+".")
+			}
+		}
+	}
+
+	if configYaml, ok := types["ConfigYaml"]; !ok {
+		return nil, fmt.Errorf("ConfigYaml not found in source")
+	} else {
+		collect(configYaml, "")
+	}
+
+	return docs, nil
+}
+
+// returns the name of the type of a config-option
+func schemaType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int64:
+		return "integer"
+	case reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "list"
+	case reflect.Map:
+		return "map"
+	default:
+		return "object"
+	}
+}
+
+// walks the options of a struct, calling fn for each of them with its path
+func walkSchema(t reflect.Type, prefix string, fn func(key string, field reflect.StructField)) {
+	for ii := 0; ii < t.NumField(); ii++ {
+		field := t.Field(ii)
+
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		key = prefix + key
+
+		fn(key, field)
+
+		elem := field.Type
+		for elem.Kind() == reflect.Slice || elem.Kind() == reflect.Map {
+			elem, key = elem.Elem(), key+"[]"
+		}
+
+		if elem.Kind() == reflect.Struct {
+			walkSchema(elem, key+".", fn)
+		}
+	}
+}
+
+// lists all options of the config-file with their types, defaults and documentation
+func Schema() ([]SchemaEntry, error) {
+	docs, err := schemaDocs()
+	if err != nil {
+		return nil, fmt.Errorf("can't read documentation of the config: %v", err)
+	}
+
+	schema := []SchemaEntry{}
+
+	walkSchema(reflect.TypeOf(ConfigYaml{}), "", func(key string, field reflect.StructField) {
+		schema = append(schema, SchemaEntry{
+			Key:     key,
+			Type:    schemaType(field.Type),
+			Default: field.Tag.Get("default"),
+			Doc:     docs[key],
+		})
+	})
+
+	return schema, nil
+}
+
+// formats a scalar as yaml-value
+func exampleValue(t reflect.Type, value string) (string, error) {
+	var v any
+
+	switch t.Kind() {
+	case reflect.String:
+		v = value
+	case reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool:
+		if value == "" {
+			v = reflect.Zero(t).Interface()
+		} else {
+			// numbers and booleans are written as they are
+			return value, nil
+		}
+	default:
+		return "", fmt.Errorf("no scalar type %s", t)
+	}
+
+	result, err := yaml.Marshal(v)
+
+	return strings.TrimSuffix(string(result), "\n"), err
+}
+
+// formats the zero-value of a scalar or of the items of nested lists and maps in flow-style
+func exampleScalar(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.Slice:
+		value, err := exampleScalar(t.Elem())
+
+		return "[" + value + "]", err
+	case reflect.Map:
+		value, err := exampleScalar(t.Elem())
+
+		return "{<" + schemaType(t.Key()) + ">: " + value + "}", err
+	default:
+		return exampleValue(t, "")
+	}
+}
+
+// writes the options of a struct as commented yaml, the lines of list- and map-items are commented out
+func writeExample(builder *strings.Builder, t reflect.Type, prefix, indent string, docs map[string]string) error {
+	for ii := 0; ii < t.NumField(); ii++ {
+		field := t.Field(ii)
+
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		path := prefix + key
+
+		if doc := docs[path]; doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				fmt.Fprintf(builder, "%s# %s\n", indent, line)
+			}
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			fmt.Fprintf(builder, "%s%s:\n", indent, key)
+
+			if err := writeExample(builder, field.Type, path+".", indent+"  ", docs); err != nil {
+				return err
+			}
+		case reflect.Slice, reflect.Map:
+			empty := "[]"
+			if field.Type.Kind() == reflect.Map {
+				empty = "{}"
+			}
+
+			fmt.Fprintf(builder, "%s%s: %s\n", indent, key, empty)
+
+			// an example of the items, commented out
+			elem := field.Type.Elem()
+
+			var item strings.Builder
+
+			if elem.Kind() == reflect.Struct {
+				if err := writeExample(&item, elem, path+"[].", "", docs); err != nil {
+					return err
+				}
+			} else if value, err := exampleScalar(elem); err != nil {
+				return fmt.Errorf("%q: %v", path, err)
+			} else {
+				item.WriteString(value + "\n")
+			}
+
+			// list-items start with a dash on their first option, map-items with their key
+			dashed := false
+			if field.Type.Kind() == reflect.Map {
+				if elem.Kind() != reflect.Struct {
+					fmt.Fprintf(builder, "%s#   <%s>: %s", indent, schemaType(field.Type.Key()), item.String())
+
+					continue
+				}
+
+				fmt.Fprintf(builder, "%s#   <%s>:\n", indent, schemaType(field.Type.Key()))
+
+				dashed = true
+			}
+
+			for _, line := range strings.Split(strings.TrimSuffix(item.String(), "\n"), "\n") {
+				if !dashed && !strings.HasPrefix(line, "#") {
+					fmt.Fprintf(builder, "%s#   - %s\n", indent, line)
+
+					dashed = true
+				} else {
+					fmt.Fprintf(builder, "%s#     %s\n", indent, line)
+				}
+			}
+		default:
+			if value, err := exampleValue(field.Type, field.Tag.Get("default")); err != nil {
+				return fmt.Errorf("%q: %v", path, err)
+			} else {
+				fmt.Fprintf(builder, "%s%s: %s\n", indent, key, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// creates a commented example of the config-file with the defaults of the options
+func Example() (string, error) {
+	docs, err := schemaDocs()
+	if err != nil {
+		return "", fmt.Errorf("can't read documentation of the config: %v", err)
+	}
+
+	var builder strings.Builder
+
+	if err := writeExample(&builder, reflect.TypeOf(ConfigYaml{}), "", "", docs); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
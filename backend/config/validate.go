@@ -0,0 +1,436 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// timeout of the connection-checks in strict mode
+const checkTimeout = 5 * time.Second
+
+// hex-codes of the colors of the branding
+var colorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// all problems found in the config
+type ValidationError []error
+
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e))
+
+	for ii, err := range e {
+		lines[ii] = "  - " + err.Error()
+	}
+
+	return fmt.Sprintf("%d problems in the config:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// collects the problems of a config instead of stopping at the first one
+type validator struct {
+	problems ValidationError
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Errorf(format, args...))
+}
+
+// checks that a value is set
+func (v *validator) required(key, value string) {
+	if value == "" {
+		v.add("%q is required", key)
+	}
+}
+
+// checks that a duration can be parsed and lies in the range. Empty durations are only
+// accepted if they're optional
+func (v *validator) duration(key, value string, optional bool, min, max time.Duration) {
+	if value == "" {
+		if !optional {
+			v.add("%q is required", key)
+		}
+	} else if d, err := time.ParseDuration(value); err != nil {
+		v.add("%q is no valid duration (e.g. \"30m\" or \"48h\"): %v", key, err)
+	} else if d < min || (max > 0 && d > max) {
+		if max > 0 {
+			v.add("%q has to be between %v and %v, is %v", key, min, max, d)
+		} else {
+			v.add("%q has to be at least %v, is %v", key, min, d)
+		}
+	}
+}
+
+// checks that a port is in the valid range
+func (v *validator) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		v.add("%q has to be between 1 and 65535, is %d", key, port)
+	}
+}
+
+// checks the config for missing keys, unparsable values and insane ranges and
+// returns all problems found
+func Validate(config ConfigYaml) error {
+	v := validator{}
+
+	if _, err := zerolog.ParseLevel(config.LogLevel); err != nil {
+		v.add("%q is no valid log-level (e.g. \"INFO\"): %v", "log_level", err)
+	}
+
+	if config.Log.MaxSize < 0 {
+		v.add("%q can't be negative", "log.max_size")
+	}
+	if config.Log.MaxBackups < 0 {
+		v.add("%q can't be negative", "log.max_backups")
+	}
+	v.duration("log.max_age", config.Log.MaxAge, true, 0, 0)
+
+	if _, ok := lib.Locales[config.Locale]; !ok && config.Locale != "" {
+		v.add("%q has to be one of %q, is %q", "locale", slices.Sorted(maps.Keys(lib.Locales)), config.Locale)
+	}
+
+	v.required("database.host", config.Database.Host)
+	v.required("database.user", config.Database.User)
+	v.required("database.database", config.Database.Database)
+	if _, err := parseDatabaseKey(config); err != nil {
+		v.add("%q is invalid: %v", "database.encryption_key", err)
+	}
+	if config.Database.Retries < 0 {
+		v.add("%q can't be negative", "database.retries")
+	}
+	v.duration("database.retry_delay", config.Database.RetryDelay, true, time.Millisecond, 10*time.Second)
+
+	v.duration("cache.expiration", config.Cache.Expiration, false, time.Second, 0)
+	v.duration("cache.purge", config.Cache.Purge, false, time.Second, 0)
+	v.duration("cache.responses", config.Cache.Responses, true, 0, time.Hour)
+	v.duration("cache.consistency_check", config.Cache.ConsistencyCheck, true, time.Minute, 0)
+	for namespace, expiration := range config.Cache.Namespaces {
+		if !slices.Contains(CacheNamespaces, namespace) {
+			v.add("%q has unknown namespace %q, has to be one of %q", "cache.namespaces", namespace, CacheNamespaces)
+		} else {
+			v.duration("cache.namespaces."+namespace, expiration, false, time.Second, 0)
+		}
+	}
+
+	v.required("client_session.jwt_signature", config.ClientSession.JwtSignature)
+	v.duration("client_session.expire", config.ClientSession.Expire, false, time.Minute, 0)
+	v.duration("client_session.idle_timeout", config.ClientSession.IdleTimeout, true, time.Minute, 0)
+
+	if len(config.Server.Listen) == 0 {
+		v.port("server.port", config.Server.Port)
+	}
+	for _, address := range config.Server.Listen {
+		if _, err := parseListenAddress(address); err != nil {
+			v.add("%q has invalid address %q: %v", "server.listen", address, err)
+		}
+	}
+	for _, address := range config.Server.AdminListen {
+		if _, err := parseListenAddress(address); err != nil {
+			v.add("%q has invalid address %q: %v", "server.admin_listen", address, err)
+		} else if slices.Contains(config.Server.Listen, address) || (len(config.Server.Listen) == 0 && address == fmt.Sprintf(":%d", config.Server.Port)) {
+			v.add("%q has address %q, which is also in %q", "server.admin_listen", address, "server.listen")
+		}
+	}
+	for _, proxy := range config.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			v.add("%q has invalid address %q", "server.trusted_proxies", proxy)
+		}
+	}
+	if _, err := parseOptionalFileMode(config.Server.SocketMode, 0); err != nil {
+		v.add("%q is invalid: %v", "server.socket_mode", err)
+	}
+
+	v.duration("reservation.expiration", config.Reservation.Expiration, false, time.Minute, 0)
+	v.duration("reservation.limit_window", config.Reservation.LimitWindow, true, time.Minute, 0)
+	if config.Reservation.MaxPerMail < 0 {
+		v.add("%q can't be negative", "reservation.max_per_mail")
+	}
+	if config.Reservation.ThrottleExpiries < 0 {
+		v.add("%q can't be negative", "reservation.throttle_expiries")
+	}
+	v.duration("reservation.throttle_window", config.Reservation.ThrottleWindow, true, time.Hour, 0)
+	v.duration("reservation.client_retention", config.Reservation.ClientRetention, true, time.Hour, 0)
+
+	v.required("mail.server", config.Mail.Server)
+	v.port("mail.port", config.Mail.Port)
+	v.duration("mail.duplicate_window", config.Mail.DuplicateWindow, true, 0, 24*time.Hour)
+	if config.Mail.MaxPerHour < 0 {
+		v.add("%q can't be negative", "mail.max_per_hour")
+	}
+	switch strings.ToLower(config.Mail.Encryption) {
+	case "", "ssl/tls", "starttls", "none":
+	default:
+		v.add("%q has to be one of \"ssl/tls\", \"starttls\" or \"none\", is %q", "mail.encryption", config.Mail.Encryption)
+	}
+
+	v.duration("thank_you.delay", config.ThankYou.Delay, true, 0, 0)
+	v.duration("thank_you.interval", config.ThankYou.Interval, true, time.Minute, 0)
+	v.duration("monitoring.interval", config.Monitoring.Interval, true, time.Minute, 0)
+	v.duration("export.interval", config.Export.Interval, true, time.Second, 0)
+	v.duration("users.deactivate_after", config.Users.DeactivateAfter, true, 24*time.Hour, 0)
+	v.duration("users.reset_expire", config.Users.ResetExpire, true, 5*time.Minute, 24*time.Hour)
+
+	for _, kind := range config.Users.MailNotifications {
+		if !slices.Contains(NotificationEvents, kind) || kind == "database-down" {
+			v.add("%q has unknown kind %q", "users.mail_notifications", kind)
+		}
+	}
+	switch hashing := config.Users.PasswordHashing; hashing.Algorithm {
+	case "", "bcrypt":
+		if hashing.BcryptCost != 0 && (hashing.BcryptCost < bcrypt.MinCost || hashing.BcryptCost > bcrypt.MaxCost) {
+			v.add("%q has to be between %d and %d, is %d", "users.password_hashing.bcrypt_cost", bcrypt.MinCost, bcrypt.MaxCost, hashing.BcryptCost)
+		}
+	case "argon2id":
+		if hashing.Argon2Memory < 0 || hashing.Argon2Iterations < 0 || hashing.Argon2Parallelism < 0 || hashing.Argon2Parallelism > 255 {
+			v.add("%q has invalid argon2id-parameters", "users.password_hashing")
+		}
+	default:
+		v.add("%q has to be \"bcrypt\" or \"argon2id\", is %q", "users.password_hashing.algorithm", hashing.Algorithm)
+	}
+	v.duration("merges.undo_window", config.Merges.UndoWindow, true, 0, 0)
+
+	switch config.Certificates.Renderer {
+	case "", "inkscape", "fpdf":
+	default:
+		v.add("%q has to be \"inkscape\" or \"fpdf\", is %q", "certificates.renderer", config.Certificates.Renderer)
+	}
+	v.duration("certificates.link_expire", config.Certificates.LinkExpire, true, time.Minute, 0)
+	if config.Certificates.MaxConcurrent < 0 {
+		v.add("%q can't be negative", "certificates.max_concurrent")
+	}
+	if config.Certificates.QueueDepth < 0 {
+		v.add("%q can't be negative", "certificates.queue_depth")
+	}
+
+	if config.Campaigns.MailsPerMinute < 0 {
+		v.add("%q can't be negative", "campaigns.mails_per_minute")
+	}
+
+	if err := validateCustomFields(config.CustomFields); err != nil {
+		v.add("%q: %v", "custom_fields", err)
+	}
+	for _, field := range config.Certificates.Fields {
+		if field == "name" || field == "dedication" {
+			continue
+		} else if ii := slices.IndexFunc(config.CustomFields, func(custom CustomField) bool { return custom.Name == field }); ii < 0 {
+			v.add("%q has unknown field %q", "certificates.fields", field)
+		} else if config.CustomFields[ii].Type != "text" {
+			v.add("%q has field %q, which isn't a text-field", "certificates.fields", field)
+		}
+	}
+	if config.Certificates.Dedication.MaxLength < 0 {
+		v.add("%q can't be negative", "certificates.dedication.max_length")
+	}
+
+	if config.Datev.Account != "" {
+		v.required("datev.contra_account", config.Datev.ContraAccount)
+
+		if config.Datev.AccountLength < 4 || config.Datev.AccountLength > 8 {
+			v.add("%q has to be between 4 and 8, is %d", "datev.account_length", config.Datev.AccountLength)
+		}
+	}
+
+	if config.Postal.Enabled {
+		v.required("postal.key", config.Postal.Key)
+		if _, err := parsePostalKey(config); err != nil && config.Postal.Key != "" {
+			v.add("%q is invalid: %v", "postal.key", err)
+		}
+	}
+
+	if config.PublicNames != "" && !slices.Contains(PublicNames, config.PublicNames) {
+		v.add("%q has to be one of %q, is %q", "public_names", PublicNames, config.PublicNames)
+	}
+
+	if config.Feed.Enabled {
+		if !slices.Contains(FeedNames, config.Feed.Names) {
+			v.add("%q has to be one of %q, is %q", "feed.names", FeedNames, config.Feed.Names)
+		}
+		if config.Feed.Entries <= 0 {
+			v.add("%q has to be at least 1, is %d", "feed.entries", config.Feed.Entries)
+		}
+	}
+
+	for _, address := range []struct{ key, url string }{
+		{"short_links.frontend_url", config.ShortLinks.FrontendURL},
+		{"short_links.base_url", config.ShortLinks.BaseURL},
+	} {
+		if address.url == "" {
+			continue
+		} else if parsed, err := url.Parse(address.url); err != nil || !parsed.IsAbs() || parsed.Host == "" {
+			v.add("%q has to be an absolute url, is %q", address.key, address.url)
+		}
+	}
+
+	for role, color := range config.Branding.Colors {
+		if !colorRegex.MatchString(color) {
+			v.add("%q: color %q has to be a hex-code (e.g. \"#0a7f3f\"), is %q", "branding.colors", role, color)
+		}
+	}
+
+	for ii, link := range config.Branding.Links {
+		if link.Label == "" || link.URL == "" {
+			v.add("%q: link %d needs a label and an url", "branding.links", ii)
+		}
+	}
+
+	for ii, document := range config.Legal {
+		if document.Kind == "" || document.Version == "" {
+			v.add("%q: document %d needs a kind and a version", "legal", ii)
+		}
+	}
+
+	for ii, channel := range config.NotificationChannels {
+		switch channel.Type {
+		case "telegram", "matrix":
+			if channel.Token == "" {
+				v.add("%q: channel %d of type %q needs a token", "notification_channels", ii, channel.Type)
+			}
+			if channel.Type == "matrix" && channel.URL == "" {
+				v.add("%q: channel %d needs the url of the homeserver", "notification_channels", ii)
+			}
+		case "ntfy":
+		default:
+			v.add("%q: channel %d has to be of type \"telegram\", \"matrix\" or \"ntfy\", is %q", "notification_channels", ii, channel.Type)
+		}
+
+		if channel.Target == "" {
+			v.add("%q: channel %d needs a target", "notification_channels", ii)
+		}
+
+		for _, event := range channel.Events {
+			if !slices.Contains(NotificationEvents, event) {
+				v.add("%q: channel %d has unknown event %q", "notification_channels", ii, event)
+			}
+		}
+	}
+
+	for feature := range config.Features {
+		if !slices.Contains(Features, feature) {
+			v.add("%q has unknown feature %q", "features", feature)
+		}
+	}
+
+	// the regex has to capture the descriptor and the number of the element
+	if _, err := NewMidScheme(config.ValidateElements.Regex); err != nil {
+		v.add("%q is invalid: %v", "validate_elements.regex", err)
+	}
+
+	for descriptor, rng := range config.ValidateElements.ValidElements {
+		if rng.From > rng.To {
+			v.add("%q: range of %q is empty (%d to %d)", "validate_elements.valid_elements", descriptor, rng.From, rng.To)
+		}
+		if rng.Digits < 0 || rng.Digits > 9 {
+			v.add("%q: digits of %q have to be between 0 and 9", "validate_elements.valid_elements", descriptor)
+		}
+		if rng.Shares < 0 || rng.Shares == 1 {
+			v.add("%q: elements %q need either no or at least two shares", "validate_elements.valid_elements", descriptor)
+		}
+	}
+
+	for ii, embargo := range config.Embargoes {
+		if rng, ok := config.ValidateElements.ValidElements[embargo.Elements]; !ok {
+			v.add("%q: elements %q of embargo %d aren't in \"validate_elements.valid_elements\"", "embargoes", embargo.Elements, ii)
+		} else if embargo.From != 0 || embargo.To != 0 {
+			if embargo.From > embargo.To || embargo.From < rng.From || embargo.To > rng.To {
+				v.add("%q: range of embargo %d (%d to %d) has to be within %d to %d", "embargoes", ii, embargo.From, embargo.To, rng.From, rng.To)
+			}
+		}
+
+		start, errStart := parseOptionalTime(embargo.Start)
+		end, errEnd := parseOptionalTime(embargo.End)
+
+		if errStart != nil {
+			v.add("%q: start of embargo %d is no valid time (\"YYYY-MM-DD HH:MM:SS\"): %v", "embargoes", ii, errStart)
+		}
+		if errEnd != nil {
+			v.add("%q: end of embargo %d is no valid time (\"YYYY-MM-DD HH:MM:SS\"): %v", "embargoes", ii, errEnd)
+		}
+		if errStart == nil && errEnd == nil && !start.IsZero() && !end.IsZero() && !start.Before(end) {
+			v.add("%q: embargo %d ends before it starts", "embargoes", ii)
+		}
+	}
+
+	for prefix, elementType := range config.ElementTypes {
+		if elementType.Name == "" {
+			v.add("%q: type %q needs a name", "element_types", prefix)
+		}
+	}
+
+	// every element-descriptor may only belong to a single plant
+	plantIDs := map[string]bool{}
+	descriptors := map[string]string{}
+
+	for ii, plant := range config.Plants {
+		if plant.ID == "" {
+			v.add("%q: plant %d needs an id", "plants", ii)
+		} else if plantIDs[plant.ID] {
+			v.add("%q: duplicate plant %q", "plants", plant.ID)
+		}
+		plantIDs[plant.ID] = true
+
+		for _, descriptor := range plant.Elements {
+			if _, ok := config.ValidateElements.ValidElements[descriptor]; !ok {
+				v.add("%q: elements %q of plant %q aren't in \"validate_elements.valid_elements\"", "plants", descriptor, plant.ID)
+			} else if other, ok := descriptors[descriptor]; ok {
+				v.add("%q: elements %q belong to the plants %q and %q", "plants", descriptor, other, plant.ID)
+			}
+			descriptors[descriptor] = plant.ID
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	} else {
+		return nil
+	}
+}
+
+// checks the availability of the port and the reachability of the database and the
+// mail-server. It's only run in strict mode, as the services might start after the backend
+func CheckConnections(config ConfigStruct) error {
+	v := validator{}
+
+	for _, addr := range slices.Concat(config.Listen, config.AdminListen) {
+		if addr.Network == "unix" {
+			// the socket itself is replaced on the start, only its directory has to exist
+			if dir, err := os.Stat(filepath.Dir(addr.Address)); err != nil || !dir.IsDir() {
+				v.add("directory of the socket %q doesn't exist", addr.Address)
+			}
+		} else if listener, err := net.Listen(addr.Network, addr.Address); err != nil {
+			v.add("address %q isn't available: %v", addr.Address, err)
+		} else {
+			listener.Close()
+		}
+	}
+
+	hosts := [][2]string{
+		{"database", config.Database.Host},
+		{"mail-server", net.JoinHostPort(config.Mail.Server, fmt.Sprint(config.Mail.Port))},
+	}
+	if config.Database.Replica.Host != "" {
+		hosts = append(hosts, [2]string{"database-replica", config.Database.Replica.Host})
+	}
+
+	for _, host := range hosts {
+		if conn, err := net.DialTimeout("tcp", host[1], checkTimeout); err != nil {
+			v.add("%s at %q isn't reachable: %v", host[0], host[1], err)
+		} else {
+			conn.Close()
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return v.problems
+	} else {
+		return nil
+	}
+}
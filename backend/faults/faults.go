@@ -0,0 +1,21 @@
+// Package faults injects failures (database down, slow mail-server, poisoned cache) for
+// rehearsing the incident-response on staging. The injection is only compiled into
+// builds with the "debug" build-tag, otherwise all faults are permanently inactive.
+package faults
+
+import "errors"
+
+// error returned by the database while its failure is injected
+var ErrDatabaseDown = errors.New("database-failure injected")
+
+// injected faults
+type State struct {
+	// the database fails every query
+	DatabaseDown bool
+	// delay before connecting to the mail-server
+	MailDelay string
+	// the cache returns empty values instead of the cached ones
+	CachePoisoned bool
+	// time the faults are reset automatically, empty if they don't expire
+	Expires string
+}
@@ -0,0 +1,88 @@
+//go:build debug
+
+package faults
+
+import (
+	"sync"
+	"time"
+)
+
+// wether the fault-injection is compiled in
+const Enabled = true
+
+var current = struct {
+	sync.RWMutex
+	databaseDown  bool
+	mailDelay     time.Duration
+	cachePoisoned bool
+	expires       time.Time
+}{}
+
+// resets the faults, if they are expired. Has to be called with the lock held
+func expire() {
+	if !current.expires.IsZero() && time.Now().After(current.expires) {
+		current.databaseDown = false
+		current.mailDelay = 0
+		current.cachePoisoned = false
+		current.expires = time.Time{}
+	}
+}
+
+// injects the faults, they are reset after the duration unless it is zero
+func Set(databaseDown bool, mailDelay time.Duration, cachePoisoned bool, duration time.Duration) {
+	current.Lock()
+	defer current.Unlock()
+
+	current.databaseDown = databaseDown
+	current.mailDelay = mailDelay
+	current.cachePoisoned = cachePoisoned
+	current.expires = time.Time{}
+
+	if duration > 0 {
+		current.expires = time.Now().Add(duration)
+	}
+}
+
+// returns the injected faults
+func Get() State {
+	current.Lock()
+	defer current.Unlock()
+
+	expire()
+
+	state := State{
+		DatabaseDown:  current.databaseDown,
+		MailDelay:     current.mailDelay.String(),
+		CachePoisoned: current.cachePoisoned,
+	}
+
+	if !current.expires.IsZero() {
+		state.Expires = current.expires.Format(time.DateTime)
+	}
+
+	return state
+}
+
+// returns ErrDatabaseDown while the failure of the database is injected
+func Database() error {
+	if Get().DatabaseDown {
+		return ErrDatabaseDown
+	} else {
+		return nil
+	}
+}
+
+// waits for the injected delay of the mail-server
+func DelayMail() {
+	current.Lock()
+	expire()
+	delay := current.mailDelay
+	current.Unlock()
+
+	time.Sleep(delay)
+}
+
+// wether the cache is poisoned
+func CachePoisoned() bool {
+	return Get().CachePoisoned
+}
@@ -0,0 +1,25 @@
+//go:build !debug
+
+package faults
+
+import "time"
+
+// wether the fault-injection is compiled in
+const Enabled = false
+
+// without the "debug" build-tag no faults can be injected
+func Set(databaseDown bool, mailDelay time.Duration, cachePoisoned bool, duration time.Duration) {}
+
+func Get() State {
+	return State{MailDelay: "0s"}
+}
+
+func Database() error {
+	return nil
+}
+
+func DelayMail() {}
+
+func CachePoisoned() bool {
+	return false
+}
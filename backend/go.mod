@@ -3,8 +3,11 @@ module github.com/johannesbuehl/johannes-pv/backend
 go 1.23.1
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gofiber/fiber/v2 v2.52.5
 	github.com/patrickmn/go-cache v2.1.0+incompatible
+	github.com/yuin/goldmark v1.7.8
+	golang.org/x/sync v0.8.0
 )
 
 require (
@@ -28,7 +31,7 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/rs/zerolog v1.33.0
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	github.com/valyala/fasthttp v1.51.0 // indirect
+	github.com/valyala/fasthttp v1.51.0
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	github.com/xhit/go-simple-mail/v2 v2.16.0
 	golang.org/x/crypto v0.28.0
@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// encrypts data with AES-GCM, the random nonce is prepended to the result
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	if block, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	} else if gcm, err := cipher.NewGCM(block); err != nil {
+		return nil, err
+	} else {
+		nonce := make([]byte, gcm.NonceSize())
+
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	}
+}
+
+// decrypts data encrypted by Encrypt
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	if block, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	} else if gcm, err := cipher.NewGCM(block); err != nil {
+		return nil, err
+	} else if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	} else {
+		return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	}
+}
+
+// derives the key of a single purpose from a master-key, so the master-key isn't used by
+// different algorithms
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+// encrypts data with AES-GCM and a nonce derived from the plaintext, so equal plaintexts
+// result in equal ciphertexts and the values can still be compared in the database.
+// The keys of the cipher and the nonce are derived separately from the key
+func EncryptDeterministic(key, plaintext []byte) ([]byte, error) {
+	if block, err := aes.NewCipher(deriveKey(key, "aes-gcm")); err != nil {
+		return nil, err
+	} else if gcm, err := cipher.NewGCM(block); err != nil {
+		return nil, err
+	} else {
+		mac := hmac.New(sha256.New, deriveKey(key, "nonce"))
+		mac.Write(plaintext)
+
+		nonce := mac.Sum(nil)[:gcm.NonceSize()]
+
+		return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	}
+}
+
+// decrypts data encrypted by EncryptDeterministic
+func DecryptDeterministic(key, ciphertext []byte) ([]byte, error) {
+	return Decrypt(deriveKey(key, "aes-gcm"), ciphertext)
+}
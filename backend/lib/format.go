@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// conventions for formatting dates and numbers of a language
+type Locale struct {
+	Months []string
+	// layout of the dates with "January" as placeholder of the month-name
+	DateLayout string
+	Decimal    string
+	Thousands  string
+	// layout of amounts with "%s" as placeholder of the number
+	CurrencyLayout string
+}
+
+// supported locales by their name in the config
+var Locales = map[string]Locale{
+	"de": {
+		Months:         []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		DateLayout:     "2. January 2006",
+		Decimal:        ",",
+		Thousands:      ".",
+		CurrencyLayout: "%s €",
+	},
+	"en": {
+		Months:         []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		DateLayout:     "January 2, 2006",
+		Decimal:        ".",
+		Thousands:      ",",
+		CurrencyLayout: "€%s",
+	},
+}
+
+// locale used by the formatting-functions
+var locale = Locales["de"]
+
+// sets the locale used by the formatting-functions
+func SetLocale(name string) error {
+	if name == "" {
+		name = "de"
+	}
+
+	if l, ok := Locales[name]; !ok {
+		return fmt.Errorf("unknown locale %q", name)
+	} else {
+		locale = l
+
+		return nil
+	}
+}
+
+// formats a date with the month-name of the locale. Besides times, strings
+// in the database-format ("YYYY-MM-DD HH:MM:SS" or "YYYY-MM-DD") are accepted
+func FormatDate(date any) (string, error) {
+	var t time.Time
+
+	switch d := date.(type) {
+	case time.Time:
+		t = d
+	case *time.Time:
+		t = *d
+	case string:
+		if parsed, err := time.ParseInLocation(time.DateTime, d, time.Local); err == nil {
+			t = parsed
+		} else if parsed, err := time.ParseInLocation(time.DateOnly, d, time.Local); err == nil {
+			t = parsed
+		} else {
+			return "", fmt.Errorf("can't parse date %q", d)
+		}
+	case *string:
+		if d == nil {
+			return "", nil
+		}
+
+		return FormatDate(*d)
+	default:
+		return "", fmt.Errorf("can't format %T as date", date)
+	}
+
+	// the month-name is inserted afterwards, as the layout only knows english names
+	return strings.Replace(t.Format(strings.Replace(locale.DateLayout, "January", "\x00", 1)), "\x00", locale.Months[t.Month()-1], 1), nil
+}
+
+// formats a number with the separators of the locale and a fixed number of decimals
+func FormatNumber(number float64, decimals int) string {
+	sign := ""
+	if number < 0 {
+		sign = "-"
+		number = -number
+	}
+
+	factor := math.Pow10(decimals)
+	scaled := int64(math.Round(number * factor))
+
+	integer := fmt.Sprint(scaled / int64(factor))
+
+	// group the thousands
+	for ii := len(integer) - 3; ii > 0; ii -= 3 {
+		integer = integer[:ii] + locale.Thousands + integer[ii:]
+	}
+
+	if decimals > 0 {
+		return fmt.Sprintf("%s%s%s%0*d", sign, integer, locale.Decimal, decimals, scaled%int64(factor))
+	} else {
+		return sign + integer
+	}
+}
+
+// formats an amount in euros, e.g. "1.234,50 €"
+func FormatCurrency(amount float64) string {
+	return fmt.Sprintf(locale.CurrencyLayout, FormatNumber(amount, 2))
+}
+
+// functions available in all templates
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"formatDate":     FormatDate,
+		"formatCurrency": FormatCurrency,
+		"formatNumber":   FormatNumber,
+	}
+}
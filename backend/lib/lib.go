@@ -0,0 +1,93 @@
+// Package lib contains small helpers shared between the packages of the backend.
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	templateHTML "html/template"
+	"os"
+	"reflect"
+	"text/template"
+)
+
+func StrucToMap(data any) (map[string]any, error) {
+	result := make(map[string]any)
+
+	v := reflect.ValueOf(data)
+
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct but got %T", data)
+	}
+
+	for ii := 0; ii < v.NumField(); ii++ {
+		field := v.Type().Field(ii)
+		value := v.Field(ii)
+
+		// skip unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		result[field.Tag.Get("json")] = value.Interface()
+	}
+
+	return result, nil
+}
+
+func LoadTemplate(pth string) (*template.Template, error) {
+	if buf, err := os.ReadFile(pth); err != nil {
+		return nil, err
+	} else {
+		return template.New(pth).Funcs(TemplateFuncs()).Parse(string(buf))
+	}
+}
+
+func ParseTemplate(pth string, vals any) (string, error) {
+	if tpl, err := LoadTemplate(pth); err != nil {
+		return "", err
+	} else {
+		var buf bytes.Buffer
+
+		err = tpl.Execute(&buf, vals)
+
+		return buf.String(), err
+	}
+}
+
+func LoadHTMLTemplate(pth string) (*templateHTML.Template, error) {
+	if buf, err := os.ReadFile(pth); err != nil {
+		return nil, err
+	} else {
+		return templateHTML.New(pth).Funcs(templateHTML.FuncMap(TemplateFuncs())).Parse(string(buf))
+	}
+}
+
+func ParseHTMLTemplate(pth string, vals any) (string, error) {
+	if tpl, err := LoadHTMLTemplate(pth); err != nil {
+		return "", err
+	} else {
+		var buf bytes.Buffer
+
+		err = tpl.Execute(&buf, vals)
+
+		return buf.String(), err
+	}
+}
+
+// executes a template given as string
+func ExecuteTemplate(text string, vals any) (string, error) {
+	if tpl, err := template.New("").Funcs(TemplateFuncs()).Parse(text); err != nil {
+		return "", err
+	} else {
+		var buf bytes.Buffer
+
+		err = tpl.Execute(&buf, vals)
+
+		return buf.String(), err
+	}
+}
+
+// returns a pointer to the value
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// algorithm hashing the passwords of the users
+type PasswordHasher interface {
+	Hash(password []byte) ([]byte, error)
+	// compares a hash of the algorithm with a password, nil if they match
+	Compare(hash, password []byte) error
+	// wether the hash was created by the algorithm with the current parameters
+	Current(hash []byte) bool
+}
+
+// hashes the passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, h.Cost)
+}
+
+func (h BcryptHasher) Compare(hash, password []byte) error {
+	return bcrypt.CompareHashAndPassword(hash, password)
+}
+
+func (h BcryptHasher) Current(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+
+	return err == nil && cost == h.Cost
+}
+
+// hashes the passwords with argon2id, stored in the PHC-format
+// ("$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>")
+type Argon2Hasher struct {
+	// memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const argon2Prefix = "$argon2id$"
+
+// lengths of the salt and the hash in bytes
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+func (h Argon2Hasher) Hash(password []byte) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return h.encode(salt, argon2.IDKey(password, salt, h.Iterations, h.Memory, h.Parallelism, argon2KeyLength)), nil
+}
+
+func (h Argon2Hasher) encode(salt, key []byte) []byte {
+	return fmt.Appendf(nil, "%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// splits a hash into its parameters, its salt and its key
+func decodeArgon2(hash []byte) (Argon2Hasher, []byte, []byte, error) {
+	var params Argon2Hasher
+	var version int
+
+	parts := bytes.Split(hash, []byte("$"))
+	if len(parts) != 6 || string(parts[1]) != "argon2id" {
+		return params, nil, nil, fmt.Errorf("no argon2id-hash")
+	} else if _, err := fmt.Sscanf(string(parts[2]), "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2-version")
+	} else if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2-parameters: %v", err)
+	}
+
+	if salt, err := base64.RawStdEncoding.DecodeString(string(parts[4])); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2-salt: %v", err)
+	} else if key, err := base64.RawStdEncoding.DecodeString(string(parts[5])); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2-hash: %v", err)
+	} else {
+		return params, salt, key, nil
+	}
+}
+
+func (h Argon2Hasher) Compare(hash, password []byte) error {
+	params, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(key, argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))) != 1 {
+		return fmt.Errorf("password doesn't match")
+	}
+
+	return nil
+}
+
+func (h Argon2Hasher) Current(hash []byte) bool {
+	params, _, _, err := decodeArgon2(hash)
+
+	return err == nil && params == h
+}
+
+// returns the algorithm a hash was created with, the stored hashes are recognized by their prefix
+func HasherOf(hash []byte) PasswordHasher {
+	if bytes.HasPrefix(hash, []byte(argon2Prefix)) {
+		return Argon2Hasher{}
+	} else {
+		return BcryptHasher{}
+	}
+}
@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sends a state (e.g. "READY=1") to the service-manager. Without systemd
+// (no NOTIFY_SOCKET) nothing is sent
+func SdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// abstract sockets are given with a leading "@"
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	if conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"}); err != nil {
+		return err
+	} else {
+		defer conn.Close()
+
+		_, err := conn.Write([]byte(state))
+
+		return err
+	}
+}
+
+// returns the interval the watchdog of the service-manager expects a sign of life in,
+// zero if the watchdog isn't enabled for this process
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	} else if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err != nil || usec <= 0 {
+		return 0
+	} else {
+		return time.Duration(usec) * time.Microsecond
+	}
+}
@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"runtime/debug"
+)
+
+// version of the build, set with
+// -ldflags "-X github.com/johannesbuehl/johannes-pv/backend/lib.Version=..."
+// like Commit and BuildTime
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+func init() {
+	// fall back to the vcs-information go embeds into the binary
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if Commit == "" {
+					Commit = setting.Value
+				}
+			case "vcs.time":
+				if BuildTime == "" {
+					BuildTime = setting.Value
+				}
+			}
+		}
+	}
+}
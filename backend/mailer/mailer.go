@@ -0,0 +1,150 @@
+// Package mailer sends the mails of the backend rendered from templates.
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	mail "github.com/xhit/go-simple-mail/v2"
+)
+
+var mailServer *mail.SMTPServer
+
+// sender-address of the mails
+var from string
+
+// parses the encryption of the mail-server, defaults to SSL/TLS
+func getMailEncryption(encryption string) mail.Encryption {
+	switch strings.ToLower(encryption) {
+	case "starttls":
+		return mail.EncryptionSTARTTLS
+	case "none":
+		return mail.EncryptionNone
+	default:
+		return mail.EncryptionSSLTLS
+	}
+}
+
+// sets up the connection to the mail-server and parses the markdown-templates
+func Init(cfg config.ConfigStruct) error {
+	mailServer = mail.NewSMTPClient()
+
+	mailServer.Host = cfg.Mail.Server
+	mailServer.Port = cfg.Mail.Port
+	mailServer.Encryption = getMailEncryption(cfg.Mail.Encryption)
+
+	mailServer.Username = cfg.Mail.User
+	mailServer.Password = cfg.Mail.Password
+
+	mailServer.ConnectTimeout = 10 * time.Second
+	mailServer.SendTimeout = 10 * time.Second
+
+	from = fmt.Sprintf("Klimaplus-Patenschaft <%s>", cfg.Mail.User)
+
+	duplicateWindow = cfg.MailDuplicateWindow
+	maxPerHour = cfg.Mail.MaxPerHour
+
+	if err := loadMarkdownTemplates(); err != nil {
+		return fmt.Errorf("can't parse mail-templates: %v", err)
+	}
+
+	return nil
+}
+
+// sends a plain-text mail. Duplicates of recent mails are suppressed and ErrThrottled is
+// returned while the hourly limit is reached, the same applies to all mails
+func Send(to, subject, body string) error {
+	email := mail.NewMSG()
+
+	email.SetFrom(from).AddTo(to).SetSubject(subject)
+
+	email.SetBody(mail.TextPlain, body)
+
+	return guard(to, subject, body, func() error {
+		faults.DelayMail()
+
+		if mailClient, err := mailServer.Connect(); err != nil {
+			return fmt.Errorf("can't connect to to mail-server: %v", err)
+		} else {
+			return email.Send(mailClient)
+		}
+	})
+}
+
+// renders the html- and the plain-text-part of a mail, either from the single
+// markdown-source "templates/<name>.md" or from "templates/<name>.html" and "templates/<name>.txt"
+func renderBodies(name string, data any) (string, string, error) {
+	if tpl, ok := markdownTemplates[name]; ok {
+		return renderMarkdown(tpl, data)
+	} else if bodyHTML, err := lib.ParseHTMLTemplate("templates/"+name+".html", data); err != nil {
+		return "", "", err
+	} else if bodyPlain, err := lib.ParseHTMLTemplate("templates/"+name+".txt", data); err != nil {
+		return "", "", err
+	} else {
+		return bodyHTML, bodyPlain, nil
+	}
+}
+
+// rendered mail, e.g. for queueing it while the mail-server isn't available
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Plain   string
+}
+
+// renders a mail from the templates "templates/<name>" (subject) and the bodies (see renderBodies)
+func Render(to, name string, data any) (Message, error) {
+	if subject, err := lib.ParseTemplate("templates/"+name, data); err != nil {
+		return Message{}, err
+	} else if bodyHTML, bodyPlain, err := renderBodies(name, data); err != nil {
+		return Message{}, err
+	} else {
+		return Message{
+			To:      to,
+			Subject: subject,
+			HTML:    bodyHTML,
+			Plain:   bodyPlain,
+		}, nil
+	}
+}
+
+// sends a rendered mail with the files as attachments
+func (msg Message) Send(attachments ...string) error {
+	email := mail.NewMSG()
+
+	email.SetFrom(from).AddTo(msg.To).SetSubject(msg.Subject)
+
+	email.SetBody(mail.TextPlain, msg.Plain)
+
+	email.AddAlternative(mail.TextHTML, msg.HTML)
+
+	for _, attachment := range attachments {
+		email.Attach(&mail.File{
+			FilePath: attachment,
+		})
+	}
+
+	return guard(msg.To, msg.Subject, msg.Plain, func() error {
+		faults.DelayMail()
+
+		if mailClient, err := mailServer.Connect(); err != nil {
+			return fmt.Errorf("can't connect to to mail-server: %v", err)
+		} else {
+			return email.Send(mailClient)
+		}
+	})
+}
+
+// sends a mail rendered from the templates (see Render) with the files as attachments
+func SendTemplate(to, name string, data any, attachments ...string) error {
+	if msg, err := Render(to, name, data); err != nil {
+		return err
+	} else {
+		return msg.Send(attachments...)
+	}
+}
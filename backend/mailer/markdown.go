@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	templateHTML "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/yuin/goldmark"
+)
+
+// directory of the mail-templates
+const templateDir = "templates"
+
+// optional html-layout the rendered markdown is inserted into as "{{ .Content }}"
+const layoutFile = "layout.html"
+
+// mail-templates with a single markdown-source, parsed at startup
+var markdownTemplates map[string]*template.Template
+
+var htmlLayout *templateHTML.Template
+
+// parses the markdown-templates ("templates/<name>.md") and the html-layout
+func loadMarkdownTemplates() error {
+	markdownTemplates = map[string]*template.Template{}
+	htmlLayout = nil
+
+	if files, err := filepath.Glob(filepath.Join(templateDir, "*.md")); err != nil {
+		return err
+	} else {
+		for _, file := range files {
+			if tpl, err := template.New(filepath.Base(file)).Funcs(lib.TemplateFuncs()).ParseFiles(file); err != nil {
+				return err
+			} else {
+				markdownTemplates[strings.TrimSuffix(filepath.Base(file), ".md")] = tpl
+			}
+		}
+	}
+
+	if layout, err := templateHTML.New(layoutFile).Funcs(templateHTML.FuncMap(lib.TemplateFuncs())).ParseFiles(filepath.Join(templateDir, layoutFile)); err == nil {
+		htmlLayout = layout
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// regexes converting the markdown-syntax to plain-text
+var plainTextReplacements = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	// images and links: "[text](url)" -> "text (url)"
+	{regexp.MustCompile(`!?\[([^\]]*)\]\(([^)]*)\)`), "$1 ($2)"},
+	// headings
+	{regexp.MustCompile(`(?m)^#{1,6}\s+`), ""},
+	// emphasis
+	{regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`), "$2"},
+	{regexp.MustCompile(`(^|[^*\w])\*([^*\n]+)\*`), "$1$2"},
+	// horizontal rules
+	{regexp.MustCompile(`(?m)^(-{3,}|\*{3,})\s*$`), strings.Repeat("-", 40)},
+}
+
+// converts markdown to readable plain-text
+func markdownToPlain(md string) string {
+	for _, r := range plainTextReplacements {
+		md = r.regex.ReplaceAllString(md, r.replacement)
+	}
+
+	return md
+}
+
+// renders the html- and the plain-text-part of a mail from its markdown-template
+func renderMarkdown(tpl *template.Template, data any) (string, string, error) {
+	var md bytes.Buffer
+
+	if err := tpl.Execute(&md, data); err != nil {
+		return "", "", err
+	}
+
+	var html bytes.Buffer
+
+	if err := goldmark.Convert(md.Bytes(), &html); err != nil {
+		return "", "", err
+	}
+
+	if htmlLayout != nil {
+		var wrapped bytes.Buffer
+
+		if err := htmlLayout.Execute(&wrapped, struct{ Content templateHTML.HTML }{Content: templateHTML.HTML(html.String())}); err != nil {
+			return "", "", err
+		}
+
+		html = wrapped
+	}
+
+	return html.String(), markdownToPlain(md.String()), nil
+}
@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// returned while the hourly limit of mails is reached, the mail can be sent later
+var ErrThrottled = errors.New("hourly limit of mails is reached")
+
+// identical mails to the same recipient within this time are only sent once
+var duplicateWindow time.Duration
+
+// maximum number of mails per hour, zero for no limit
+var maxPerHour int
+
+// recently sent mails
+var sent = struct {
+	sync.Mutex
+	// time of the mails by their key, for suppressing duplicates
+	recent map[string]time.Time
+	// times of the mails within the last hour
+	times []time.Time
+}{
+	recent: map[string]time.Time{},
+}
+
+// identifies a mail by its recipient and content
+func mailKey(to, subject, body string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(to)) + "\x00" + subject + "\x00" + body))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// reserves the sending of a mail before it is sent, so concurrent duplicates are caught as well
+//
+// @returns wether the mail is a duplicate of a recent one and shouldn't be sent
+func reserve(key string) (bool, error) {
+	sent.Lock()
+	defer sent.Unlock()
+
+	now := time.Now()
+
+	for k, t := range sent.recent {
+		if now.Sub(t) >= duplicateWindow {
+			delete(sent.recent, k)
+		}
+	}
+
+	for len(sent.times) > 0 && now.Sub(sent.times[0]) >= time.Hour {
+		sent.times = sent.times[1:]
+	}
+
+	if _, ok := sent.recent[key]; ok && duplicateWindow > 0 {
+		return true, nil
+	} else if maxPerHour > 0 && len(sent.times) >= maxPerHour {
+		return false, ErrThrottled
+	}
+
+	if duplicateWindow > 0 {
+		sent.recent[key] = now
+	}
+	sent.times = append(sent.times, now)
+
+	return false, nil
+}
+
+// releases the reservation of a mail that couldn't be sent, so it can be retried
+func release(key string) {
+	sent.Lock()
+	defer sent.Unlock()
+
+	delete(sent.recent, key)
+
+	if len(sent.times) > 0 {
+		sent.times = sent.times[:len(sent.times)-1]
+	}
+}
+
+// sends a mail unless it is a duplicate of a recent one or the hourly limit is reached
+func guard(to, subject, body string, send func() error) error {
+	key := mailKey(to, subject, body)
+
+	if duplicate, err := reserve(key); err != nil {
+		return err
+	} else if duplicate {
+		return nil
+	} else if err := send(); err != nil {
+		release(key)
+
+		return err
+	} else {
+		return nil
+	}
+}
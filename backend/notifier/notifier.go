@@ -0,0 +1,180 @@
+// Package notifier pushes the events of the backend to chat-channels (e.g. the group of the board).
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/rs/zerolog"
+)
+
+// sends a message to a channel
+type Notifier interface {
+	Notify(kind, message string) error
+}
+
+// configured channel with the events it receives
+type channel struct {
+	name     string
+	events   []string
+	notifier Notifier
+}
+
+// creates the notifier of a channel from its config
+var notifierTypes = map[string]func(cfg config.NotificationChannel) Notifier{
+	"telegram": func(cfg config.NotificationChannel) Notifier {
+		return telegramNotifier{token: cfg.Token, chat: cfg.Target}
+	},
+	"matrix": func(cfg config.NotificationChannel) Notifier {
+		return matrixNotifier{homeserver: strings.TrimRight(cfg.URL, "/"), token: cfg.Token, room: cfg.Target}
+	},
+	"ntfy": func(cfg config.NotificationChannel) Notifier {
+		server := cfg.URL
+		if server == "" {
+			server = "https://ntfy.sh"
+		}
+
+		return ntfyNotifier{server: strings.TrimRight(server, "/"), token: cfg.Token, topic: cfg.Target}
+	},
+}
+
+// client for the requests to the chat-apis
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+var channels []channel
+
+var logger zerolog.Logger
+
+// sets up the configured channels
+func Init(cfg config.ConfigStruct, log zerolog.Logger) error {
+	logger = log
+	channels = nil
+
+	for ii, channelConfig := range cfg.NotificationChannels {
+		if newNotifier, ok := notifierTypes[channelConfig.Type]; !ok {
+			return fmt.Errorf("notification-channel %d has unknown type %q", ii, channelConfig.Type)
+		} else {
+			channels = append(channels, channel{
+				name:     fmt.Sprintf("%s (%d)", channelConfig.Type, ii),
+				events:   channelConfig.Events,
+				notifier: newNotifier(channelConfig),
+			})
+		}
+	}
+
+	return nil
+}
+
+// pushes an event to all channels receiving its kind. The messages are sent in the
+// background, so slow chat-apis don't delay the requests
+func Publish(kind, message string) {
+	for _, ch := range channels {
+		if len(ch.events) > 0 && !slices.Contains(ch.events, kind) {
+			continue
+		}
+
+		go func() {
+			if err := ch.notifier.Notify(kind, message); err != nil {
+				logger.Warn().Msgf("can't push %q-event to channel %s: %v", kind, ch.name, err)
+			}
+		}()
+	}
+}
+
+// sends a request and checks the response-status
+func send(req *http.Request) error {
+	if res, err := client.Do(req); err != nil {
+		return err
+	} else {
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("api responded with %s", res.Status)
+		}
+
+		return nil
+	}
+}
+
+// sends a JSON-body to an url
+func sendJSON(method, u, token string, body any) error {
+	if data, err := json.Marshal(body); err != nil {
+		return err
+	} else if req, err := http.NewRequest(method, u, bytes.NewReader(data)); err != nil {
+		return err
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return send(req)
+	}
+}
+
+// posts to a chat through a telegram-bot
+type telegramNotifier struct {
+	token string
+	chat  string
+}
+
+func (n telegramNotifier) Notify(kind, message string) error {
+	return sendJSON(http.MethodPost, fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.token), "", map[string]string{
+		"chat_id": n.chat,
+		"text":    fmt.Sprintf("[%s] %s", kind, message),
+	})
+}
+
+// posts to a matrix-room
+type matrixNotifier struct {
+	homeserver string
+	token      string
+	room       string
+}
+
+func (n matrixNotifier) Notify(kind, message string) error {
+	// the transaction-id only has to be unique for the access-token
+	txnID := fmt.Sprint(time.Now().UnixNano())
+
+	return sendJSON(
+		http.MethodPut,
+		fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", n.homeserver, url.PathEscape(n.room), txnID),
+		n.token,
+		map[string]string{
+			"msgtype": "m.text",
+			"body":    fmt.Sprintf("[%s] %s", kind, message),
+		},
+	)
+}
+
+// publishes to a ntfy-topic
+type ntfyNotifier struct {
+	server string
+	token  string
+	topic  string
+}
+
+func (n ntfyNotifier) Notify(kind, message string) error {
+	if req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s", n.server, url.PathEscape(n.topic)), strings.NewReader(message)); err != nil {
+		return err
+	} else {
+		req.Header.Set("Title", "Klimaplus-Patenschaft: "+kind)
+		req.Header.Set("Tags", kind)
+
+		if n.token != "" {
+			req.Header.Set("Authorization", "Bearer "+n.token)
+		}
+
+		return send(req)
+	}
+}
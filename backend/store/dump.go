@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// handling of rows whose key already exists during a restore
+type Conflict string
+
+const (
+	// aborts the whole restore
+	ConflictAbort Conflict = "abort"
+	// keeps the existing row
+	ConflictSkip Conflict = "skip"
+	// overwrites the columns of the existing row with the restored ones
+	ConflictUpdate Conflict = "update"
+)
+
+// retrieves the names of the columns of a table
+func Columns(table string) ([]string, error) {
+	if columns, err := getTableColumns(table); err != nil {
+		return nil, err
+	} else {
+		names := make([]string, 0, len(columns))
+
+		for name := range columns {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		return names, nil
+	}
+}
+
+// reads all rows of a table as "column: value" with the encrypted columns decrypted.
+// Text-values are returned as strings, NULL as nil
+func Dump(table string) ([]map[string]any, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s", table))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]any{}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		scanArgs := make([]any, len(columns))
+
+		for ii := range values {
+			scanArgs[ii] = &values[ii]
+		}
+
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+
+		for ii, col := range columns {
+			value := values[ii]
+
+			if bytes, ok := value.([]byte); ok {
+				value = string(bytes)
+			}
+
+			if text, ok := value.(string); ok && encryptedColumns[table][col] != 0 {
+				if plaintext, err := decryptString(text); err != nil {
+					return nil, fmt.Errorf("can't decrypt column %q of table %q: %v", col, table, err)
+				} else {
+					value = plaintext
+				}
+			}
+
+			row[col] = value
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// writes the rows of the tables in the given order within a single transaction, the
+// encrypted columns are encrypted. The insert-only columns per table aren't overwritten
+// on a conflict. Returns the number of written rows per table
+func Restore(tables map[string][]map[string]any, order []string, conflict Conflict, insertOnly map[string][]string) (map[string]int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	written := make(map[string]int64)
+
+	for _, table := range order {
+		for _, row := range tables[table] {
+			columns := make([]string, 0, len(row))
+
+			for col := range row {
+				columns = append(columns, col)
+			}
+
+			slices.Sort(columns)
+
+			values := make([]any, len(columns))
+			updates := []string{}
+
+			for ii, col := range columns {
+				if value, err := sealColumn(table, col, row[col]); err != nil {
+					tx.Rollback()
+
+					return nil, err
+				} else {
+					values[ii] = value
+				}
+
+				if !slices.Contains(insertOnly[table], col) {
+					updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", col, col))
+				}
+			}
+
+			query := fmt.Sprintf("INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+
+			switch conflict {
+			case ConflictSkip:
+				query = "INSERT IGNORE " + query
+			case ConflictUpdate:
+				query = fmt.Sprintf("INSERT %s ON DUPLICATE KEY UPDATE %s", query, strings.Join(updates, ", "))
+			default:
+				query = "INSERT " + query
+			}
+
+			if res, err := tx.Exec(query, values...); err != nil {
+				tx.Rollback()
+
+				return nil, fmt.Errorf("can't restore row of table %q: %v", table, err)
+			} else if affected, err := res.RowsAffected(); err != nil {
+				tx.Rollback()
+
+				return nil, err
+			} else if affected > 0 {
+				written[table]++
+			}
+		}
+	}
+
+	return written, tx.Commit()
+}
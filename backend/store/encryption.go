@@ -0,0 +1,249 @@
+package store
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+)
+
+// kinds of the encrypted columns
+type columnKind int
+
+const (
+	// mail-addresses are normalized, so an address in any case results in the same value
+	columnMail columnKind = iota + 1
+	columnText
+)
+
+// columns holding the contact-data of the sponsors, which are stored encrypted
+var encryptedColumns = map[string]map[string]columnKind{
+	"elements":             {"mail": columnMail, "giftmail": columnMail},
+	"sponsorships_archive": {"mail": columnMail, "giftmail": columnMail},
+	"mergedcontacts":       {"mail": columnMail},
+	"newsletter":           {"mail": columnMail},
+	"consents":             {"mail": columnMail},
+	"campaignmails":        {"mail": columnMail},
+	"mailqueue":            {"recipient": columnMail, "subject": columnText, "html": columnText, "plain": columnText},
+}
+
+// prefix of the encrypted values, values without it are read as plaintext
+const encryptedPrefix = "enc2:"
+
+// prefix of the values encrypted directly with the key, before the keys were derived from it.
+// They are read and encrypted again on startup
+const legacyPrefix = "enc:"
+
+// key of the encrypted columns, nil if the encryption is disabled
+var encryptionKey []byte
+
+// wether a column of a table is stored encrypted
+func isEncrypted(table, column string) bool {
+	return encryptionKey != nil && encryptedColumns[table][column] != 0
+}
+
+// normalizes a mail-address, so lookups don't depend on its case
+func normalizeMail(mail string) string {
+	return strings.ToLower(strings.TrimSpace(mail))
+}
+
+// wether a value is already encrypted with the current scheme. A plaintext that only starts with
+// the prefix, e.g. entered by a user, doesn't decrypt and is encrypted like any other
+func isSealed(value string) bool {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return false
+	}
+
+	_, err := decryptString(value)
+
+	return err == nil
+}
+
+// encrypts a string for an encrypted column, values that are already encrypted are kept
+func encryptString(value string) (string, error) {
+	if encryptionKey == nil || value == "" || isSealed(value) {
+		return value, nil
+	} else if ciphertext, err := lib.EncryptDeterministic(encryptionKey, []byte(value)); err != nil {
+		return "", err
+	} else {
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+	}
+}
+
+// decrypts a value of an encrypted column, plaintext-values are returned unchanged
+func decryptString(value string) (string, error) {
+	decrypt := lib.DecryptDeterministic
+
+	if strings.HasPrefix(value, encryptedPrefix) {
+		value = strings.TrimPrefix(value, encryptedPrefix)
+	} else if strings.HasPrefix(value, legacyPrefix) {
+		value = strings.TrimPrefix(value, legacyPrefix)
+		decrypt = lib.Decrypt
+	} else {
+		return value, nil
+	}
+
+	if encryptionKey == nil {
+		return "", fmt.Errorf("value is encrypted, but no key is configured")
+	} else if ciphertext, err := base64.StdEncoding.DecodeString(value); err != nil {
+		return "", err
+	} else if plaintext, err := decrypt(encryptionKey, ciphertext); err != nil {
+		return "", err
+	} else {
+		return string(plaintext), nil
+	}
+}
+
+// encrypts a value of a column of the kind, supports strings and string-pointers
+func sealKind(kind columnKind, value any) (any, error) {
+	seal := encryptString
+	if kind == columnMail {
+		// the normalization would break the ciphertext of an encrypted value
+		seal = func(value string) (string, error) {
+			if isSealed(value) {
+				return value, nil
+			} else {
+				return encryptString(normalizeMail(value))
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return seal(v)
+	case *string:
+		if v == nil {
+			return v, nil
+		} else if sealed, err := seal(*v); err != nil {
+			return nil, err
+		} else {
+			return &sealed, nil
+		}
+	default:
+		return nil, fmt.Errorf("can't encrypt value of type %T", value)
+	}
+}
+
+// encrypts a mail-address for an encrypted column, supports strings and string-pointers.
+// Used for the values of raw queries, as the encryption is deterministic it works for
+// comparisons in where-clauses, too
+func Seal(value any) (any, error) {
+	if encryptionKey == nil {
+		return value, nil
+	} else {
+		return sealKind(columnMail, value)
+	}
+}
+
+// encrypts the value of a column, if it is encrypted
+func sealColumn(table, column string, value any) (any, error) {
+	if !isEncrypted(table, column) {
+		return value, nil
+	} else {
+		return sealKind(encryptedColumns[table][column], value)
+	}
+}
+
+// decrypts a scanned struct-field of an encrypted column in place
+func openField(field reflect.Value) error {
+	switch field.Kind() {
+	case reflect.String:
+		if plaintext, err := decryptString(field.String()); err != nil {
+			return err
+		} else {
+			field.SetString(plaintext)
+		}
+	case reflect.Pointer:
+		if !field.IsNil() && field.Elem().Kind() == reflect.String {
+			return openField(field.Elem())
+		}
+	}
+
+	return nil
+}
+
+// encrypts the plaintext-values remaining in the encrypted columns, e.g. after enabling the encryption.
+// Values encrypted before the keys were derived and mail-addresses that aren't normalized are encrypted
+// again. As the encryption is deterministic, all rows with the same value are updated at once
+func encryptExisting() error {
+	if encryptionKey == nil {
+		return nil
+	}
+
+	for table, columns := range encryptedColumns {
+		for column, kind := range columns {
+			values, err := outdatedValues(table, column)
+			if err != nil {
+				return err
+			}
+
+			updated := 0
+
+			for _, value := range values {
+				plaintext, err := decryptString(value)
+				if err != nil && strings.HasPrefix(value, encryptedPrefix) {
+					// a plaintext starting with the prefix, stored unencrypted before it was checked
+					plaintext, err = value, nil
+				}
+
+				if err != nil {
+					return err
+				} else if sealed, err := sealKind(kind, plaintext); err != nil {
+					return err
+				} else if sealed == value {
+					continue
+				} else if _, err := execRetry(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", table, column, column), sealed, value); isDuplicateKey(err) {
+					// the row duplicates a unique normalized mail-address, e.g. "Foo@example.org" and "foo@example.org"
+					if _, err := execRetry(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, column), value); err != nil {
+						return err
+					}
+				} else if err != nil {
+					return err
+				}
+
+				updated++
+			}
+
+			if updated > 0 {
+				logger.Info().Msgf("encrypted %d values of %s.%s", updated, table, column)
+			}
+		}
+	}
+
+	return nil
+}
+
+// returns the distinct values of an encrypted column, that aren't encrypted with the current scheme.
+// Mail-addresses are all returned, as their ciphertext doesn't tell wether they're normalized
+func outdatedValues(table, column string) ([]string, error) {
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL AND %s != ''", column, table, column, column)
+
+	if encryptedColumns[table][column] != columnMail {
+		query += fmt.Sprintf(" AND %s NOT LIKE '%s%%'", column, encryptedPrefix)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value string
+
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
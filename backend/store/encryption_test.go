@@ -0,0 +1,47 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+// uses a key for the encrypted columns during a test
+func useEncryptionKey(t *testing.T) {
+	t.Helper()
+
+	previous := encryptionKey
+	encryptionKey = bytes.Repeat([]byte{0x42}, 32)
+
+	t.Cleanup(func() { encryptionKey = previous })
+}
+
+// encrypted values are kept, a plaintext starting with the prefix is encrypted anyway
+func TestSealKindPrefixedPlaintext(t *testing.T) {
+	useEncryptionKey(t)
+
+	for _, kind := range []columnKind{columnMail, columnText} {
+		sealed, err := sealKind(kind, "enc2:Not-Encrypted")
+		if err != nil {
+			t.Fatalf("can't seal value: %v", err)
+		} else if sealed == "enc2:Not-Encrypted" {
+			t.Fatal("prefixed plaintext is stored unencrypted")
+		}
+
+		if resealed, err := sealKind(kind, sealed); err != nil {
+			t.Fatalf("can't seal encrypted value: %v", err)
+		} else if resealed != sealed {
+			t.Error("encrypted value was encrypted again")
+		}
+
+		expected := "enc2:Not-Encrypted"
+		if kind == columnMail {
+			expected = normalizeMail(expected)
+		}
+
+		if plaintext, err := decryptString(sealed.(string)); err != nil {
+			t.Errorf("can't decrypt value: %v", err)
+		} else if plaintext != expected {
+			t.Errorf("value decrypts to %q, expected %q", plaintext, expected)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// connection to the read-replica, nil if none is configured
+var replica *sql.DB
+
+// time after a failed query until the replica is used again
+const replicaRetry = 30 * time.Second
+
+// time until which the replica is considered down
+var replicaDown struct {
+	sync.Mutex
+	until time.Time
+}
+
+// returns the value or the fallback, if the value is empty
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	} else {
+		return value
+	}
+}
+
+// connects to the read-replica, if one is configured
+func openReplica(cfg config.ConfigStruct) error {
+	replicaConfig := cfg.Database.Replica
+
+	if replicaConfig.Host == "" {
+		return nil
+	}
+
+	sqlConfig := mysql.Config{
+		AllowNativePasswords: true,
+		Net:                  "tcp",
+		User:                 orDefault(replicaConfig.User, cfg.Database.User),
+		Passwd:               orDefault(replicaConfig.Password, cfg.Database.Password),
+		Addr:                 replicaConfig.Host,
+		DBName:               orDefault(replicaConfig.Database, cfg.Database.Database),
+	}
+
+	if conn, err := sql.Open("mysql", sqlConfig.FormatDSN()); err != nil {
+		return err
+	} else {
+		replica = conn
+	}
+
+	replica.SetMaxIdleConns(10)
+	replica.SetConnMaxLifetime(time.Minute)
+
+	return nil
+}
+
+// checks wether the replica can be used
+func replicaAvailable() bool {
+	if replica == nil {
+		return false
+	}
+
+	replicaDown.Lock()
+	defer replicaDown.Unlock()
+
+	return time.Now().After(replicaDown.until)
+}
+
+// query the read-replica, falls back to the primary if there is no replica or it fails.
+// The replica may lag behind, so this is only for reads that don't need to see a preceding write
+func SelectReplica[T any](table string, where string, args ...any) ([]T, error) {
+	if replicaAvailable() {
+		if results, err := selectFrom[T](replica, table, where, args...); err == nil {
+			return results, nil
+		} else {
+			logger.Warn().Msgf("read-replica failed, falling back to the primary for %s: %v", replicaRetry, err)
+
+			replicaDown.Lock()
+			replicaDown.until = time.Now().Add(replicaRetry)
+			replicaDown.Unlock()
+		}
+	}
+
+	return Select[T](table, where, args...)
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+)
+
+// error-codes of mysql for transient lock-conflicts, after which the statement can be retried
+const (
+	errDeadlock    = 1213
+	errLockTimeout = 1205
+)
+
+// error-code of mysql for a duplicate entry of a unique key
+const errDuplicateKey = 1062
+
+// number of retries of a write after a lock-conflict and the delay before the first one,
+// which doubles with every further retry
+var retries int
+var retryDelay time.Duration
+
+// counters of the lock-conflicts and retries
+var retryMetrics struct {
+	deadlocks    atomic.Int64
+	lockTimeouts atomic.Int64
+	retries      atomic.Int64
+	failed       atomic.Int64
+}
+
+// counters of the lock-conflicts of the writes
+type RetryMetrics struct {
+	Deadlocks    int64
+	LockTimeouts int64
+	// retried statements
+	Retries int64
+	// statements that still failed after all retries
+	Failed int64
+}
+
+// retrieves the counters of the lock-conflicts
+func GetRetryMetrics() RetryMetrics {
+	return RetryMetrics{
+		Deadlocks:    retryMetrics.deadlocks.Load(),
+		LockTimeouts: retryMetrics.lockTimeouts.Load(),
+		Retries:      retryMetrics.retries.Load(),
+		Failed:       retryMetrics.failed.Load(),
+	}
+}
+
+// wether an error is a transient lock-conflict
+func isLockConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+
+	switch mysqlErr.Number {
+	case errDeadlock:
+		retryMetrics.deadlocks.Add(1)
+	case errLockTimeout:
+		retryMetrics.lockTimeouts.Add(1)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// wether an error is the violation of a unique key
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateKey
+}
+
+// executes a write-statement, retries it with a jittered backoff if it fails because of
+// a deadlock or a lock-timeout. Without a transaction, mysql only rolls back the failed
+// statement, so it can be repeated as is
+func execRetry(query string, args ...any) (sql.Result, error) {
+	if err := faults.Database(); err != nil {
+		return nil, err
+	}
+
+	delay := retryDelay
+
+	for attempt := 0; ; attempt++ {
+		res, err := db.Exec(query, args...)
+
+		if err == nil || !isLockConflict(err) {
+			return res, err
+		} else if attempt >= retries {
+			retryMetrics.failed.Add(1)
+
+			logger.Error().Msgf("write failed after %d retries: %v", attempt, err)
+
+			return res, err
+		}
+
+		retryMetrics.retries.Add(1)
+
+		// the jitter keeps the conflicting writes from colliding again
+		wait := delay/2 + rand.N(delay/2+1)
+
+		logger.Warn().Msgf("write failed with lock-conflict, retrying in %s: %v", wait, err)
+
+		time.Sleep(wait)
+
+		delay *= 2
+	}
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// how long the detected columns of a table are trusted before checking again
+const schemaCacheExpiration = time.Minute
+
+// tables whose renamed columns are currently resolved to their old name
+var legacyColumns = struct {
+	sync.Mutex
+	tables map[string]map[string]string
+}{}
+
+// retrieves the columns currently existing in a table
+func getTableColumns(table string) (map[string]bool, error) {
+	cacheKey := table
+
+	if columns, found := columnCache.Get(cacheKey); found {
+		return columns.(map[string]bool), nil
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SHOW COLUMNS FROM %s", table))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool)
+
+	for rows.Next() {
+		var name string
+
+		// only the first column (the field-name) is of interest
+		scanArgs := make([]any, len(columnTypes))
+		scanArgs[0] = &name
+
+		for ii := 1; ii < len(scanArgs); ii++ {
+			scanArgs[ii] = new(any)
+		}
+
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+
+		columns[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	columnCache.Set(cacheKey, columns, schemaCacheExpiration)
+
+	return columns, nil
+}
+
+// returns the columns of a table that have to be accessed by their old name,
+// because the new name doesn't exist (yet) in the database
+//
+// @returns map[new-name]old-name
+func getLegacyColumns(table string) map[string]string {
+	renamed, ok := renamedColumns[table]
+
+	// if there are no renamed columns for this table, there is nothing to check
+	if !ok || len(renamed) == 0 {
+		return nil
+	}
+
+	columns, err := getTableColumns(table)
+	if err != nil {
+		logger.Error().Msgf("can't read columns of table %q: %v", table, err)
+
+		return nil
+	}
+
+	legacy := make(map[string]string)
+
+	for newName, oldName := range renamed {
+		if !columns[newName] && columns[oldName] {
+			legacy[newName] = oldName
+		}
+	}
+
+	legacyColumns.Lock()
+	defer legacyColumns.Unlock()
+
+	if legacyColumns.tables == nil {
+		legacyColumns.tables = make(map[string]map[string]string)
+	}
+
+	// log, when the resolution of a table changes
+	if fmt.Sprint(legacyColumns.tables[table]) != fmt.Sprint(legacy) {
+		logger.Info().Msgf("table %q: reading renamed columns by their old name: %v", table, legacy)
+
+		legacyColumns.tables[table] = legacy
+	}
+
+	return legacy
+}
+
+// resolves a column-name to the one existing in the database
+func resolveColumn(legacy map[string]string, column string) string {
+	if oldName, ok := legacy[column]; ok {
+		return oldName
+	} else {
+		return column
+	}
+}
+
+// replaces all renamed columns in a where-clause with their old names
+func resolveWhere(legacy map[string]string, where string) string {
+	for newName, oldName := range legacy {
+		where = regexp.MustCompile(fmt.Sprintf(`\b%s\b`, regexp.QuoteMeta(newName))).ReplaceAllString(where, oldName)
+	}
+
+	return where
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// reads a setting from the database and decodes it into value
+//
+// @returns wether the setting exists
+func LoadSetting(name string, value any) (bool, error) {
+	var buf []byte
+
+	if err := db.QueryRow("SELECT value FROM settings WHERE name = ?", name).Scan(&buf); errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else {
+		return true, json.Unmarshal(buf, value)
+	}
+}
+
+// stores a setting in the database
+func StoreSetting(name string, value any) error {
+	if buf, err := json.Marshal(value); err != nil {
+		return err
+	} else {
+		_, err := execRetry("REPLACE INTO settings (name, value) VALUES (?, ?)", name, buf)
+
+		return err
+	}
+}
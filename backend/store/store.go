@@ -0,0 +1,400 @@
+// Package store provides the access to the database.
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// connection to database
+var db *sql.DB
+
+// cache for the detected columns of the tables
+var columnCache *cache.Cache
+
+// renamed columns per table as "new-name: old-name"
+var renamedColumns map[string]map[string]string
+
+var logger zerolog.Logger
+
+// applies the settings of the config to the package
+func configure(cfg config.ConfigStruct, log zerolog.Logger) {
+	logger = log
+	renamedColumns = cfg.Database.RenamedColumns
+	encryptionKey = cfg.DatabaseKey
+	retries = cfg.ConfigYaml.Database.Retries
+	retryDelay = cfg.DatabaseRetryDelay
+	columnCache = cache.New(schemaCacheExpiration, schemaCacheExpiration)
+}
+
+// uses an already opened connection instead of connecting to the configured database,
+// e.g. one of a fake driver in the tests. There is no read-replica
+func Use(conn *sql.DB, cfg config.ConfigStruct, log zerolog.Logger) {
+	configure(cfg, log)
+
+	db = conn
+}
+
+// connects to the database
+func Open(cfg config.ConfigStruct, log zerolog.Logger) error {
+	configure(cfg, log)
+
+	// setup the database-connection
+	sqlConfig := mysql.Config{
+		AllowNativePasswords: true,
+		Net:                  "tcp",
+		User:                 cfg.Database.User,
+		Passwd:               cfg.Database.Password,
+		Addr:                 cfg.Database.Host,
+		DBName:               cfg.Database.Database,
+	}
+
+	// connect to the database
+	if conn, err := sql.Open("mysql", sqlConfig.FormatDSN()); err != nil {
+		return err
+	} else {
+		db = conn
+	}
+
+	db.SetMaxIdleConns(10)
+	db.SetMaxIdleConns(100)
+	db.SetConnMaxLifetime(time.Minute)
+
+	if err := encryptExisting(); err != nil {
+		return fmt.Errorf("can't encrypt the existing contact-data: %v", err)
+	}
+
+	return openReplica(cfg)
+}
+
+// checks wether the database is reachable
+func Ping() error {
+	if err := faults.Database(); err != nil {
+		return err
+	}
+
+	return db.Ping()
+}
+
+// closes the connection to the database
+func Close() error {
+	if replica != nil {
+		replica.Close()
+	}
+
+	return db.Close()
+}
+
+// executes a query without returning any rows, retries it on lock-conflicts
+func Exec(query string, args ...any) (sql.Result, error) {
+	return execRetry(query, args...)
+}
+
+// executes a query that is expected to return at most one row
+func QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRow(query, args...)
+}
+
+// query the database
+func Select[T any](table string, where string, args ...any) ([]T, error) {
+	return selectFrom[T](db, table, where, args...)
+}
+
+// query a database-connection
+func selectFrom[T any](conn *sql.DB, table string, where string, args ...any) ([]T, error) {
+	if err := faults.Database(); err != nil {
+		return nil, err
+	}
+
+	// validate columns against struct T
+	tType := reflect.TypeOf(new(T)).Elem()
+	columns := make([]string, tType.NumField())
+
+	validColumns := make(map[string]any)
+	for ii := 0; ii < tType.NumField(); ii++ {
+		field := tType.Field(ii)
+		validColumns[strings.ToLower(field.Name)] = struct{}{}
+		columns[ii] = strings.ToLower(field.Name)
+	}
+
+	for _, col := range columns {
+		if _, ok := validColumns[strings.ToLower(col)]; !ok {
+			return nil, fmt.Errorf("invalid column: %s for struct type %T", col, new(T))
+		}
+	}
+
+	// read renamed columns by their old name, if the new one doesn't exist yet
+	legacy := getLegacyColumns(table)
+
+	selectColumns := make([]string, len(columns))
+	for ii, col := range columns {
+		if resolved := resolveColumn(legacy, col); resolved != col {
+			selectColumns[ii] = fmt.Sprintf("%s AS %s", resolved, col)
+		} else {
+			selectColumns[ii] = col
+		}
+	}
+
+	// create the query
+	completeQuery := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selectColumns, ", "), table)
+
+	if where != "" && where != "*" {
+		completeQuery = fmt.Sprintf("%s WHERE %s", completeQuery, resolveWhere(legacy, where))
+	}
+
+	var rows *sql.Rows
+	var err error
+
+	if len(args) > 0 {
+		conn.Ping()
+
+		rows, err = conn.Query(completeQuery, args...)
+	} else {
+		conn.Ping()
+
+		rows, err = conn.Query(completeQuery)
+	}
+
+	if err != nil {
+		logger.Error().Msgf("database access failed with error %v", err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+	results := []T{}
+
+	title := cases.Title(language.Und)
+
+	for rows.Next() {
+		var lineResult T
+
+		scanArgs := make([]any, len(columns))
+		v := reflect.ValueOf(&lineResult).Elem()
+
+		for ii, col := range columns {
+			colTitle := title.String(col)
+
+			field := v.FieldByName(colTitle)
+
+			if field.IsValid() && field.CanSet() {
+				scanArgs[ii] = field.Addr().Interface()
+			} else {
+				logger.Warn().Msgf("Field %s not found in struct %T", col, lineResult)
+				scanArgs[ii] = new(any) // save dummy value
+			}
+		}
+
+		// scan the row into the struct
+		if err := rows.Scan(scanArgs...); err != nil {
+			logger.Warn().Msgf("Scan-error: %v", err)
+
+			return nil, err
+		}
+
+		// decrypt the encrypted columns
+		for _, col := range columns {
+			if encryptedColumns[table][col] != 0 {
+				if err := openField(v.FieldByName(title.String(col))); err != nil {
+					logger.Error().Msgf("can't decrypt column %q of table %q: %v", col, table, err)
+
+					return nil, err
+				}
+			}
+		}
+
+		results = append(results, lineResult)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error().Msgf("rows-error: %v", err)
+		return nil, err
+	} else {
+		return results, nil
+	}
+}
+
+// insert data intot the databse
+func Insert(table string, vals any) error {
+	if completeQuery, values, err := insertStatement(table, vals); err != nil {
+		return err
+	} else {
+		_, err := execRetry(completeQuery, values...)
+
+		return err
+	}
+}
+
+// builds the insert-statement of the fields of vals with its arguments
+func insertStatement(table string, vals any) (string, []any, error) {
+	// extract columns from vals
+	v := reflect.ValueOf(vals)
+	t := v.Type()
+
+	columns := make([]string, t.NumField())
+	values := make([]any, t.NumField())
+
+	legacy := getLegacyColumns(table)
+
+	for ii := 0; ii < t.NumField(); ii++ {
+		fieldValue := v.Field(ii)
+
+		field := t.Field(ii)
+
+		columns[ii] = resolveColumn(legacy, strings.ToLower(field.Name))
+
+		if value, err := sealColumn(table, strings.ToLower(field.Name), fieldValue.Interface()); err != nil {
+			return "", nil, err
+		} else {
+			values[ii] = value
+		}
+	}
+
+	placeholders := strings.Repeat(("?, "), len(columns))
+	placeholders = strings.TrimSuffix(placeholders, ", ")
+
+	completeQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders)
+
+	return completeQuery, values, nil
+}
+
+// update data in the database
+func Update(table string, set, where any) error {
+	if completeQuery, values, err := updateStatement(table, set, where); err != nil {
+		return err
+	} else {
+		_, err := execRetry(completeQuery, values...)
+
+		return err
+	}
+}
+
+// builds the update-statement setting the fields of set where the non-zero fields of where match
+func updateStatement(table string, set, where any) (string, []any, error) {
+	setV := reflect.ValueOf(set)
+	setT := setV.Type()
+
+	setColumns := make([]string, setT.NumField())
+	setValues := make([]any, setT.NumField())
+
+	legacy := getLegacyColumns(table)
+
+	for ii := 0; ii < setT.NumField(); ii++ {
+		fieldValue := setV.Field(ii)
+
+		field := setT.Field(ii)
+
+		setColumns[ii] = resolveColumn(legacy, strings.ToLower(field.Name)) + " = ?"
+
+		if value, err := sealColumn(table, strings.ToLower(field.Name), fieldValue.Interface()); err != nil {
+			return "", nil, err
+		} else {
+			setValues[ii] = value
+		}
+	}
+
+	whereV := reflect.ValueOf(where)
+	whereT := whereV.Type()
+
+	whereColumns := make([]string, whereT.NumField())
+	whereValues := make([]any, whereT.NumField())
+
+	for ii := 0; ii < whereT.NumField(); ii++ {
+		fieldValue := whereV.Field(ii)
+
+		// skip empty (zero) values
+		if !fieldValue.IsZero() {
+			field := whereT.Field(ii)
+
+			whereColumns[ii] = resolveColumn(legacy, strings.ToLower(field.Name)) + " = ?"
+
+			if value, err := sealColumn(table, strings.ToLower(field.Name), fmt.Sprint(fieldValue.Interface())); err != nil {
+				return "", nil, err
+			} else {
+				whereValues[ii] = value
+			}
+		}
+	}
+
+	sets := strings.Join(setColumns, ", ")
+	wheres := strings.Join(whereColumns, " AND ")
+
+	placeholderValues := append(setValues, whereValues...)
+
+	completeQuery := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, sets, wheres)
+
+	return completeQuery, placeholderValues, nil
+}
+
+// remove data from the database
+func Delete(table string, vals any) error {
+	// extract columns from vals
+	v := reflect.ValueOf(vals)
+	t := v.Type()
+
+	columns := make([]string, t.NumField())
+	values := make([]any, t.NumField())
+
+	legacy := getLegacyColumns(table)
+
+	for ii := 0; ii < t.NumField(); ii++ {
+		fieldValue := v.Field(ii)
+
+		// skip empty (zero) values
+		if !fieldValue.IsZero() {
+			field := t.Field(ii)
+
+			columns[ii] = resolveColumn(legacy, strings.ToLower(field.Name)) + " = ?"
+
+			if value, err := sealColumn(table, strings.ToLower(field.Name), fmt.Sprint(fieldValue.Interface())); err != nil {
+				return err
+			} else {
+				values[ii] = value
+			}
+		}
+	}
+
+	completeQuery := fmt.Sprintf("DELETE FROM %s WHERE %s", table, strings.Join(columns, ", "))
+
+	_, err := execRetry(completeQuery, values...)
+
+	return err
+}
+
+// number of rows deleted per statement by DeleteBefore
+const deleteBatchSize = 100
+
+// removes the rows whose column lies before the date in batches, so large sets
+// don't lock the table for long. Returns the number of removed rows
+func DeleteBefore(table, column, before string) (int64, error) {
+	column = resolveColumn(getLegacyColumns(table), column)
+
+	completeQuery := fmt.Sprintf("DELETE FROM %s WHERE %s IS NOT NULL AND %s < ? LIMIT %d", table, column, column, deleteBatchSize)
+
+	var deleted int64
+
+	for {
+		if res, err := execRetry(completeQuery, before); err != nil {
+			return deleted, err
+		} else if affected, err := res.RowsAffected(); err != nil {
+			return deleted, err
+		} else {
+			deleted += affected
+
+			if affected < deleteBatchSize {
+				return deleted, nil
+			}
+		}
+	}
+}
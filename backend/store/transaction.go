@@ -0,0 +1,61 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/johannesbuehl/johannes-pv/backend/faults"
+)
+
+// statements within a transaction of Transaction
+type Tx struct {
+	tx *sql.Tx
+}
+
+// runs the statements of the function within a single transaction. It is committed if the
+// function returns without an error, otherwise it is rolled back. A lock-conflict isn't
+// retried, since mysql rolls back the whole transaction on it
+func Transaction(fn func(tx *Tx) error) error {
+	if err := faults.Database(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&Tx{tx: tx}); err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// executes a query without returning any rows within the transaction
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.tx.Exec(query, args...)
+}
+
+// inserts data within the transaction like Insert
+func (tx *Tx) Insert(table string, vals any) error {
+	if query, values, err := insertStatement(table, vals); err != nil {
+		return err
+	} else {
+		_, err := tx.tx.Exec(query, values...)
+
+		return err
+	}
+}
+
+// updates data within the transaction like Update
+func (tx *Tx) Update(table string, set, where any) error {
+	if query, values, err := updateStatement(table, set, where); err != nil {
+		return err
+	} else {
+		_, err := tx.tx.Exec(query, values...)
+
+		return err
+	}
+}
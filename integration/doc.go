@@ -0,0 +1,3 @@
+// Package integration tests the backend end-to-end against a MariaDB and a mail-server started in
+// docker. The tests only run with the "integration" build-tag, e.g. with "make integration"
+package integration
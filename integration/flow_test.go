@@ -0,0 +1,135 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/api"
+)
+
+// runs through the reservation-, confirmation- and certificate-process
+func TestReservationFlow(t *testing.T) {
+	const mid = "pv-a1"
+	const name = "Erika Müller"
+
+	c, mailAPI := startBackend(t)
+
+	t.Logf("reserving element %q", mid)
+	if status, err := c.ReserveElement(mid, api.ReservationBody{Name: name, Mail: sponsorMail, Source: "integration"}); err != nil {
+		t.Fatalf("can't reserve element: %v", err)
+	} else if !slices.Contains(status.Reserved, mid) {
+		t.Fatalf("element %q isn't listed as reserved: %v", mid, status)
+	}
+
+	t.Logf("reserving element %q a second time", mid)
+	if _, err := c.ReserveElement(mid, api.ReservationBody{Name: name, Mail: sponsorMail}); err == nil {
+		t.Fatalf("element %q could be reserved twice", mid)
+	}
+
+	t.Log("checking the reservation-mail")
+	if err := expectMail(mailAPI, sponsorMail, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("logging in as admin")
+	if login, err := c.Login("admin", adminPassword); err != nil {
+		t.Fatalf("can't login: %v", err)
+	} else if !login.LoggedIn {
+		t.Fatal("login wasn't successful")
+	}
+
+	t.Log("renaming the reservation")
+	if reservations, err := c.UpdateReservation(mid, name+" (geändert)"); err != nil {
+		t.Fatalf("can't update reservation: %v", err)
+	} else if len(reservations) != 1 || reservations[0].Name != name+" (geändert)" {
+		t.Fatalf("reservation wasn't renamed: %v", reservations)
+	}
+
+	if *inkscape == "" {
+		t.Log("skipping the certificate-process: no inkscape given")
+	} else {
+		t.Log("confirming the reservation")
+		if reservations, err := c.ConfirmReservation(mid); err != nil {
+			t.Fatalf("can't confirm reservation: %v", err)
+		} else if len(reservations) != 0 {
+			t.Fatalf("reservation is still listed after confirmation: %v", reservations)
+		}
+
+		t.Log("checking the certificate-mail")
+		if err := expectMail(mailAPI, sponsorMail, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Log("downloading the certificate")
+		if pdf, err := c.GetCertificate(mid); err != nil {
+			t.Fatalf("can't download certificate: %v", err)
+		} else if !strings.HasPrefix(string(pdf), "%PDF") {
+			t.Fatal("certificate isn't a pdf")
+		}
+
+		t.Log("checking the sponsorships")
+		if sponsorships, err := c.ListSponsorships(); err != nil {
+			t.Fatalf("can't list sponsorships: %v", err)
+		} else if len(sponsorships) != 1 || sponsorships[0].Mid != mid {
+			t.Fatalf("element %q isn't listed as sponsorship: %v", mid, sponsorships)
+		}
+	}
+
+	t.Log("verifying an unknown certificate")
+	if _, err := c.VerifyCertificate("AAAA-AAAA-AAAA"); err == nil {
+		t.Fatal("unknown certificate was verified")
+	}
+
+	t.Log("removing the element")
+	if status, err := c.DeleteElement(mid); err != nil {
+		t.Fatalf("can't delete element: %v", err)
+	} else if _, ok := status.Taken[mid]; ok || slices.Contains(status.Reserved, mid) {
+		t.Fatalf("element %q is still listed: %v", mid, status)
+	}
+}
+
+// checks, that the mail-server has received the expected number of mails for a recipient
+func expectMail(mailAPI, recipient string, count int) error {
+	// the mails are sent synchronously, but give the mail-server a moment to store them
+	time.Sleep(500 * time.Millisecond)
+
+	res, err := http.Get(mailAPI)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	var messages struct {
+		Items []struct {
+			Raw struct {
+				To []string
+			}
+		}
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&messages); err != nil {
+		return err
+	}
+
+	received := 0
+
+	for _, item := range messages.Items {
+		if slices.Contains(item.Raw.To, recipient) {
+			received++
+		}
+	}
+
+	if received != count {
+		return fmt.Errorf("expected %d mails for %q, got %d", count, recipient, received)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+//go:build integration
+
+package integration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/johannesbuehl/johannes-pv/backend/client"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	dbName        = "pv"
+	dbPassword    = "integration"
+	adminPassword = "integration-password"
+	sponsorMail   = "sponsor@example.org"
+)
+
+var keep = flag.Bool("keep", false, "keep the working-directory and containers after the run")
+var inkscape = flag.String("inkscape", "", "path of an inkscape-directory to test the certificate-creation")
+
+// starts the database, the mail-server and the backend. Returns a client of the backend
+// and the api-url of the mail-server
+func startBackend(t *testing.T) (*client.Client, string) {
+	t.Helper()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Fatalf("can't connect to docker: %v", err)
+	}
+
+	pool.MaxWait = 2 * time.Minute
+
+	t.Log("starting the database")
+	dbResource := runContainer(t, pool, "mariadb", "11", "MARIADB_ROOT_PASSWORD="+dbPassword, "MARIADB_DATABASE="+dbName)
+
+	t.Log("starting the mail-server")
+	mailResource := runContainer(t, pool, "mailhog/mailhog", "latest")
+
+	dbHost := dbResource.GetHostPort("3306/tcp")
+	smtpPort := mailResource.GetPort("1025/tcp")
+	mailAPI := fmt.Sprintf("http://%s/api/v2/messages", mailResource.GetHostPort("8025/tcp"))
+
+	sqlConfig := mysql.Config{
+		AllowNativePasswords: true,
+		Net:                  "tcp",
+		User:                 "root",
+		Passwd:               dbPassword,
+		Addr:                 dbHost,
+		DBName:               dbName,
+	}
+
+	db, err := sql.Open("mysql", sqlConfig.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	t.Log("waiting for the database")
+	if err := pool.Retry(db.Ping); err != nil {
+		t.Fatalf("database isn't reachable: %v", err)
+	}
+
+	t.Log("creating the tables")
+	if err := setupDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("preparing the working-directory")
+	workDir, err := os.MkdirTemp("", "pv-integration-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *keep {
+		t.Logf("working-directory: %s", workDir)
+	} else {
+		t.Cleanup(func() { os.RemoveAll(workDir) })
+	}
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeWorkDir(workDir, dbHost, smtpPort, port); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("building the backend")
+	build := exec.Command("go", "build", "-o", filepath.Join(workDir, "backend"), ".")
+	build.Dir = "../backend"
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+
+	if err := build.Run(); err != nil {
+		t.Fatalf("can't build backend: %v", err)
+	}
+
+	t.Logf("starting the backend on port %d", port)
+	server := exec.Command(filepath.Join(workDir, "backend"))
+	server.Dir = workDir
+	server.Stdout = os.Stdout
+	server.Stderr = os.Stderr
+
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { server.Process.Kill() })
+
+	c, err := client.New(fmt.Sprintf("http://localhost:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pool.Retry(func() error {
+		_, err := c.Welcome()
+
+		return err
+	}); err != nil {
+		t.Fatalf("backend isn't reachable: %v", err)
+	}
+
+	return c, mailAPI
+}
+
+// starts a container, it is removed after the test unless "-keep" is given
+func runContainer(t *testing.T, pool *dockertest.Pool, repository, tag string, env ...string) *dockertest.Resource {
+	t.Helper()
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: repository,
+		Tag:        tag,
+		Env:        env,
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		t.Fatalf("can't start %s: %v", repository, err)
+	}
+
+	if !*keep {
+		t.Cleanup(func() {
+			if err := pool.Purge(resource); err != nil {
+				t.Logf("can't remove container: %v", err)
+			}
+		})
+	}
+
+	return resource
+}
+
+// creates the tables from the setup-script and an admin-user
+func setupDatabase(db *sql.DB) error {
+	script, err := os.ReadFile("../setup/setup.sql")
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range strings.Split(string(script), "\n") {
+		if strings.TrimSpace(cmd) == "" {
+			continue
+		}
+
+		if _, err := db.Exec(cmd); err != nil {
+			return fmt.Errorf("can't execute %q: %v", cmd, err)
+		}
+	}
+
+	if hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost); err != nil {
+		return err
+	} else if _, err := db.Exec("INSERT INTO users (name, password, admin) VALUES ('admin', ?, TRUE)", hash); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// retrieves an unused tcp-port
+func freePort() (int, error) {
+	if l, err := net.Listen("tcp", "localhost:0"); err != nil {
+		return 0, err
+	} else {
+		defer l.Close()
+
+		return l.Addr().(*net.TCPAddr).Port, nil
+	}
+}
+
+// writes the config-file and the templates into the working-directory
+func writeWorkDir(workDir, dbHost, smtpPort string, port int) error {
+	files := map[string]string{
+		"config.yaml":                         fmt.Sprintf(configTemplate, dbHost, dbPassword, dbName, port, smtpPort),
+		"templates/reservation_mail":          "Reservierung {{ .Element }}",
+		"templates/reservation_mail.txt":      "Reservierung von {{ .Element }} für {{ .Name }}",
+		"templates/reservation_mail.html":     "<p>Reservierung von {{ .Element }} für {{ .Name }}</p>",
+		"templates/certificate_mail":          "Urkunde {{ .Element }}",
+		"templates/certificate_mail.txt":      "Urkunde {{ .Serial }} für {{ .Element }}, Prüfcode {{ .Code }}",
+		"templates/certificate_mail.html":     "<p>Urkunde für {{ .Element }}</p>",
+		"templates/template_with_name.svg":    svgTemplate,
+		"templates/template_without_name.svg": svgTemplate,
+	}
+
+	for name, content := range files {
+		pth := filepath.Join(workDir, name)
+
+		if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+			return err
+		} else if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if *inkscape != "" {
+		if abs, err := filepath.Abs(*inkscape); err != nil {
+			return err
+		} else if err := os.Symlink(abs, filepath.Join(workDir, "inkscape")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const configTemplate = `log_level: DEBUG
+database:
+  host: %s
+  user: root
+  password: %s
+  database: %s
+cache:
+  expiration: 1h
+  purge: 1h
+client_session:
+  jwt_signature: integration-signature
+  expire: 1h
+server:
+  port: %d
+reservation:
+  expiration: 168h
+  max_per_mail: 5
+  limit_window: 168h
+mail:
+  server: localhost
+  port: %s
+  encryption: none
+  user: integration@example.org
+  password: ""
+validate_elements:
+  regex: ^(pv-\w|(?:wr|bs)-)(\d{1,2})$
+  valid_elements:
+    pv-a:
+      from: 1
+      to: 16
+`
+
+const svgTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="210mm" height="297mm"><text x="20" y="40">{{ .Element }} {{ .Name }}</text></svg>`
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/johannesbuehl/johannes-pv/backend/lib"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// version of the archive-format, increased on incompatible changes
+const archiveVersion = 1
+
+// exported tables in the order they are restored. The postal-addresses are encrypted with
+// "postal.key", so the target has to be configured with the same key. Not exported are:
+//   - mailqueue: the queued mails are still sent by the old host, sending them from the new
+//     one as well would deliver them twice
+//   - expiries: they only count the expiries within the throttle-window, without them the
+//     throttling is relaxed for a while
+//   - reservationclients: the addresses of the clients are only kept on the host they were
+//     recorded on, until "reservation.client_retention" removes them
+var archiveTables = []string{
+	"users", "elements", "certificates", "sponsorships_archive", "matchings", "postaladdresses",
+	"consents", "approvednames", "newsletter", "midaliases", "merges", "mergedcontacts",
+	"apikeys", "apikeyusage", "campaigns", "campaignmails", "notifications", "audit", "settings",
+}
+
+// columns that aren't exported per table
+var excludedColumns = map[string][]string{
+	"users": {"password"},
+}
+
+// binary columns per table, they are exported base64-encoded
+var binaryColumns = map[string][]string{
+	"postaladdresses": {"address"},
+}
+
+// tables without a unique key. Existing rows can't be detected, so they are only restored
+// into empty tables
+var keylessTables = []string{"consents", "approvednames", "mergedcontacts"}
+
+// directory of the templates, relative to the state-directory
+const templateDir = "templates"
+
+// files created by the backend inside the templates, which aren't exported
+var generatedFiles = regexp.MustCompile(`^certificate\..+\.(pdf|svg)$`)
+
+// signed archive as written to the file
+type signedArchive struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+}
+
+// content of the archive
+type archive struct {
+	Version int    `json:"version"`
+	Created string `json:"created"`
+	// version of the backend the archive was created with
+	Backend string `json:"backend"`
+	// exported columns per table
+	Schema map[string][]string         `json:"schema"`
+	Tables map[string][]map[string]any `json:"tables"`
+	// content of the templates by their path inside the templates-directory
+	Templates map[string][]byte `json:"templates"`
+}
+
+// signs the payload with the key
+func sign(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// reads the templates of the deployment
+func readTemplates() (map[string][]byte, error) {
+	templates := make(map[string][]byte)
+
+	err := filepath.WalkDir(templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || generatedFiles.MatchString(d.Name()) {
+			return err
+		} else if content, err := os.ReadFile(path); err != nil {
+			return err
+		} else if rel, err := filepath.Rel(templateDir, path); err != nil {
+			return err
+		} else {
+			templates[filepath.ToSlash(rel)] = content
+
+			return nil
+		}
+	})
+
+	return templates, err
+}
+
+// writes the state of the deployment into a signed archive
+func exportArchive(path string, key []byte) error {
+	data := archive{
+		Version: archiveVersion,
+		Created: time.Now().Format(time.DateTime),
+		Backend: lib.Version,
+		Schema:  make(map[string][]string),
+		Tables:  make(map[string][]map[string]any),
+	}
+
+	for _, table := range archiveTables {
+		if columns, err := store.Columns(table); err != nil {
+			return fmt.Errorf("can't read columns of table %q: %v", table, err)
+		} else if rows, err := store.Dump(table); err != nil {
+			return fmt.Errorf("can't read table %q: %v", table, err)
+		} else {
+			for _, row := range rows {
+				for _, col := range excludedColumns[table] {
+					delete(row, col)
+				}
+
+				for _, col := range binaryColumns[table] {
+					if value, ok := row[col].(string); ok {
+						row[col] = base64.StdEncoding.EncodeToString([]byte(value))
+					}
+				}
+			}
+
+			data.Schema[table] = slices.DeleteFunc(columns, func(col string) bool {
+				return slices.Contains(excludedColumns[table], col)
+			})
+			data.Tables[table] = rows
+
+			fmt.Printf("\texported %d rows of table %q\n", len(rows), table)
+		}
+	}
+
+	if templates, err := readTemplates(); err != nil {
+		return fmt.Errorf("can't read templates: %v", err)
+	} else {
+		data.Templates = templates
+
+		fmt.Printf("\texported %d templates\n", len(templates))
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+
+	if err := json.NewEncoder(zw).Encode(signedArchive{Payload: payload, Signature: sign(key, payload)}); err != nil {
+		return err
+	} else if err := zw.Close(); err != nil {
+		return err
+	} else if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote archive %q\n", path)
+
+	return nil
+}
+
+// reads and verifies a signed archive
+func readArchive(path string, key []byte) (archive, error) {
+	var data archive
+	var signed signedArchive
+
+	if file, err := os.Open(path); err != nil {
+		return data, err
+	} else {
+		defer file.Close()
+
+		if zr, err := gzip.NewReader(file); err != nil {
+			return data, err
+		} else if err := json.NewDecoder(zr).Decode(&signed); err != nil {
+			return data, fmt.Errorf("can't parse archive: %v", err)
+		}
+	}
+
+	if !hmac.Equal([]byte(sign(key, signed.Payload)), []byte(signed.Signature)) {
+		return data, fmt.Errorf("invalid signature, the archive was modified or signed with a different key")
+	}
+
+	// keep the numbers as they are, instead of converting them to floats
+	decoder := json.NewDecoder(bytes.NewReader(signed.Payload))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&data); err != nil {
+		return data, fmt.Errorf("can't parse archive: %v", err)
+	} else if data.Version != archiveVersion {
+		return data, fmt.Errorf("unsupported archive-version %d, expected %d", data.Version, archiveVersion)
+	}
+
+	return data, nil
+}
+
+// checks wether all exported columns exist in the database
+func checkSchema(data archive) error {
+	for _, table := range archiveTables {
+		if columns, err := store.Columns(table); err != nil {
+			return fmt.Errorf("can't read columns of table %q: %v", table, err)
+		} else {
+			for _, col := range data.Schema[table] {
+				if !slices.Contains(columns, col) {
+					return fmt.Errorf("column %q of table %q doesn't exist in the database, run the setup of the backend-version %q first", col, table, data.Backend)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// restores the state of the deployment from a signed archive
+func importArchive(path string, key []byte, conflict store.Conflict) error {
+	data, err := readArchive(path, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("read archive of backend-version %q, created %s\n", data.Backend, data.Created)
+
+	if err := checkSchema(data); err != nil {
+		return err
+	}
+
+	// check the templates before touching the database
+	templates := make(map[string][]byte)
+
+	for name, content := range data.Templates {
+		target := filepath.Join(templateDir, filepath.FromSlash(name))
+
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("invalid template-path %q", name)
+		} else if existing, err := os.ReadFile(target); err == nil && !bytes.Equal(existing, content) {
+			switch conflict {
+			case store.ConflictAbort:
+				return fmt.Errorf("template %q already exists with a different content", name)
+			case store.ConflictSkip:
+				continue
+			}
+		} else if err == nil {
+			continue
+		}
+
+		templates[target] = content
+	}
+
+	for table, columns := range binaryColumns {
+		for _, row := range data.Tables[table] {
+			for _, col := range columns {
+				if value, ok := row[col].(string); !ok {
+					continue
+				} else if decoded, err := base64.StdEncoding.DecodeString(value); err != nil {
+					return fmt.Errorf("can't decode column %q of table %q: %v", col, table, err)
+				} else {
+					row[col] = decoded
+				}
+			}
+		}
+	}
+
+	// a repeated import would duplicate the rows of the tables without a key
+	for _, table := range keylessTables {
+		var count int
+
+		if len(data.Tables[table]) == 0 {
+			continue
+		} else if err := store.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count); err != nil {
+			return fmt.Errorf("can't count rows of table %q: %v", table, err)
+		} else if count == 0 {
+			continue
+		} else if conflict == store.ConflictAbort {
+			return fmt.Errorf("table %q already contains rows and has no key to detect the existing ones", table)
+		}
+
+		fmt.Printf("	skipping table %q: it already contains rows and has no key to detect the existing ones\n", table)
+
+		delete(data.Tables, table)
+	}
+
+	// the passwords aren't exported, new users get an unknown random one
+	for _, row := range data.Tables["users"] {
+		password := make([]byte, 32)
+
+		if _, err := rand.Read(password); err != nil {
+			return err
+		} else if hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost); err != nil {
+			return err
+		} else {
+			row["password"] = hash
+		}
+	}
+
+	if written, err := store.Restore(data.Tables, archiveTables, conflict, map[string][]string{"users": {"password"}}); err != nil {
+		return err
+	} else {
+		for _, table := range archiveTables {
+			fmt.Printf("\trestored %d of %d rows of table %q\n", written[table], len(data.Tables[table]), table)
+		}
+	}
+
+	for target, content := range templates {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		} else if err := os.WriteFile(target, content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\trestored %d templates\n", len(templates))
+	fmt.Println("imported users have to get a new password from an admin")
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+	"github.com/johannesbuehl/johannes-pv/backend/store"
+	"github.com/rs/zerolog"
+)
+
+const usage = `usage: pvadmin [flags] <command>
+
+commands:
+  export <file>   writes the state of the deployment into a signed archive
+  import <file>   restores the state of the deployment from a signed archive
+  config example  writes a commented example of the config-file with the defaults
+  smoke <mid>     checks a running deployment end-to-end with a test-reservation of the free
+                  element, which is removed afterwards
+
+flags:
+`
+
+func exit(e error) {
+	fmt.Fprintf(os.Stderr, "%v\n", e)
+	os.Exit(1)
+}
+
+func main() {
+	configPath := flag.String("config", "../backend/config.yaml", "path of the config-file of the backend")
+	stateDir := flag.String("state-dir", "../backend", "directory of the templates of the backend")
+	key := flag.String("key", os.Getenv("PVADMIN_KEY"), `key the archive is signed with, defaults to "PVADMIN_KEY"`)
+	conflicts := flag.String("conflicts", string(store.ConflictAbort), `handling of existing entries on import: "abort", "skip" or "update"`)
+	smokeURL := flag.String("url", "http://localhost:8080", "address of the deployment checked by the smoke-test")
+	smokeUser := flag.String("user", "admin", "user the smoke-test logs in as")
+	smokeMail := flag.String("mail", "", "recipient of the reservation-mail of the smoke-test")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// the example doesn't need a deployment
+	if flag.NArg() == 2 && strings.ToLower(flag.Arg(0)) == "config" {
+		if strings.ToLower(flag.Arg(1)) != "example" {
+			exit(fmt.Errorf("unknown config-command %q", flag.Arg(1)))
+		} else if example, err := config.Example(); err != nil {
+			exit(err)
+		} else {
+			fmt.Print(example)
+		}
+
+		return
+	}
+
+	// the smoke-test only uses the api of the running deployment
+	if flag.NArg() == 2 && strings.ToLower(flag.Arg(0)) == "smoke" {
+		if *smokeMail == "" {
+			exit(fmt.Errorf("a mail-address is required for the reservation-mail"))
+		} else if password, ok := os.LookupEnv("PVADMIN_PASSWORD"); !ok {
+			exit(fmt.Errorf(`the password of the user is required in "PVADMIN_PASSWORD"`))
+		} else if err := runSmokeTest(smokeTest{url: *smokeURL, user: *smokeUser, password: password, mid: flag.Arg(1), mail: *smokeMail}); err != nil {
+			exit(err)
+		}
+
+		fmt.Println("smoke-test passed")
+
+		return
+	}
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	} else if *key == "" {
+		exit(fmt.Errorf("a key is required to sign and verify the archive"))
+	}
+
+	conflict := store.Conflict(*conflicts)
+
+	switch conflict {
+	case store.ConflictAbort, store.ConflictSkip, store.ConflictUpdate:
+	default:
+		exit(fmt.Errorf("invalid conflict-handling %q", *conflicts))
+	}
+
+	// the archive is read and written relative to the original working-directory
+	archivePath, err := filepath.Abs(flag.Arg(1))
+	if err != nil {
+		exit(err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		exit(fmt.Errorf("can't load config: %v", err))
+	}
+
+	if err := os.Chdir(*stateDir); err != nil {
+		exit(fmt.Errorf("can't change into state-directory: %v", err))
+	}
+
+	fmt.Println("connecting to database")
+
+	if err := store.Open(cfg, zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr})); err != nil {
+		exit(err)
+	}
+
+	defer store.Close()
+
+	switch strings.ToLower(flag.Arg(0)) {
+	case "export":
+		err = exportArchive(archivePath, []byte(*key))
+	case "import":
+		err = importArchive(archivePath, []byte(*key), conflict)
+	default:
+		err = fmt.Errorf("unknown command %q", flag.Arg(0))
+	}
+
+	if err != nil {
+		store.Close()
+		exit(err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/johannesbuehl/johannes-pv/backend/api"
+	"github.com/johannesbuehl/johannes-pv/backend/client"
+	"github.com/johannesbuehl/johannes-pv/backend/config"
+)
+
+// name of the sponsor of the test-reservation
+const smokeName = "pvadmin smoke-test"
+
+// settings of the smoke-test
+type smokeTest struct {
+	url      string
+	user     string
+	password string
+	// element reserved for the test, it has to be free
+	mid string
+	// recipient of the reservation-mail
+	mail string
+}
+
+// result of the steps of the smoke-test
+type smokeReport struct {
+	failed bool
+}
+
+// runs a step of the smoke-test and prints its result, false if it failed
+func (report *smokeReport) step(name string, fn func() error) bool {
+	if err := fn(); err != nil {
+		fmt.Printf("FAIL  %s: %v\n", name, err)
+
+		report.failed = true
+
+		return false
+	} else {
+		fmt.Printf("PASS  %s\n", name)
+
+		return true
+	}
+}
+
+// values for the required custom fields of the reservation-form
+func smokeFields(fields []config.CustomField) map[string]any {
+	values := map[string]any{}
+
+	for _, field := range fields {
+		if !field.Required {
+			continue
+		}
+
+		switch field.Type {
+		case "bool":
+			values[field.Name] = true
+		case "number":
+			values[field.Name] = 1
+		case "select":
+			values[field.Name] = field.Options[0]
+		default:
+			values[field.Name] = "smoke-test"
+		}
+	}
+
+	return values
+}
+
+// checks a running deployment end-to-end: logs in, reserves the test-element, renders a certificate
+// of it and removes the reservation again. The reservation is removed even if a later step fails
+func runSmokeTest(test smokeTest) error {
+	report := smokeReport{}
+
+	c, err := client.New(test.url)
+	if err != nil {
+		return err
+	}
+
+	report.step("backend is reachable", func() error {
+		version, err := c.GetVersion()
+		if err == nil {
+			fmt.Printf("      version %s\n", version.Version)
+		}
+
+		return err
+	})
+
+	if !report.step(fmt.Sprintf("login as %q", test.user), func() error {
+		_, err := c.Login(test.user, test.password)
+
+		return err
+	}) {
+		return fmt.Errorf("smoke-test failed")
+	}
+
+	defer report.step("logout", func() error {
+		_, err := c.Logout()
+
+		return err
+	})
+
+	if !report.step(fmt.Sprintf("element %q is free", test.mid), func() error {
+		if status, err := c.GetElements(); err != nil {
+			return err
+		} else if _, taken := status.Taken[test.mid]; taken || slices.Contains(status.Reserved, test.mid) {
+			return fmt.Errorf("element is reserved or sponsored, choose a free one")
+		} else {
+			return nil
+		}
+	}) {
+		return fmt.Errorf("smoke-test failed")
+	}
+
+	reserved := report.step("reserve element", func() error {
+		body := api.ReservationBody{Name: smokeName, Mail: test.mail, Source: "smoke-test", Legal: map[string]string{}}
+
+		if fields, err := c.GetFields(); err != nil {
+			return fmt.Errorf("can't get fields: %v", err)
+		} else {
+			body.Fields = smokeFields(fields)
+		}
+
+		if legal, err := c.GetLegal(); err != nil {
+			return fmt.Errorf("can't get legal documents: %v", err)
+		} else {
+			for _, document := range legal {
+				body.Legal[document.Kind] = document.Version
+			}
+		}
+
+		if status, err := c.ReserveElement(test.mid, body); err != nil {
+			return err
+		} else if !slices.Contains(status.Reserved, test.mid) {
+			return fmt.Errorf("element isn't listed as reserved")
+		} else {
+			return nil
+		}
+	})
+
+	if reserved {
+		report.step("reservation is listed", func() error {
+			if reservations, err := c.ListReservations(); err != nil {
+				return err
+			} else if !slices.ContainsFunc(reservations, func(reservation api.Reservation) bool { return reservation.Mid == test.mid }) {
+				return fmt.Errorf("reservation of %q is missing", test.mid)
+			} else {
+				return nil
+			}
+		})
+
+		report.step("render certificate", func() error {
+			if pdf, err := c.PreviewCertificate(test.mid, smokeName); err != nil {
+				return err
+			} else if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+				return fmt.Errorf("response isn't a pdf-file")
+			} else {
+				return nil
+			}
+		})
+
+		report.step("remove reservation", func() error {
+			if _, err := c.DeleteReservation(test.mid); err != nil {
+				return err
+			} else if status, err := c.GetElements(); err != nil {
+				return err
+			} else if slices.Contains(status.Reserved, test.mid) {
+				return fmt.Errorf("element is still reserved")
+			} else {
+				return nil
+			}
+		})
+	}
+
+	if report.failed {
+		return fmt.Errorf("smoke-test failed")
+	}
+
+	return nil
+}
@@ -4,76 +4,19 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"time"
 
+	backendConfig "github.com/johannesbuehl/johannes-pv/backend/config"
 	"gopkg.in/yaml.v3"
 )
 
 var CONFIG_PATH = "../backend/config.yaml"
 
-type ConfigYaml struct {
-	LogLevel string `yaml:"log_level"`
-	Database struct {
-		Host     string `yaml:"host"`
-		User     string `yaml:"user"`
-		Password string `yaml:"password"`
-		Database string `yaml:"database"`
-	} `yaml:"database"`
-	Cache struct {
-		Expiration string `yaml:"expiration"`
-		Purge      string `yaml:"purge"`
-	} `yaml:"cache"`
-	ClientSession struct {
-		JwtSignature string `yaml:"jwt_signature"`
-		Expire       string `yaml:"expire"`
-	} `yaml:"client_session"`
-	Server struct {
-		Port int `yaml:"port"`
-	} `yaml:"server"`
-	Reservation struct {
-		Expiration string `yaml:"expiration"`
-	} `yaml:"reservation"`
-	Mail struct {
-		Server    string `yaml:"server"`
-		Port      int    `yaml:"port"`
-		User      string `yaml:"user"`
-		Password  string `yaml:"password"`
-		Templates struct {
-			ReservationSubject string `yaml:"reservation_subject"`
-			CertificateSubject string `yaml:"certificate_subject"`
-		} `yaml:"subject_templates"`
-	} `yaml:"mail"`
-	ValidateElements struct {
-		Regex         string `yaml:"regex"`
-		ValidElements map[string]struct {
-			From int `yaml:"from"`
-			To   int `yaml:"to"`
-		} `yaml:"valid_elements"`
-	} `yaml:"validate_elements"`
-}
-
-type CacheConfig struct {
-	Expiration time.Duration
-	Purge      time.Duration
-}
-
-var config ConfigYaml
-
-func loadConfig() ConfigYaml {
-	config := ConfigYaml{}
-
-	yamlFile, err := os.ReadFile(CONFIG_PATH)
-	if err != nil {
-		panic(fmt.Sprintf("Error opening config-file: %v", err))
-	}
-
-	reader := bytes.NewReader(yamlFile)
+var config backendConfig.ConfigYaml
 
-	dec := yaml.NewDecoder(reader)
-	dec.KnownFields(true)
-	err = dec.Decode(&config)
+func loadConfig() backendConfig.ConfigYaml {
+	config, err := backendConfig.LoadYaml(CONFIG_PATH)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing config-file: %v", err)
+		fmt.Fprintf(os.Stderr, "Error loading config-file: %v", err)
 		os.Exit(1)
 	}
 
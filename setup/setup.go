@@ -1,7 +1,9 @@
 package main
 
 import (
+	cryptoRand "crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
 	"math/rand/v2"
 	"os"
@@ -23,11 +25,65 @@ func createPassword(l int) string {
 	return password
 }
 
+// creates a random base64-encoded 32-byte key
+func createKey() string {
+	key := make([]byte, 32)
+
+	if _, err := cryptoRand.Read(key); err != nil {
+		exit(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key)
+}
+
 func exit(e error) {
 	fmt.Printf("%v\n", e)
 	os.Exit(1)
 }
 
+// upgrades the tables of an existing installation: creates the missing tables from "setup.sql"
+// and runs the statements of "upgrade.sql"
+func upgradeDatabase(db *sql.DB, setupScript []byte) {
+	fmt.Println(`reading "upgrade.sql"`)
+	upgradeScript, err := os.ReadFile("upgrade.sql")
+	if err != nil {
+		exit(err)
+	}
+
+	fmt.Println("creating the missing tables")
+	for _, cmd := range strings.Split(string(setupScript), "\n") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			if _, err := db.Exec(regexp.MustCompile(`(?i)^create table `).ReplaceAllString(cmd, "CREATE TABLE IF NOT EXISTS ")); err != nil {
+				exit(fmt.Errorf("can't create table: %v", err))
+			}
+		}
+	}
+
+	fmt.Println("upgrading the tables")
+	for _, cmd := range strings.Split(string(upgradeScript), "\n") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" && !strings.HasPrefix(cmd, "--") {
+			if _, err := db.Exec(cmd); err != nil {
+				exit(fmt.Errorf("can't run %q: %v", cmd, err))
+			}
+		}
+	}
+
+	// installations from before the encryption get the keys, too
+	if config.Postal.Key == "" || config.Database.EncryptionKey == "" {
+		if config.Postal.Key == "" {
+			config.Postal.Key = createKey()
+		}
+
+		if config.Database.EncryptionKey == "" {
+			config.Database.EncryptionKey = createKey()
+		}
+
+		writeConfig()
+	}
+
+	fmt.Println("upgraded the database")
+}
+
 func main() {
 	fmt.Println("connecting to database")
 
@@ -55,6 +111,13 @@ func main() {
 		sqlScriptCommands = c
 	}
 
+	// existing installations are upgraded instead
+	if len(os.Args) > 1 && os.Args[1] == "upgrade" {
+		upgradeDatabase(db, sqlScriptCommands)
+
+		return
+	}
+
 	// read the currently availabe tables
 	fmt.Println("reading available tables in database")
 	if rows, err := db.Query("SHOW TABLES"); err != nil {
@@ -101,7 +164,7 @@ func main() {
 		fmt.Println("\thashed password")
 
 		// create an admin-user
-		if _, err := db.Exec("INSERT INTO users (name, password) VALUES ('admin', ?)", passwordHash); err != nil {
+		if _, err := db.Exec("INSERT INTO users (name, password, admin) VALUES ('admin', ?, TRUE)", passwordHash); err != nil {
 			exit(err)
 		}
 
@@ -113,6 +176,15 @@ func main() {
 	// create a jwt-signature
 	config.ClientSession.JwtSignature = createPassword(100)
 
+	// create the keys of the postal-addresses and the contact-data
+	if config.Postal.Key == "" {
+		config.Postal.Key = createKey()
+	}
+
+	if config.Database.EncryptionKey == "" {
+		config.Database.EncryptionKey = createKey()
+	}
+
 	// write the modified config-file
 	writeConfig()
 }
@@ -1,2 +1,22 @@
-CREATE TABLE elements (mid CHAR(6) NOT NULL KEY , name TINYTEXT NOT NULL DEFAULT "", mail TINYTEXT, reservation TIMESTAMP NULL DEFAULT current_timestamp());
-CREATE TABLE users (uid INT NOT NULL KEY auto_increment, name TINYTEXT NOT NULL, password binary(60) NOT NULL, tid INT NOT NULL DEFAULT 0);
+CREATE TABLE elements (mid VARCHAR(12) NOT NULL KEY , name TINYTEXT NOT NULL DEFAULT "", mail TEXT, reservation TIMESTAMP NULL DEFAULT current_timestamp(), source TINYTEXT, confirmed TIMESTAMP NULL, thankyou TIMESTAMP NULL, optout BOOLEAN NOT NULL DEFAULT FALSE, notes TEXT, pending BOOLEAN NOT NULL DEFAULT FALSE, buyer TINYTEXT, giftmail TEXT, giftdelivery TIMESTAMP NULL, fields TEXT NOT NULL DEFAULT "{}", dedication TEXT, approvedname TINYTEXT, rejectedname TINYTEXT, matchingemployer TINYTEXT, matchingreference TINYTEXT);
+CREATE TABLE users (uid INT NOT NULL KEY auto_increment, name TINYTEXT NOT NULL, password VARBINARY(255) NOT NULL, tid INT NOT NULL DEFAULT 0, mail TINYTEXT, mailverified TIMESTAMP NULL, created TIMESTAMP NOT NULL DEFAULT current_timestamp(), lastlogin TIMESTAMP NULL, lastaction TIMESTAMP NULL, deactivated BOOLEAN NOT NULL DEFAULT FALSE, issuecertificates BOOLEAN NOT NULL DEFAULT FALSE, admin BOOLEAN NOT NULL DEFAULT FALSE, adminrequested TIMESTAMP NULL, adminrequestedby INT NULL);
+CREATE TABLE newsletter (mail VARCHAR(512) NOT NULL KEY, name TINYTEXT NOT NULL DEFAULT "", consent TIMESTAMP NOT NULL DEFAULT current_timestamp(), ip TINYTEXT);
+CREATE TABLE settings (name VARCHAR(64) NOT NULL KEY, value TEXT NOT NULL);
+CREATE TABLE certificates (serial INT NOT NULL KEY auto_increment, code CHAR(12) NOT NULL UNIQUE, mid VARCHAR(12) NOT NULL, name TINYTEXT NOT NULL DEFAULT "", issued TIMESTAMP NOT NULL DEFAULT current_timestamp(), mailhash CHAR(64) NOT NULL DEFAULT "", KEY (mailhash));
+CREATE TABLE campaigns (cid INT NOT NULL KEY auto_increment, subject TEXT NOT NULL, body TEXT NOT NULL, created TIMESTAMP NOT NULL DEFAULT current_timestamp());
+CREATE TABLE campaignmails (cid INT NOT NULL, mail VARCHAR(512) NOT NULL, name TINYTEXT NOT NULL DEFAULT "", elements TEXT NOT NULL, status VARCHAR(16) NOT NULL DEFAULT "queued", error TEXT, sent TIMESTAMP NULL, PRIMARY KEY (cid, mail));
+CREATE TABLE notifications (nid INT NOT NULL KEY auto_increment, uid INT NOT NULL, kind VARCHAR(32) NOT NULL, mid VARCHAR(12) NOT NULL DEFAULT "", message TEXT NOT NULL, created TIMESTAMP NOT NULL DEFAULT current_timestamp(), readat TIMESTAMP NULL);
+CREATE TABLE midaliases (aid INT NOT NULL KEY auto_increment, old VARCHAR(12) NOT NULL, new VARCHAR(12) NOT NULL, renamed TIMESTAMP NOT NULL DEFAULT current_timestamp(), uid INT NOT NULL);
+CREATE TABLE apikeys (kid INT NOT NULL KEY auto_increment, name TINYTEXT NOT NULL, keyhash CHAR(64) NOT NULL UNIQUE, origins TEXT NOT NULL, quota INT NOT NULL DEFAULT 0, created TIMESTAMP NOT NULL DEFAULT current_timestamp(), revoked TIMESTAMP NULL);
+CREATE TABLE apikeyusage (kid INT NOT NULL, day DATE NOT NULL, requests INT NOT NULL DEFAULT 0, reservations INT NOT NULL DEFAULT 0, PRIMARY KEY (kid, day));
+CREATE TABLE consents (mail VARCHAR(512) NOT NULL, mid VARCHAR(12) NOT NULL, kind VARCHAR(32) NOT NULL, version VARCHAR(64) NOT NULL, accepted TIMESTAMP NOT NULL DEFAULT current_timestamp(), ip TINYTEXT NOT NULL, KEY (mail));
+CREATE TABLE merges (mgid INT NOT NULL KEY auto_increment, canonical VARCHAR(12) NOT NULL, merged TIMESTAMP NOT NULL DEFAULT current_timestamp(), uid INT NOT NULL, undone TIMESTAMP NULL);
+CREATE TABLE mergedcontacts (mgid INT NOT NULL, mid VARCHAR(12) NOT NULL, name TINYTEXT NOT NULL, mail TEXT, KEY (mgid));
+CREATE TABLE sponsorships_archive (aid INT NOT NULL KEY auto_increment, mid VARCHAR(12) NOT NULL, name TINYTEXT NOT NULL, mail TEXT, source TINYTEXT, reservation TIMESTAMP NULL, confirmed TIMESTAMP NOT NULL, uid INT NOT NULL, username TINYTEXT NOT NULL, amount DOUBLE NOT NULL DEFAULT 0, fields TEXT NOT NULL, buyer TINYTEXT, giftmail TEXT, serial INT NOT NULL, KEY (mid));
+CREATE TABLE mailqueue (qid INT NOT NULL KEY auto_increment, mid VARCHAR(12) NOT NULL DEFAULT "", recipient TEXT NOT NULL, subject TEXT NOT NULL, html MEDIUMTEXT NOT NULL, plain MEDIUMTEXT NOT NULL, queued TIMESTAMP NOT NULL DEFAULT current_timestamp(), attempts INT NOT NULL DEFAULT 0, lasterror TEXT, KEY (mid));
+CREATE TABLE audit (auid INT NOT NULL KEY auto_increment, uid INT NOT NULL, mail TINYTEXT NOT NULL DEFAULT "", action VARCHAR(16) NOT NULL, mid VARCHAR(12) NOT NULL, created TIMESTAMP NOT NULL DEFAULT current_timestamp(), KEY (uid));
+CREATE TABLE postaladdresses (mid VARCHAR(12) NOT NULL KEY, address BLOB NOT NULL, printed BOOLEAN NOT NULL DEFAULT FALSE, exported TIMESTAMP NULL);
+CREATE TABLE expiries (mid VARCHAR(12) NOT NULL, reservation TIMESTAMP NOT NULL, expired TIMESTAMP NOT NULL DEFAULT current_timestamp(), PRIMARY KEY (mid, reservation));
+CREATE TABLE reservationclients (mid VARCHAR(12) NOT NULL, reservation TIMESTAMP NOT NULL, ip TINYTEXT NOT NULL, useragent TEXT NOT NULL, KEY (mid));
+CREATE TABLE approvednames (mailhash CHAR(64) NOT NULL, name TINYTEXT NOT NULL, approved TIMESTAMP NOT NULL DEFAULT current_timestamp(), KEY (mailhash));
+CREATE TABLE matchings (maid INT NOT NULL KEY auto_increment, mid VARCHAR(12) NOT NULL, employer TINYTEXT NOT NULL, reference TINYTEXT, amount DOUBLE NOT NULL DEFAULT 0, created TIMESTAMP NOT NULL DEFAULT current_timestamp(), confirmed TIMESTAMP NULL, uid INT NULL, KEY (mid));
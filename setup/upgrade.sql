@@ -0,0 +1,68 @@
+-- upgrades the tables of an existing installation to the current "setup.sql", run by "go run . upgrade".
+-- Missing tables are created from "setup.sql" first, every statement can be run again
+-- source of the reservations
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS source TINYTEXT;
+-- confirmations and thank-you-mails
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS confirmed TIMESTAMP NULL;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS thankyou TIMESTAMP NULL;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS optout BOOLEAN NOT NULL DEFAULT FALSE;
+-- activity of the users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created TIMESTAMP NOT NULL DEFAULT current_timestamp();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lastlogin TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lastaction TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated BOOLEAN NOT NULL DEFAULT FALSE;
+-- internal notes
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS notes TEXT;
+-- approval of new reservations
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS pending BOOLEAN NOT NULL DEFAULT FALSE;
+-- gift-reservations
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS buyer TINYTEXT;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS giftmail TINYTEXT;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS giftdelivery TIMESTAMP NULL;
+-- custom fields of the reservation-form
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS fields TEXT NOT NULL DEFAULT "{}";
+-- mids with shares
+ALTER TABLE elements MODIFY COLUMN mid VARCHAR(12) NOT NULL;
+ALTER TABLE certificates MODIFY COLUMN mid VARCHAR(12) NOT NULL;
+ALTER TABLE notifications MODIFY COLUMN mid VARCHAR(12) NOT NULL DEFAULT "";
+ALTER TABLE midaliases MODIFY COLUMN old VARCHAR(12) NOT NULL;
+ALTER TABLE midaliases MODIFY COLUMN new VARCHAR(12) NOT NULL;
+ALTER TABLE consents MODIFY COLUMN mid VARCHAR(12) NOT NULL;
+ALTER TABLE merges MODIFY COLUMN canonical VARCHAR(12) NOT NULL;
+ALTER TABLE mergedcontacts MODIFY COLUMN mid VARCHAR(12) NOT NULL;
+-- requests of the certificates by mail-address
+ALTER TABLE certificates ADD COLUMN IF NOT EXISTS mailhash CHAR(64) NOT NULL DEFAULT "";
+ALTER TABLE certificates ADD INDEX IF NOT EXISTS mailhash (mailhash);
+-- capabilities of the users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS issuecertificates BOOLEAN NOT NULL DEFAULT FALSE;
+-- encrypted contact-data
+ALTER TABLE elements MODIFY COLUMN mail TEXT;
+ALTER TABLE elements MODIFY COLUMN giftmail TEXT;
+ALTER TABLE mergedcontacts MODIFY COLUMN mail TEXT;
+ALTER TABLE sponsorships_archive MODIFY COLUMN mail TEXT;
+ALTER TABLE sponsorships_archive MODIFY COLUMN giftmail TEXT;
+-- mail-addresses of the users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mail TINYTEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mailverified TIMESTAMP NULL;
+ALTER TABLE audit ADD COLUMN IF NOT EXISTS mail TINYTEXT NOT NULL DEFAULT "";
+-- configurable password-hashes
+ALTER TABLE users MODIFY COLUMN password VARBINARY(255) NOT NULL;
+-- admins confirmed by a second admin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS adminrequested TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS adminrequestedby INT NULL;
+-- dedications on the certificates
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS dedication TEXT;
+-- moderation of the sponsor-names
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS approvedname TINYTEXT;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS rejectedname TINYTEXT;
+-- matching-donations
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS matchingemployer TINYTEXT;
+ALTER TABLE elements ADD COLUMN IF NOT EXISTS matchingreference TINYTEXT;
+-- encrypted mail-addresses of the newsletter, the consents, the campaigns and the mail-queue
+ALTER TABLE newsletter MODIFY COLUMN mail VARCHAR(512) NOT NULL;
+ALTER TABLE consents MODIFY COLUMN mail VARCHAR(512) NOT NULL;
+ALTER TABLE campaignmails MODIFY COLUMN mail VARCHAR(512) NOT NULL;
+ALTER TABLE mailqueue MODIFY COLUMN recipient TEXT NOT NULL;
+-- the admin of the setup had the admin-role by its name before
+UPDATE users SET admin = TRUE WHERE name = 'admin';